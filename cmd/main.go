@@ -39,25 +39,96 @@ func main() {
 	log := GetLog()
 	log.Info("starting server", slog.String("config", cfg.String()))
 
-	taskRepo := store.NewInMemoryTaskRepository()
+	var taskRepo store.TaskRepository = store.NewInMemoryTaskRepository()
+	if cfg.Storage == config.StorageMarkdown {
+		markdownRepo, err := store.NewMarkdownTaskRepository(log, cfg.MarkdownDir, cfg.MarkdownPollInterval)
+		if err != nil {
+			log.Error("failed to open task directory", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+		defer markdownRepo.Close()
+		taskRepo = markdownRepo
+	}
 	taskService := service.NewTaskService(log, taskRepo)
 	taskHandler := handler.NewTaskHandler(log, taskService)
 
+	webhookRepo := store.NewInMemoryWebhookRepository()
+	webhookService := service.NewWebhookService(log, webhookRepo, service.DefaultWebhookOptions())
+	webhookHandler := handler.NewWebhookHandler(log, webhookService)
+	taskService.AddListener(webhookService.HandleEvent)
+
+	tagRepo := store.NewInMemoryTagRepository()
+	tagService := service.NewTagService(log, tagRepo, taskService)
+	tagHandler := handler.NewTagHandler(log, tagService)
+
+	viewRepo := store.NewInMemoryViewRepository()
+	viewService := service.NewViewService(log, viewRepo)
+	viewHandler := handler.NewViewHandler(log, viewService, taskService)
+
+	davHandler := handler.NewDavHandler(log, taskService)
+
+	importJobRepo := store.NewInMemoryImportJobRepository()
+	importJobService := service.NewImportJobService(log, importJobRepo, taskService)
+	importJobHandler := handler.NewImportJobHandler(log, importJobService)
+
+	eventBroker := service.NewEventBroker(1000)
+	eventHandler := handler.NewEventHandler(log, eventBroker, 15*time.Second)
+	taskService.AddListener(eventBroker.Publish)
+
 	mux := http.NewServeMux()
 	mux.HandleFunc(http.MethodPost+" /tasks", taskHandler.CreateTask)
 	mux.HandleFunc(http.MethodGet+" /tasks", taskHandler.GetTasks)
+	mux.HandleFunc(http.MethodGet+" /tasks/events", eventHandler.StreamTaskEvents)
+	mux.HandleFunc(http.MethodGet+" /tasks/stats", taskHandler.GetStats)
+	mux.HandleFunc(http.MethodGet+" /tasks/export", taskHandler.ExportTasks)
+	mux.HandleFunc(http.MethodPost+" /tasks/import", taskHandler.ImportTasks)
+	mux.HandleFunc(http.MethodGet+" /tasks.ics", taskHandler.GetTasksIcal)
+	mux.HandleFunc(http.MethodPost+" /tasks.ics", taskHandler.ImportIcal)
+	mux.HandleFunc(http.MethodGet+" /tasks.txt", taskHandler.GetTasksTodotxt)
 	mux.HandleFunc(http.MethodGet+" /tasks/{id}", taskHandler.GetTaskById)
 	mux.HandleFunc(http.MethodPatch+" /tasks/{id}", taskHandler.UpdateTask)
 	mux.HandleFunc(http.MethodDelete+" /tasks/{id}", taskHandler.DeleteTask)
+	mux.HandleFunc(http.MethodPost+" /tasks/{id}/changes", taskHandler.MergeChanges)
+	mux.HandleFunc(http.MethodGet+" /sync", taskHandler.Sync)
+	mux.HandleFunc(http.MethodPost+" /imports", importJobHandler.CreateImportJob)
+	mux.HandleFunc(http.MethodGet+" /imports", importJobHandler.GetImportJobs)
+	mux.HandleFunc(http.MethodGet+" /imports/{id}", importJobHandler.GetImportJobById)
+	mux.HandleFunc(http.MethodPost+" /webhooks", webhookHandler.CreateWebhook)
+	mux.HandleFunc(http.MethodGet+" /webhooks", webhookHandler.GetWebhooks)
+	mux.HandleFunc(http.MethodGet+" /webhooks/{id}", webhookHandler.GetWebhookById)
+	mux.HandleFunc(http.MethodPatch+" /webhooks/{id}", webhookHandler.UpdateWebhook)
+	mux.HandleFunc(http.MethodDelete+" /webhooks/{id}", webhookHandler.DeleteWebhook)
+	mux.HandleFunc(http.MethodGet+" /webhooks/{id}/deliveries", webhookHandler.GetDeliveries)
+	mux.HandleFunc(http.MethodGet+" /tags", tagHandler.GetTags)
+	mux.HandleFunc(http.MethodGet+" /tags/{name}", tagHandler.GetTag)
+	mux.HandleFunc(http.MethodPatch+" /tags/{name}", tagHandler.UpdateTag)
+	mux.HandleFunc(http.MethodPost+" /tags/{name}/rename", tagHandler.RenameTag)
+	mux.HandleFunc(http.MethodPost+" /tags/{name}/merge", tagHandler.MergeTag)
+	mux.HandleFunc(http.MethodPost+" /views", viewHandler.CreateView)
+	mux.HandleFunc(http.MethodGet+" /views", viewHandler.GetViews)
+	mux.HandleFunc(http.MethodGet+" /views/{id}", viewHandler.GetViewById)
+	mux.HandleFunc(http.MethodPatch+" /views/{id}", viewHandler.UpdateView)
+	mux.HandleFunc(http.MethodDelete+" /views/{id}", viewHandler.DeleteView)
+	mux.HandleFunc(http.MethodGet+" /views/{id}/tasks", viewHandler.GetViewTasks)
+	mux.Handle("/.well-known/caldav", http.RedirectHandler("/dav/", http.StatusMovedPermanently))
+	mux.HandleFunc(http.MethodOptions+" /dav/", davHandler.Options)
+	mux.HandleFunc("PROPFIND /dav/", davHandler.Propfind)
+	mux.HandleFunc("REPORT /dav/tasks/{$}", davHandler.Report)
+	mux.HandleFunc(http.MethodGet+" /dav/tasks/{name}", davHandler.GetTask)
+	mux.HandleFunc(http.MethodPut+" /dav/tasks/{name}", davHandler.PutTask)
+	mux.HandleFunc(http.MethodDelete+" /dav/tasks/{name}", davHandler.DeleteTask)
 
 	logMiddleware := func(h http.Handler) http.Handler {
 		return middleware.LogMiddleware(log, h)
 	}
 	server := &http.Server{
 		Addr:              fmt.Sprintf(":%d", cfg.Port),
-		Handler:           middleware.RequestIdMiddleware(logMiddleware(mux)),
+		Handler:           middleware.RequestIdMiddleware(logMiddleware(middleware.HandlingMiddleware(cfg.LenientRequests, mux))),
 		ReadHeaderTimeout: 5 * time.Second,
 	}
+	// Shutdown does not interrupt active connections, so event streams have
+	// to be closed explicitly for it to complete.
+	server.RegisterOnShutdown(eventBroker.Close)
 
 	go func() {
 		if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
@@ -76,5 +147,7 @@ func main() {
 	if err := server.Shutdown(shutdownCtx); err != nil {
 		log.Error("HTTP shutdown error: %v", slog.String("error", err.Error()))
 	}
+	importJobService.Close(shutdownCtx)
+	webhookService.Close(shutdownCtx)
 	log.Info("Graceful shutdown complete")
 }
@@ -0,0 +1,288 @@
+// Command todotxt-sync reconciles a local todo.txt file with the tasks on a
+// server. The lines written by the last sync are kept in a state file, so
+// changes on either side can be told apart; see todotxt.Reconcile.
+//
+//	todotxt-sync -file ~/todo.txt -server http://localhost:8080
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/google/uuid"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"simple-tasks/internal/model"
+	"simple-tasks/internal/todotxt"
+	"strings"
+	"time"
+)
+
+type importReport struct {
+	Rows []struct {
+		Row    int        `json:"row"`
+		Status string     `json:"status"`
+		TaskId *uuid.UUID `json:"taskId"`
+		Errors []struct {
+			Field   string `json:"field"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	} `json:"rows"`
+}
+
+type client struct {
+	server string
+	http   *http.Client
+}
+
+func main() {
+	file := flag.String("file", "todo.txt", "todo.txt file to sync")
+	server := flag.String("server", defaultServer(), "base URL of the tasks API, or $TASKS_SERVER")
+	state := flag.String("state", "", "file with the lines of the last sync (default .<file>.sync next to the file)")
+	dryRun := flag.Bool("dry-run", false, "print the changes without applying them")
+	flag.Parse()
+
+	if *state == "" {
+		*state = filepath.Join(filepath.Dir(*file), "."+filepath.Base(*file)+".sync")
+	}
+
+	c := &client{
+		server: strings.TrimSuffix(*server, "/"),
+		http:   &http.Client{Timeout: 30 * time.Second},
+	}
+	if err := run(c, *file, *state, *dryRun); err != nil {
+		fmt.Fprintln(os.Stderr, "todotxt-sync:", err)
+		os.Exit(1)
+	}
+}
+
+func defaultServer() string {
+	if server := os.Getenv("TASKS_SERVER"); server != "" {
+		return server
+	}
+	return "http://localhost:8080"
+}
+
+func run(c *client, file string, state string, dryRun bool) error {
+	local, err := readLines(file)
+	if err != nil {
+		return err
+	}
+	baseLines, err := readLines(state)
+	if err != nil {
+		return err
+	}
+	base := make(map[string]string)
+	for _, line := range baseLines {
+		base[todotxt.Id(line)] = line
+	}
+
+	tasks, err := c.tasks()
+	if err != nil {
+		return err
+	}
+	plan := todotxt.Reconcile(local, base, formatTasks(tasks))
+
+	pushes := make([]string, 0)
+	for _, entry := range plan.Entries {
+		if entry.Push {
+			pushes = append(pushes, entry.Line)
+		}
+	}
+	for _, id := range plan.Conflicts {
+		fmt.Printf("conflict: %s changed on both sides, keeping the local line\n", id)
+	}
+
+	if dryRun {
+		for _, line := range pushes {
+			fmt.Println("push:  ", line)
+		}
+		for _, id := range plan.Deletes {
+			fmt.Println("delete:", id)
+		}
+		return nil
+	}
+
+	report, err := c.importLines(pushes)
+	if err != nil {
+		return err
+	}
+	for _, id := range plan.Deletes {
+		if err := c.deleteTask(tasks[id].Id); err != nil {
+			return err
+		}
+	}
+
+	// Pushed lines are replaced by the server's rendering, which adds ids
+	// and creation dates.
+	tasks, err = c.tasks()
+	if err != nil {
+		return err
+	}
+	byId := make(map[uuid.UUID]*model.Task, len(tasks))
+	for _, task := range tasks {
+		byId[task.Id] = &task
+	}
+
+	lines := make([]string, 0, len(plan.Entries))
+	synced := make([]string, 0, len(plan.Entries))
+	pushed, failed := 0, 0
+	for _, entry := range plan.Entries {
+		line := entry.Line
+		if entry.Push {
+			row := report.Rows[pushed]
+			pushed++
+			if len(row.Errors) > 0 || row.TaskId == nil || byId[*row.TaskId] == nil {
+				failed++
+				for _, e := range row.Errors {
+					fmt.Printf("failed: %q: %s %s\n", line, e.Field, e.Message)
+				}
+				lines = append(lines, line)
+				continue
+			}
+			line = todotxt.Format(byId[*row.TaskId])
+		} else if task, ok := tasks[todotxt.Id(line)]; ok {
+			line = todotxt.Format(&task)
+		}
+		lines = append(lines, line)
+		synced = append(synced, line)
+	}
+
+	if err := writeLines(file, lines); err != nil {
+		return err
+	}
+	if err := writeLines(state, synced); err != nil {
+		return err
+	}
+
+	fmt.Printf("pushed %d, failed %d, deleted %d, %d tasks in %s\n",
+		pushed-failed, failed, len(plan.Deletes), len(lines), file)
+	return nil
+}
+
+// tasks fetches every task, keyed by the id used in todo.txt lines.
+func (c *client) tasks() (map[string]model.Task, error) {
+	resp, err := c.http.Get(c.server + "/tasks/export?format=ndjson")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+
+	tasks := make(map[string]model.Task)
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var task model.Task
+		if err := decoder.Decode(&task); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		tasks[task.ExportId()] = task
+	}
+	return tasks, nil
+}
+
+func (c *client) importLines(lines []string) (*importReport, error) {
+	report := &importReport{}
+	if len(lines) == 0 {
+		return report, nil
+	}
+
+	body := strings.Join(lines, "\n") + "\n"
+	resp, err := c.http.Post(c.server+"/tasks/import?format=todotxt", "text/plain; charset=utf-8", strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(report); err != nil {
+		return nil, err
+	}
+	if len(report.Rows) != len(lines) {
+		return nil, fmt.Errorf("import reported %d rows for %d lines", len(report.Rows), len(lines))
+	}
+	return report, nil
+}
+
+func (c *client) deleteTask(id uuid.UUID) error {
+	req, err := http.NewRequest(http.MethodDelete, c.server+"/tasks/"+id.String(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusNotFound {
+		return responseError(resp)
+	}
+	return nil
+}
+
+func responseError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("%s %s: %s: %s", resp.Request.Method, resp.Request.URL, resp.Status, bytes.TrimSpace(body))
+}
+
+func formatTasks(tasks map[string]model.Task) map[string]string {
+	lines := make(map[string]string, len(tasks))
+	for id, task := range tasks {
+		lines[id] = todotxt.Format(&task)
+	}
+	return lines
+}
+
+// readLines reads the non-blank lines of a file; a missing file has none.
+func readLines(name string) ([]string, error) {
+	f, err := os.Open(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	lines := make([]string, 0)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// writeLines replaces a file through a temporary file, so an interrupted
+// sync doesn't leave it half written.
+func writeLines(name string, lines []string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	for _, line := range lines {
+		_, _ = w.WriteString(line + "\n")
+	}
+	if err := w.Flush(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
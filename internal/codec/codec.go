@@ -0,0 +1,401 @@
+// Package codec reads and writes API documents as JSON, YAML, XML and
+// MessagePack.
+//
+// JSON is the canonical form: values are marshaled with encoding/json first,
+// so every format has the same field names and omissions, and the other
+// formats are converted from that document. Decoding goes the other way; the
+// document is converted to JSON shaped after the target type, so that, for
+// example, the untyped text of an XML element can fill a number field, and
+// then unmarshaled with encoding/json.
+package codec
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// maxDepth limits the nesting of decoded documents.
+const maxDepth = 1000
+
+type Codec interface {
+	// ContentType is the media type of the documents the codec writes.
+	ContentType() string
+	Encode(w io.Writer, v any) error
+	Decode(r io.Reader, v any) error
+}
+
+// SyntaxError is a malformed YAML, XML or MessagePack document.
+type SyntaxError struct {
+	Format string
+	Msg    string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("invalid %s: %s", e.Format, e.Msg)
+}
+
+var (
+	JSON        Codec = jsonCodec{}
+	YAML        Codec = &treeCodec{contentType: "application/yaml", encode: encodeYaml, decode: decodeYaml}
+	XML         Codec = &treeCodec{contentType: "application/xml", encode: encodeXml, decode: decodeXml}
+	MessagePack Codec = &treeCodec{contentType: "application/msgpack", encode: encodeMsgpack, decode: decodeMsgpack}
+)
+
+// Codecs lists the supported codecs in order of preference.
+func Codecs() []Codec {
+	return []Codec{JSON, YAML, XML, MessagePack}
+}
+
+var mediaTypes = map[string]Codec{
+	"application/json":        JSON,
+	"text/json":               JSON,
+	"application/yaml":        YAML,
+	"application/x-yaml":      YAML,
+	"text/yaml":               YAML,
+	"text/x-yaml":             YAML,
+	"application/xml":         XML,
+	"text/xml":                XML,
+	"application/msgpack":     MessagePack,
+	"application/x-msgpack":   MessagePack,
+	"application/vnd.msgpack": MessagePack,
+}
+
+var suffixes = map[string]Codec{
+	"+json":    JSON,
+	"+yaml":    YAML,
+	"+xml":     XML,
+	"+msgpack": MessagePack,
+}
+
+// Lookup finds the codec of a media type without parameters, including
+// structured syntax suffixes such as application/merge-patch+json.
+func Lookup(mediaType string) (Codec, bool) {
+	mediaType = strings.ToLower(mediaType)
+	if c, ok := mediaTypes[mediaType]; ok {
+		return c, true
+	}
+	if i := strings.LastIndex(mediaType, "+"); i >= 0 {
+		c, ok := suffixes[mediaType[i:]]
+		return c, ok
+	}
+	return nil, false
+}
+
+// Transcode reads a document in the format of c and returns it as JSON
+// shaped after the type of v, without unmarshaling it. v is only used for
+// its type.
+func Transcode(c Codec, r io.Reader, v any) ([]byte, error) {
+	tc, ok := c.(*treeCodec)
+	if !ok {
+		return io.ReadAll(r)
+	}
+	return tc.transcode(r, reflect.TypeOf(v))
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) ContentType() string { return "application/json" }
+
+func (jsonCodec) Encode(w io.Writer, v any) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+func (jsonCodec) Decode(r io.Reader, v any) error {
+	return json.NewDecoder(r).Decode(v)
+}
+
+// treeCodec converts between JSON and another format through a document
+// tree of object, []any, string, json.Number, bool and nil values.
+type treeCodec struct {
+	contentType string
+	encode      func(value any) ([]byte, error)
+	decode      func(data []byte) (any, error)
+}
+
+// member is a field of an object; objects keep the order of their fields.
+type member struct {
+	key   string
+	value any
+}
+
+type object []member
+
+func (c *treeCodec) ContentType() string { return c.contentType }
+
+func (c *treeCodec) Encode(w io.Writer, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	value, err := readJsonValue(decoder)
+	if err != nil {
+		return err
+	}
+
+	out, err := c.encode(value)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(out)
+	return err
+}
+
+func (c *treeCodec) Decode(r io.Reader, v any) error {
+	data, err := c.transcode(r, reflect.TypeOf(v))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func (c *treeCodec) transcode(r io.Reader, t reflect.Type) ([]byte, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	value, err := c.decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	writeTyped(&b, value, t)
+	return b.Bytes(), nil
+}
+
+func readJsonValue(decoder *json.Decoder) (any, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch token {
+	case json.Delim('{'):
+		obj := object{}
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := readJsonValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			obj = append(obj, member{key: key.(string), value: value})
+		}
+		_, err := decoder.Token()
+		return obj, err
+	case json.Delim('['):
+		list := []any{}
+		for decoder.More() {
+			value, err := readJsonValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		_, err := decoder.Token()
+		return list, err
+	}
+	return token, nil
+}
+
+var (
+	jsonUnmarshalerType = reflect.TypeFor[json.Unmarshaler]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+)
+
+// writeTyped writes value as the JSON that type t expects where the value
+// can be converted, such as the text "5" for an int, and as it is otherwise,
+// leaving the mismatch to encoding/json.
+func writeTyped(b *bytes.Buffer, value any, t reflect.Type) {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if value == nil || t == nil || t.Kind() == reflect.Interface ||
+		reflect.PointerTo(t).Implements(jsonUnmarshalerType) || reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		writeJson(b, value)
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		switch v := value.(type) {
+		case json.Number:
+			writeJson(b, string(v))
+			return
+		case bool:
+			writeJson(b, strconv.FormatBool(v))
+			return
+		}
+	case reflect.Bool:
+		if s, ok := value.(string); ok {
+			if v, err := strconv.ParseBool(strings.TrimSpace(s)); err == nil {
+				value = v
+			}
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		if s, ok := value.(string); ok && isNumber(strings.TrimSpace(s)) {
+			value = json.Number(strings.TrimSpace(s))
+		}
+	case reflect.Slice, reflect.Array:
+		if list, ok := asList(value); ok {
+			b.WriteByte('[')
+			for i, item := range list {
+				if i > 0 {
+					b.WriteByte(',')
+				}
+				writeTyped(b, item, t.Elem())
+			}
+			b.WriteByte(']')
+			return
+		}
+	case reflect.Map:
+		if obj, ok := asObject(value); ok {
+			b.WriteByte('{')
+			for i, m := range obj {
+				if i > 0 {
+					b.WriteByte(',')
+				}
+				writeJson(b, m.key)
+				b.WriteByte(':')
+				writeTyped(b, m.value, t.Elem())
+			}
+			b.WriteByte('}')
+			return
+		}
+	case reflect.Struct:
+		if obj, ok := asObject(value); ok {
+			fields := make(map[string]reflect.Type)
+			structFields(t, fields)
+			b.WriteByte('{')
+			for i, m := range obj {
+				if i > 0 {
+					b.WriteByte(',')
+				}
+				writeJson(b, m.key)
+				b.WriteByte(':')
+				writeTyped(b, m.value, fields[strings.ToLower(m.key)])
+			}
+			b.WriteByte('}')
+			return
+		}
+	}
+	writeJson(b, value)
+}
+
+// asList reads a value as a list. Formats without arrays, such as XML, wrap
+// the items of a list in an element of their own, which reads as an object
+// with a single field holding one item or a list of them; an empty element
+// is an empty list.
+func asList(value any) ([]any, bool) {
+	switch v := value.(type) {
+	case []any:
+		return v, true
+	case string:
+		if strings.TrimSpace(v) == "" {
+			return []any{}, true
+		}
+	case object:
+		if len(v) == 0 {
+			return []any{}, true
+		}
+		if len(v) == 1 {
+			if list, ok := v[0].value.([]any); ok {
+				return list, true
+			}
+			return []any{v[0].value}, true
+		}
+	}
+	return nil, false
+}
+
+func asObject(value any) (object, bool) {
+	switch v := value.(type) {
+	case object:
+		return v, true
+	case string:
+		if strings.TrimSpace(v) == "" {
+			return object{}, true
+		}
+	}
+	return nil, false
+}
+
+// structFields collects the types of the JSON fields of a struct, keyed by
+// their lowercased names as encoding/json matches them case-insensitively.
+func structFields(t reflect.Type, fields map[string]reflect.Type) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		tag, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if tag == "-" {
+			continue
+		}
+
+		if field.Anonymous && tag == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				structFields(embedded, fields)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		name := tag
+		if name == "" {
+			name = field.Name
+		}
+		if _, ok := fields[strings.ToLower(name)]; !ok {
+			fields[strings.ToLower(name)] = field.Type
+		}
+	}
+}
+
+func isNumber(s string) bool {
+	return s != "" && (s[0] == '-' || (s[0] >= '0' && s[0] <= '9')) && json.Valid([]byte(s))
+}
+
+func writeJson(b *bytes.Buffer, value any) {
+	switch v := value.(type) {
+	case object:
+		b.WriteByte('{')
+		for i, m := range v {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			writeJson(b, m.key)
+			b.WriteByte(':')
+			writeJson(b, m.value)
+		}
+		b.WriteByte('}')
+	case []any:
+		b.WriteByte('[')
+		for i, item := range v {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			writeJson(b, item)
+		}
+		b.WriteByte(']')
+	case json.Number:
+		b.WriteString(string(v))
+	default:
+		data, _ := json.Marshal(v)
+		b.Write(data)
+	}
+}
@@ -0,0 +1,334 @@
+package codec
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// encodeMsgpack writes a document in the MessagePack format
+// (https://github.com/msgpack/msgpack/blob/master/spec.md). Integers use the
+// smallest encoding that holds them and other numbers are 64-bit floats.
+func encodeMsgpack(value any) ([]byte, error) {
+	return appendMsgpack(nil, value)
+}
+
+func appendMsgpack(b []byte, value any) ([]byte, error) {
+	var err error
+	switch v := value.(type) {
+	case nil:
+		return append(b, 0xc0), nil
+	case bool:
+		if v {
+			return append(b, 0xc3), nil
+		}
+		return append(b, 0xc2), nil
+	case json.Number:
+		return appendMsgpackNumber(b, v)
+	case string:
+		n := len(v)
+		switch {
+		case n < 32:
+			b = append(b, 0xa0|byte(n))
+		case n <= math.MaxUint8:
+			b = append(b, 0xd9, byte(n))
+		case n <= math.MaxUint16:
+			b = binary.BigEndian.AppendUint16(append(b, 0xda), uint16(n))
+		default:
+			b = binary.BigEndian.AppendUint32(append(b, 0xdb), uint32(n))
+		}
+		return append(b, v...), nil
+	case []any:
+		b = appendMsgpackHeader(b, len(v), 0x90, 0xdc)
+		for _, item := range v {
+			if b, err = appendMsgpack(b, item); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	case object:
+		b = appendMsgpackHeader(b, len(v), 0x80, 0xde)
+		for _, m := range v {
+			if b, err = appendMsgpack(b, m.key); err != nil {
+				return nil, err
+			}
+			if b, err = appendMsgpack(b, m.value); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	}
+	return nil, fmt.Errorf("msgpack: unsupported value %T", value)
+}
+
+// appendMsgpackHeader writes the length of an array or map: fix is the
+// prefix of the short form and wide the one of the 16-bit form, which is
+// followed by the 32-bit form.
+func appendMsgpackHeader(b []byte, n int, fix byte, wide byte) []byte {
+	switch {
+	case n < 16:
+		return append(b, fix|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, wide), uint16(n))
+	}
+	return binary.BigEndian.AppendUint32(append(b, wide+1), uint32(n))
+}
+
+func appendMsgpackNumber(b []byte, n json.Number) ([]byte, error) {
+	if i, err := strconv.ParseInt(string(n), 10, 64); err == nil {
+		switch {
+		case i >= 0 && i < 128, i >= -32 && i < 0:
+			return append(b, byte(i)), nil
+		case i >= math.MinInt8 && i <= math.MaxInt8:
+			return append(b, 0xd0, byte(i)), nil
+		case i >= math.MinInt16 && i <= math.MaxInt16:
+			return binary.BigEndian.AppendUint16(append(b, 0xd1), uint16(i)), nil
+		case i >= math.MinInt32 && i <= math.MaxInt32:
+			return binary.BigEndian.AppendUint32(append(b, 0xd2), uint32(i)), nil
+		}
+		return binary.BigEndian.AppendUint64(append(b, 0xd3), uint64(i)), nil
+	}
+	if u, err := strconv.ParseUint(string(n), 10, 64); err == nil {
+		return binary.BigEndian.AppendUint64(append(b, 0xcf), u), nil
+	}
+	f, err := strconv.ParseFloat(string(n), 64)
+	if err != nil {
+		return nil, fmt.Errorf("msgpack: %w", err)
+	}
+	return binary.BigEndian.AppendUint64(append(b, 0xcb), math.Float64bits(f)), nil
+}
+
+func decodeMsgpack(data []byte) (any, error) {
+	r := &msgpackReader{data: data}
+	value, err := r.value(1)
+	if err != nil {
+		return nil, err
+	}
+	if r.pos != len(r.data) {
+		return nil, r.errorf("unexpected data after the document")
+	}
+	return value, nil
+}
+
+type msgpackReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *msgpackReader) errorf(format string, args ...any) error {
+	return &SyntaxError{Format: "msgpack", Msg: fmt.Sprintf("offset %d: ", r.pos) + fmt.Sprintf(format, args...)}
+}
+
+func (r *msgpackReader) read(n int) ([]byte, error) {
+	if n < 0 || n > len(r.data)-r.pos {
+		return nil, r.errorf("unexpected end of data")
+	}
+	data := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return data, nil
+}
+
+// uint reads a big-endian unsigned integer of size bytes.
+func (r *msgpackReader) uint(size int) (uint64, error) {
+	data, err := r.read(size)
+	if err != nil {
+		return 0, err
+	}
+	var v uint64
+	for _, c := range data {
+		v = v<<8 | uint64(c)
+	}
+	return v, nil
+}
+
+func (r *msgpackReader) value(depth int) (any, error) {
+	if depth > maxDepth {
+		return nil, r.errorf("document is nested too deeply")
+	}
+	prefix, err := r.read(1)
+	if err != nil {
+		return nil, err
+	}
+
+	c := prefix[0]
+	switch {
+	case c <= 0x7f:
+		return json.Number(strconv.Itoa(int(c))), nil
+	case c >= 0xe0:
+		return json.Number(strconv.Itoa(int(int8(c)))), nil
+	case c&0xf0 == 0x80:
+		return r.object(int(c&0x0f), depth)
+	case c&0xf0 == 0x90:
+		return r.list(int(c&0x0f), depth)
+	case c&0xe0 == 0xa0:
+		return r.string(int(c & 0x1f))
+	}
+
+	switch c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xd9:
+		return r.sizedString(1)
+	case 0xc5, 0xda:
+		return r.sizedString(2)
+	case 0xc6, 0xdb:
+		return r.sizedString(4)
+	case 0xca:
+		v, err := r.uint(4)
+		if err != nil {
+			return nil, err
+		}
+		return r.float(float64(math.Float32frombits(uint32(v))))
+	case 0xcb:
+		v, err := r.uint(8)
+		if err != nil {
+			return nil, err
+		}
+		return r.float(math.Float64frombits(v))
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		v, err := r.uint(1 << (c - 0xcc))
+		if err != nil {
+			return nil, err
+		}
+		return json.Number(strconv.FormatUint(v, 10)), nil
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (c - 0xd0)
+		v, err := r.uint(size)
+		if err != nil {
+			return nil, err
+		}
+		// Sign-extend from the size of the encoding.
+		shift := 64 - 8*size
+		return json.Number(strconv.FormatInt(int64(v<<shift)>>shift, 10)), nil
+	case 0xdc, 0xde:
+		n, err := r.uint(2)
+		if err != nil {
+			return nil, err
+		}
+		if c == 0xdc {
+			return r.list(int(n), depth)
+		}
+		return r.object(int(n), depth)
+	case 0xdd, 0xdf:
+		n, err := r.uint(4)
+		if err != nil {
+			return nil, err
+		}
+		if c == 0xdd {
+			return r.list(int(n), depth)
+		}
+		return r.object(int(n), depth)
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return r.ext(1 << (c - 0xd4))
+	case 0xc7, 0xc8, 0xc9:
+		n, err := r.uint(1 << (c - 0xc7))
+		if err != nil {
+			return nil, err
+		}
+		return r.ext(int(n))
+	}
+	return nil, r.errorf("unknown type 0x%02x", c)
+}
+
+func (r *msgpackReader) string(n int) (any, error) {
+	data, err := r.read(n)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (r *msgpackReader) sizedString(size int) (any, error) {
+	n, err := r.uint(size)
+	if err != nil {
+		return nil, err
+	}
+	return r.string(int(n))
+}
+
+func (r *msgpackReader) float(f float64) (any, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, r.errorf("%v is not a supported number", f)
+	}
+	return json.Number(strconv.FormatFloat(f, 'g', -1, 64)), nil
+}
+
+// list reads n items. Every item takes at least a byte, which bounds the
+// allocation by the size of the data.
+func (r *msgpackReader) list(n int, depth int) (any, error) {
+	list := make([]any, 0, min(n, len(r.data)-r.pos))
+	for range n {
+		value, err := r.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, value)
+	}
+	return list, nil
+}
+
+func (r *msgpackReader) object(n int, depth int) (any, error) {
+	obj := make(object, 0, min(n, (len(r.data)-r.pos)/2))
+	for range n {
+		key, err := r.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		value, err := r.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+
+		switch k := key.(type) {
+		case string:
+			obj = append(obj, member{key: k, value: value})
+		case json.Number:
+			obj = append(obj, member{key: string(k), value: value})
+		case bool:
+			obj = append(obj, member{key: strconv.FormatBool(k), value: value})
+		default:
+			return nil, r.errorf("map keys must be strings or numbers")
+		}
+	}
+	return obj, nil
+}
+
+// ext reads an extension value of n bytes. Only timestamps (type -1) are
+// supported; they are read as RFC 3339 strings.
+func (r *msgpackReader) ext(n int) (any, error) {
+	typ, err := r.read(1)
+	if err != nil {
+		return nil, err
+	}
+	data, err := r.read(n)
+	if err != nil {
+		return nil, err
+	}
+	if int8(typ[0]) != -1 {
+		return nil, r.errorf("unsupported extension type %d", int8(typ[0]))
+	}
+
+	var sec int64
+	var nsec uint32
+	switch n {
+	case 4:
+		sec = int64(binary.BigEndian.Uint32(data))
+	case 8:
+		v := binary.BigEndian.Uint64(data)
+		nsec = uint32(v >> 34)
+		sec = int64(v & (1<<34 - 1))
+	case 12:
+		nsec = binary.BigEndian.Uint32(data)
+		sec = int64(binary.BigEndian.Uint64(data[4:]))
+	default:
+		return nil, r.errorf("invalid timestamp of %d bytes", n)
+	}
+	return time.Unix(sec, int64(nsec)).UTC().Format(time.RFC3339Nano), nil
+}
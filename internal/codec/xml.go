@@ -0,0 +1,208 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// XML documents have a <response> root element. Object fields become
+// elements named after them, or <entry key="..."> when the name isn't a
+// valid element name, list items become <item> elements and null values
+// carry nil="true", as in
+//
+//	<response><id>7</id><tags><item>work</item></tags><dueDate nil="true"/></response>
+//
+// Requests may use any root element name.
+const xmlRoot = "response"
+
+func encodeXml(value any) ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	if err := writeXmlElement(&b, xmlRoot, value); err != nil {
+		return nil, err
+	}
+	b.WriteByte('\n')
+	return b.Bytes(), nil
+}
+
+func writeXmlElement(b *bytes.Buffer, name string, value any) error {
+	end := name
+	if isXmlName(name) {
+		b.WriteString("<" + name)
+	} else {
+		end = "entry"
+		b.WriteString(`<entry key="`)
+		if err := xml.EscapeText(b, []byte(name)); err != nil {
+			return err
+		}
+		b.WriteByte('"')
+	}
+
+	switch v := value.(type) {
+	case nil:
+		b.WriteString(` nil="true"/>`)
+		return nil
+	case object:
+		b.WriteByte('>')
+		for _, m := range v {
+			if err := writeXmlElement(b, m.key, m.value); err != nil {
+				return err
+			}
+		}
+	case []any:
+		b.WriteByte('>')
+		for _, item := range v {
+			if err := writeXmlElement(b, "item", item); err != nil {
+				return err
+			}
+		}
+	case string:
+		b.WriteByte('>')
+		if err := xml.EscapeText(b, []byte(v)); err != nil {
+			return err
+		}
+	case json.Number:
+		b.WriteString(">" + string(v))
+	case bool:
+		b.WriteString(">" + strconv.FormatBool(v))
+	}
+	b.WriteString("</" + end + ">")
+	return nil
+}
+
+// isXmlName reports whether a field name can be used as an element name
+// as it is: a name without a namespace prefix that isn't reserved.
+func isXmlName(name string) bool {
+	if name == "" || strings.HasPrefix(strings.ToLower(name), "xml") {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case unicode.IsLetter(r), r == '_':
+		case i > 0 && (unicode.IsDigit(r) || r == '-' || r == '.'):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func decodeXml(data []byte) (any, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+
+	var value any
+	root := false
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, xmlError(err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if root {
+				return nil, &SyntaxError{Format: "xml", Msg: "more than one root element"}
+			}
+			root = true
+			if value, err = readXmlElement(decoder, t, 1); err != nil {
+				return nil, err
+			}
+		case xml.CharData:
+			if len(bytes.TrimSpace(t)) > 0 {
+				return nil, &SyntaxError{Format: "xml", Msg: "text outside of the root element"}
+			}
+		}
+	}
+
+	if !root {
+		return nil, &SyntaxError{Format: "xml", Msg: "no root element"}
+	}
+	return value, nil
+}
+
+// readXmlElement reads the content of an element: an object when it has
+// child elements, with the values of repeated children collected in a list,
+// and its text otherwise.
+func readXmlElement(decoder *xml.Decoder, start xml.StartElement, depth int) (any, error) {
+	if depth > maxDepth {
+		return nil, &SyntaxError{Format: "xml", Msg: "document is nested too deeply"}
+	}
+
+	null := false
+	for _, attr := range start.Attr {
+		if attr.Name.Local == "nil" && attr.Value == "true" {
+			null = true
+		}
+	}
+
+	var obj object
+	var text strings.Builder
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, xmlError(err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			key := t.Name.Local
+			for _, attr := range t.Attr {
+				if key == "entry" && attr.Name.Local == "key" {
+					key = attr.Value
+				}
+			}
+			value, err := readXmlElement(decoder, t, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			obj = appendXmlField(obj, key, value)
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			switch {
+			case null:
+				return nil, nil
+			case obj != nil:
+				return obj, nil
+			}
+			return text.String(), nil
+		}
+	}
+}
+
+// appendXmlField adds a child element to an object. Element values are
+// never lists themselves, so a list value marks a repeated element.
+func appendXmlField(obj object, key string, value any) object {
+	for i := range obj {
+		if obj[i].key != key {
+			continue
+		}
+		if list, ok := obj[i].value.([]any); ok {
+			obj[i].value = append(list, value)
+		} else {
+			obj[i].value = []any{obj[i].value, value}
+		}
+		return obj
+	}
+	return append(obj, member{key: key, value: value})
+}
+
+func xmlError(err error) error {
+	if errors.Is(err, io.EOF) {
+		err = io.ErrUnexpectedEOF
+	}
+	var syntaxErr *xml.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return &SyntaxError{Format: "xml", Msg: "line " + strconv.Itoa(syntaxErr.Line) + ": " + syntaxErr.Msg}
+	}
+	return &SyntaxError{Format: "xml", Msg: err.Error()}
+}
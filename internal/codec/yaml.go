@@ -0,0 +1,134 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"gopkg.in/yaml.v3"
+	"math"
+	"strconv"
+	"strings"
+)
+
+func encodeYaml(value any) ([]byte, error) {
+	var b bytes.Buffer
+	encoder := yaml.NewEncoder(&b)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(yamlNode(value)); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func yamlNode(value any) *yaml.Node {
+	switch v := value.(type) {
+	case object:
+		node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		if len(v) == 0 {
+			node.Style = yaml.FlowStyle
+		}
+		for _, m := range v {
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: m.key}, yamlNode(m.value))
+		}
+		return node
+	case []any:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		if len(v) == 0 {
+			node.Style = yaml.FlowStyle
+		}
+		for _, item := range v {
+			node.Content = append(node.Content, yamlNode(item))
+		}
+		return node
+	case string:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v}
+	case json.Number:
+		if strings.ContainsAny(string(v), ".eE") {
+			return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!float", Value: string(v)}
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: string(v)}
+	case bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(v)}
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}
+}
+
+func decodeYaml(data []byte) (any, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, &SyntaxError{Format: "yaml", Msg: strings.TrimPrefix(err.Error(), "yaml: ")}
+	}
+	if len(doc.Content) == 0 {
+		return nil, &SyntaxError{Format: "yaml", Msg: "empty document"}
+	}
+	return yamlValue(doc.Content[0])
+}
+
+// yamlValue converts a node to the document tree. Aliases are rejected
+// rather than expanded, which also rules out documents that grow
+// exponentially when they are.
+func yamlValue(node *yaml.Node) (any, error) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		obj := make(object, 0, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			if key.Kind != yaml.ScalarNode {
+				return nil, &SyntaxError{Format: "yaml", Msg: "line " + strconv.Itoa(key.Line) + ": mapping keys must be scalars"}
+			}
+			value, err := yamlValue(node.Content[i+1])
+			if err != nil {
+				return nil, err
+			}
+			obj = append(obj, member{key: key.Value, value: value})
+		}
+		return obj, nil
+	case yaml.SequenceNode:
+		list := make([]any, 0, len(node.Content))
+		for _, item := range node.Content {
+			value, err := yamlValue(item)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		return list, nil
+	case yaml.ScalarNode:
+		return yamlScalar(node)
+	}
+	return nil, &SyntaxError{Format: "yaml", Msg: "line " + strconv.Itoa(node.Line) + ": aliases are not supported"}
+}
+
+func yamlScalar(node *yaml.Node) (any, error) {
+	switch node.ShortTag() {
+	case "!!null":
+		return nil, nil
+	case "!!bool":
+		var v bool
+		if err := node.Decode(&v); err != nil {
+			return nil, &SyntaxError{Format: "yaml", Msg: err.Error()}
+		}
+		return v, nil
+	case "!!int", "!!float":
+		var v any
+		if err := node.Decode(&v); err != nil {
+			return nil, &SyntaxError{Format: "yaml", Msg: err.Error()}
+		}
+		switch n := v.(type) {
+		case int:
+			return json.Number(strconv.Itoa(n)), nil
+		case int64:
+			return json.Number(strconv.FormatInt(n, 10)), nil
+		case uint64:
+			return json.Number(strconv.FormatUint(n, 10)), nil
+		case float64:
+			if math.IsNaN(n) || math.IsInf(n, 0) {
+				return nil, &SyntaxError{Format: "yaml", Msg: "line " + strconv.Itoa(node.Line) + ": " + node.Value + " is not a supported number"}
+			}
+			return json.Number(strconv.FormatFloat(n, 'g', -1, 64)), nil
+		}
+	}
+	return node.Value, nil
+}
@@ -5,10 +5,23 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"time"
+)
+
+const (
+	StorageMemory   = "memory"
+	StorageMarkdown = "markdown"
 )
 
 type Config struct {
 	Port int
+	// Storage selects the task repository, StorageMemory or StorageMarkdown.
+	Storage              string
+	MarkdownDir          string
+	MarkdownPollInterval time.Duration
+	// LenientRequests is the compatibility mode for clients that send unknown
+	// body fields or query parameters, which are rejected otherwise.
+	LenientRequests bool
 }
 
 func GetConfig() Config {
@@ -18,11 +31,44 @@ func GetConfig() Config {
 		port = 8080
 	}
 
+	storage := os.Getenv("STORAGE")
+	if storage != StorageMarkdown {
+		if storage != "" && storage != StorageMemory {
+			log.Printf("invalid storage: %q, using %s", storage, StorageMemory)
+		}
+		storage = StorageMemory
+	}
+
+	markdownDir := os.Getenv("MARKDOWN_DIR")
+	if markdownDir == "" {
+		markdownDir = "tasks"
+	}
+
+	pollInterval, err := time.ParseDuration(os.Getenv("MARKDOWN_POLL_INTERVAL"))
+	if err != nil || pollInterval <= 0 {
+		pollInterval = 2 * time.Second
+	}
+
+	lenientRequests := false
+	if value := os.Getenv("LENIENT_REQUESTS"); value != "" {
+		if lenientRequests, err = strconv.ParseBool(value); err != nil {
+			log.Printf("invalid lenient requests: %v, using strict requests", err)
+		}
+	}
+
 	return Config{
-		Port: port,
+		Port:                 port,
+		Storage:              storage,
+		MarkdownDir:          markdownDir,
+		MarkdownPollInterval: pollInterval,
+		LenientRequests:      lenientRequests,
 	}
 }
 
 func (c *Config) String() string {
-	return fmt.Sprintf("port: %d", c.Port)
+	if c.Storage == StorageMarkdown {
+		return fmt.Sprintf("port: %d, storage: %s, dir: %s, poll interval: %s, lenient requests: %t",
+			c.Port, c.Storage, c.MarkdownDir, c.MarkdownPollInterval, c.LenientRequests)
+	}
+	return fmt.Sprintf("port: %d, storage: %s, lenient requests: %t", c.Port, c.Storage, c.LenientRequests)
 }
@@ -0,0 +1,231 @@
+// Package filter implements the expression language of the filter query
+// parameter, for example
+//
+//	priority in (high, normal) and due < 2025-10-01 and not tag:archive
+//
+// Parse produces a typed AST that repositories evaluate or translate into
+// their own query language.
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type FieldType int
+
+const (
+	TypeText FieldType = iota
+	TypeEnum
+	TypeTime
+	TypeSet
+	TypeNumber
+)
+
+const (
+	FieldTitle     = "title"
+	FieldContent   = "content"
+	FieldStatus    = "status"
+	FieldPriority  = "priority"
+	FieldTags      = "tags"
+	FieldDueDate   = "dueDate"
+	FieldCreatedAt = "createdAt"
+	FieldUpdatedAt = "updatedAt"
+)
+
+var fieldTypes = map[string]FieldType{
+	FieldTitle:     TypeText,
+	FieldContent:   TypeText,
+	FieldStatus:    TypeEnum,
+	FieldPriority:  TypeEnum,
+	FieldTags:      TypeSet,
+	FieldDueDate:   TypeTime,
+	FieldCreatedAt: TypeTime,
+	FieldUpdatedAt: TypeTime,
+}
+
+var fieldAliases = map[string]string{
+	"tag":     FieldTags,
+	"due":     FieldDueDate,
+	"created": FieldCreatedAt,
+	"updated": FieldUpdatedAt,
+}
+
+var enumValues = map[string][]string{
+	FieldStatus:   {"todo", "in_progress", "done"},
+	FieldPriority: {"low", "normal", "high"},
+}
+
+type Operator string
+
+const (
+	OpEq       Operator = "="
+	OpNe       Operator = "!="
+	OpLt       Operator = "<"
+	OpLe       Operator = "<="
+	OpGt       Operator = ">"
+	OpGe       Operator = ">="
+	OpContains Operator = "~"
+	OpIn       Operator = "in"
+	OpNotIn    Operator = "not in"
+)
+
+// Field functions transform a field before it is compared.
+const (
+	FuncLower = "lower"
+	FuncLen   = "len"
+)
+
+// Value functions produce dates relative to the evaluation time.
+const (
+	FuncNow          = "now"
+	FuncToday        = "today"
+	FuncDaysAgo      = "daysAgo"
+	FuncDaysAhead    = "daysAhead"
+	FuncStartOfWeek  = "startOfWeek"
+	FuncStartOfMonth = "startOfMonth"
+)
+
+type Expr interface {
+	Pos() int
+	String() string
+}
+
+type And struct {
+	Left, Right Expr
+}
+
+type Or struct {
+	Left, Right Expr
+}
+
+type Not struct {
+	Position int
+	X        Expr
+}
+
+// Has is true when the field is set: a due date exists, or the text or tag
+// set is not empty.
+type Has struct {
+	Position int
+	Field    string
+}
+
+type Comparison struct {
+	Position int
+	Field    string
+	// Func is an optional field function, FuncLower or FuncLen.
+	Func   string
+	Op     Operator
+	Values []Value
+}
+
+// Type returns the type of the compared expression, taking the field
+// function into account.
+func (c *Comparison) Type() FieldType {
+	if c.Func == FuncLen {
+		return TypeNumber
+	}
+	return fieldTypes[c.Field]
+}
+
+type ValueKind int
+
+const (
+	KindString ValueKind = iota
+	KindNumber
+	// KindDate is a calendar day; comparisons against it use whole days.
+	KindDate
+	KindTime
+	KindCall
+)
+
+type Value struct {
+	Position int
+	Kind     ValueKind
+	Str      string
+	Num      int
+	Time     time.Time
+	// Func and Arg describe a KindCall value.
+	Func string
+	Arg  int
+}
+
+// Resolve evaluates a date value at the given time. It returns the instant
+// and whether the value denotes a whole day.
+func (v Value) Resolve(now time.Time) (time.Time, bool) {
+	now = now.UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	switch v.Kind {
+	case KindDate:
+		return v.Time, true
+	case KindTime:
+		return v.Time, false
+	case KindCall:
+		switch v.Func {
+		case FuncNow:
+			return now, false
+		case FuncToday:
+			return today, true
+		case FuncDaysAgo:
+			return today.AddDate(0, 0, -v.Arg), true
+		case FuncDaysAhead:
+			return today.AddDate(0, 0, v.Arg), true
+		case FuncStartOfWeek:
+			offset := (int(today.Weekday()) + 6) % 7
+			return today.AddDate(0, 0, -offset), true
+		case FuncStartOfMonth:
+			return time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC), true
+		}
+	}
+	return time.Time{}, false
+}
+
+func (e *And) Pos() int        { return e.Left.Pos() }
+func (e *Or) Pos() int         { return e.Left.Pos() }
+func (e *Not) Pos() int        { return e.Position }
+func (e *Has) Pos() int        { return e.Position }
+func (e *Comparison) Pos() int { return e.Position }
+
+func (e *And) String() string { return "(" + e.Left.String() + " and " + e.Right.String() + ")" }
+func (e *Or) String() string  { return "(" + e.Left.String() + " or " + e.Right.String() + ")" }
+func (e *Not) String() string { return "not " + e.X.String() }
+func (e *Has) String() string { return "has(" + e.Field + ")" }
+
+func (e *Comparison) String() string {
+	field := e.Field
+	if e.Func != "" {
+		field = e.Func + "(" + field + ")"
+	}
+
+	if e.Op == OpIn || e.Op == OpNotIn {
+		values := make([]string, 0, len(e.Values))
+		for _, value := range e.Values {
+			values = append(values, value.String())
+		}
+		return fmt.Sprintf("%s %s (%s)", field, e.Op, strings.Join(values, ", "))
+	}
+
+	return fmt.Sprintf("%s %s %s", field, e.Op, e.Values[0].String())
+}
+
+func (v Value) String() string {
+	switch v.Kind {
+	case KindNumber:
+		return strconv.Itoa(v.Num)
+	case KindDate:
+		return v.Time.Format(time.DateOnly)
+	case KindTime:
+		return v.Time.Format(time.RFC3339)
+	case KindCall:
+		switch v.Func {
+		case FuncDaysAgo, FuncDaysAhead:
+			return fmt.Sprintf("%s(%d)", v.Func, v.Arg)
+		}
+		return v.Func + "()"
+	}
+	return strconv.Quote(v.Str)
+}
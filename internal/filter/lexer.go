@@ -0,0 +1,167 @@
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenLiteral
+	tokenOperator
+	tokenColon
+	tokenComma
+	tokenLParen
+	tokenRParen
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	// pos is the 1-based character (not byte) position in the expression.
+	pos int
+}
+
+// SyntaxError is a malformed filter. Msg is the English message; Template
+// is the same message with {0}-style placeholders for Args, which are quoted
+// parts of the filter or phrases such as "end of filter", so that clients
+// can get it translated.
+type SyntaxError struct {
+	Pos      int
+	Msg      string
+	Template string
+	Args     []string
+}
+
+func newSyntaxError(pos int, template string, args ...string) *SyntaxError {
+	placeholders := make([]string, 0, 2*len(args))
+	for i, arg := range args {
+		placeholders = append(placeholders, "{"+strconv.Itoa(i)+"}", arg)
+	}
+	msg := strings.NewReplacer(placeholders...).Replace(template)
+	return &SyntaxError{Pos: pos, Msg: msg, Template: template, Args: args}
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("filter syntax error at position %d: %s", e.Pos, e.Msg)
+}
+
+type lexer struct {
+	input  string
+	offset int
+	pos    int
+}
+
+func (l *lexer) syntaxError(pos int, template string, args ...string) *SyntaxError {
+	return newSyntaxError(pos, template, args...)
+}
+
+func (l *lexer) peekRune() rune {
+	r, _ := utf8.DecodeRuneInString(l.input[l.offset:])
+	return r
+}
+
+func (l *lexer) nextRune() rune {
+	r, size := utf8.DecodeRuneInString(l.input[l.offset:])
+	l.offset += size
+	l.pos++
+	return r
+}
+
+func (l *lexer) tokens() ([]token, error) {
+	tokens := make([]token, 0)
+	for {
+		for l.offset < len(l.input) && unicode.IsSpace(l.peekRune()) {
+			l.nextRune()
+		}
+		if l.offset >= len(l.input) {
+			tokens = append(tokens, token{kind: tokenEOF, pos: l.pos + 1})
+			return tokens, nil
+		}
+
+		start := l.pos + 1
+		r := l.peekRune()
+		switch {
+		case r == '(':
+			l.nextRune()
+			tokens = append(tokens, token{kind: tokenLParen, text: "(", pos: start})
+		case r == ')':
+			l.nextRune()
+			tokens = append(tokens, token{kind: tokenRParen, text: ")", pos: start})
+		case r == ',':
+			l.nextRune()
+			tokens = append(tokens, token{kind: tokenComma, text: ",", pos: start})
+		case r == ':':
+			l.nextRune()
+			tokens = append(tokens, token{kind: tokenColon, text: ":", pos: start})
+		case r == '"' || r == '\'':
+			text, err := l.quoted()
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenString, text: text, pos: start})
+		case strings.ContainsRune("=!<>~", r):
+			op := string(l.nextRune())
+			if l.offset < len(l.input) && l.peekRune() == '=' && op != "=" && op != "~" {
+				op += string(l.nextRune())
+			}
+			if op == "!" {
+				return nil, l.syntaxError(start, `unexpected {0}, did you mean "!="`, strconv.Quote(op))
+			}
+			tokens = append(tokens, token{kind: tokenOperator, text: op, pos: start})
+		case unicode.IsDigit(r):
+			// Dates and times may contain ':' and '+', which end other words.
+			tokens = append(tokens, token{kind: tokenLiteral, text: l.word(true), pos: start})
+		case isWordRune(r):
+			tokens = append(tokens, token{kind: tokenIdent, text: l.word(false), pos: start})
+		default:
+			return nil, l.syntaxError(start, "unexpected character {0}", strconv.QuoteRune(r))
+		}
+	}
+}
+
+func (l *lexer) quoted() (string, error) {
+	start := l.pos + 1
+	quote := l.nextRune()
+
+	var sb strings.Builder
+	for l.offset < len(l.input) {
+		r := l.nextRune()
+		switch r {
+		case quote:
+			return sb.String(), nil
+		case '\\':
+			if l.offset >= len(l.input) {
+				return "", l.syntaxError(start, "unterminated string")
+			}
+			sb.WriteRune(l.nextRune())
+		default:
+			sb.WriteRune(r)
+		}
+	}
+
+	return "", l.syntaxError(start, "unterminated string")
+}
+
+func (l *lexer) word(literal bool) string {
+	var sb strings.Builder
+	for l.offset < len(l.input) {
+		r := l.peekRune()
+		if !isWordRune(r) && !(literal && (r == ':' || r == '+')) {
+			break
+		}
+		sb.WriteRune(l.nextRune())
+	}
+	return sb.String()
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-' || r == '.'
+}
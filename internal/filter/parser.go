@@ -0,0 +1,362 @@
+package filter
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const maxDepth = 32
+
+type parser struct {
+	tokens []token
+	i      int
+	depth  int
+}
+
+// Parse parses a filter expression. Errors are *SyntaxError values pointing
+// at the offending position.
+func Parse(input string) (Expr, error) {
+	l := &lexer{input: input}
+	tokens, err := l.tokens()
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	if p.peek().kind == tokenEOF {
+		return nil, p.syntaxError(p.peek(), "empty filter")
+	}
+
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, p.syntaxError(t, "unexpected {0}", strconv.Quote(t.text))
+	}
+
+	return expr, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.i]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.i]
+	if t.kind != tokenEOF {
+		p.i++
+	}
+	return t
+}
+
+func (p *parser) syntaxError(t token, template string, args ...string) *SyntaxError {
+	return newSyntaxError(t.pos, template, args...)
+}
+
+func (p *parser) isKeyword(t token, keyword string) bool {
+	return t.kind == tokenIdent && strings.EqualFold(t.text, keyword)
+}
+
+func (p *parser) expect(kind tokenKind, what string) (token, error) {
+	t := p.next()
+	if t.kind != kind {
+		return t, p.syntaxError(t, "expected {0}, got {1}", what, describe(t))
+	}
+	return t, nil
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.isKeyword(p.peek(), "or") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &Or{Left: left, Right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.isKeyword(p.peek(), "and") {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &And{Left: left, Right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseUnary() (Expr, error) {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > maxDepth {
+		return nil, p.syntaxError(p.peek(), "expression is nested too deeply")
+	}
+
+	t := p.peek()
+	if p.isKeyword(t, "not") {
+		p.next()
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &Not{Position: t.pos, X: x}, nil
+	}
+
+	if t.kind == tokenLParen {
+		p.next()
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokenRParen, `")"`); err != nil {
+			return nil, err
+		}
+		return expr, nil
+	}
+
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (Expr, error) {
+	t, err := p.expect(tokenIdent, "field name")
+	if err != nil {
+		return nil, err
+	}
+
+	comparison := &Comparison{Position: t.pos}
+	name := t.text
+
+	if p.peek().kind == tokenLParen {
+		p.next()
+		if strings.EqualFold(name, "has") {
+			field, err := p.parseField()
+			if err != nil {
+				return nil, err
+			}
+			if _, err := p.expect(tokenRParen, `")"`); err != nil {
+				return nil, err
+			}
+			return &Has{Position: t.pos, Field: field}, nil
+		}
+
+		if name != FuncLower && name != FuncLen {
+			return nil, p.syntaxError(t, "unknown function {0}", strconv.Quote(name))
+		}
+		comparison.Func = name
+
+		field, err := p.parseField()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokenRParen, `")"`); err != nil {
+			return nil, err
+		}
+		comparison.Field = field
+
+		fieldType := fieldTypes[field]
+		if name == FuncLower && fieldType != TypeText {
+			return nil, p.syntaxError(t, "lower() needs a text field, got {0}", field)
+		}
+		if name == FuncLen && fieldType != TypeText && fieldType != TypeSet {
+			return nil, p.syntaxError(t, "len() needs a text or tag field, got {0}", field)
+		}
+	} else {
+		field, ok := resolveField(name)
+		if !ok {
+			return nil, p.syntaxError(t, "unknown field {0}", strconv.Quote(name))
+		}
+		comparison.Field = field
+	}
+
+	if err := p.parseOperator(comparison); err != nil {
+		return nil, err
+	}
+
+	if comparison.Op == OpIn || comparison.Op == OpNotIn {
+		if _, err := p.expect(tokenLParen, `"("`); err != nil {
+			return nil, err
+		}
+		for {
+			value, err := p.parseValue(comparison)
+			if err != nil {
+				return nil, err
+			}
+			comparison.Values = append(comparison.Values, value)
+
+			if p.peek().kind != tokenComma {
+				break
+			}
+			p.next()
+		}
+		if _, err := p.expect(tokenRParen, `")" or ","`); err != nil {
+			return nil, err
+		}
+		return comparison, nil
+	}
+
+	value, err := p.parseValue(comparison)
+	if err != nil {
+		return nil, err
+	}
+	comparison.Values = []Value{value}
+
+	return comparison, nil
+}
+
+func (p *parser) parseField() (string, error) {
+	t, err := p.expect(tokenIdent, "field name")
+	if err != nil {
+		return "", err
+	}
+	field, ok := resolveField(t.text)
+	if !ok {
+		return "", p.syntaxError(t, "unknown field {0}", strconv.Quote(t.text))
+	}
+	return field, nil
+}
+
+func (p *parser) parseOperator(comparison *Comparison) error {
+	t := p.next()
+
+	var op Operator
+	switch {
+	case t.kind == tokenColon:
+		op = OpEq
+	case t.kind == tokenOperator:
+		op = Operator(t.text)
+	case p.isKeyword(t, "in"):
+		op = OpIn
+	case p.isKeyword(t, "not") && p.isKeyword(p.peek(), "in"):
+		p.next()
+		op = OpNotIn
+	default:
+		return p.syntaxError(t, "expected operator, got {0}", describe(t))
+	}
+
+	allowed := map[FieldType][]Operator{
+		TypeText:   {OpEq, OpNe, OpContains, OpIn, OpNotIn},
+		TypeEnum:   {OpEq, OpNe, OpLt, OpLe, OpGt, OpGe, OpIn, OpNotIn},
+		TypeTime:   {OpEq, OpNe, OpLt, OpLe, OpGt, OpGe},
+		TypeSet:    {OpEq, OpNe, OpContains, OpIn, OpNotIn},
+		TypeNumber: {OpEq, OpNe, OpLt, OpLe, OpGt, OpGe},
+	}
+	if !slices.Contains(allowed[comparison.Type()], op) {
+		return p.syntaxError(t, "operator {0} is not supported for {1}", strconv.Quote(string(op)), comparison.Field)
+	}
+
+	comparison.Op = op
+	return nil
+}
+
+func (p *parser) parseValue(comparison *Comparison) (Value, error) {
+	t := p.next()
+	value := Value{Position: t.pos}
+
+	switch comparison.Type() {
+	case TypeNumber:
+		if t.kind != tokenLiteral {
+			return value, p.syntaxError(t, "expected number, got {0}", describe(t))
+		}
+		n, err := strconv.Atoi(t.text)
+		if err != nil {
+			return value, p.syntaxError(t, "invalid number {0}", strconv.Quote(t.text))
+		}
+		value.Kind = KindNumber
+		value.Num = n
+
+	case TypeTime:
+		switch t.kind {
+		case tokenLiteral, tokenString:
+			if date, err := time.Parse(time.DateOnly, t.text); err == nil {
+				value.Kind = KindDate
+				value.Time = date
+			} else if instant, err := time.Parse(time.RFC3339, t.text); err == nil {
+				value.Kind = KindTime
+				value.Time = instant
+			} else {
+				return value, p.syntaxError(t, "invalid date {0}, expected YYYY-MM-DD or RFC 3339", strconv.Quote(t.text))
+			}
+		case tokenIdent:
+			if err := p.parseCall(t, &value); err != nil {
+				return value, err
+			}
+		default:
+			return value, p.syntaxError(t, "expected date, got {0}", describe(t))
+		}
+
+	default:
+		if t.kind != tokenIdent && t.kind != tokenString && t.kind != tokenLiteral {
+			return value, p.syntaxError(t, "expected value, got {0}", describe(t))
+		}
+		value.Kind = KindString
+		value.Str = t.text
+
+		if values, ok := enumValues[comparison.Field]; ok && !slices.Contains(values, t.text) {
+			return value, p.syntaxError(t, "invalid {0} {1}, expected one of {2}", comparison.Field, strconv.Quote(t.text), strings.Join(values, ", "))
+		}
+	}
+
+	return value, nil
+}
+
+func (p *parser) parseCall(t token, value *Value) error {
+	value.Kind = KindCall
+	value.Func = t.text
+
+	if _, err := p.expect(tokenLParen, `"(" after function name`); err != nil {
+		return err
+	}
+
+	switch t.text {
+	case FuncNow, FuncToday, FuncStartOfWeek, FuncStartOfMonth:
+	case FuncDaysAgo, FuncDaysAhead:
+		arg, err := p.expect(tokenLiteral, "number of days")
+		if err != nil {
+			return err
+		}
+		n, err := strconv.Atoi(arg.text)
+		if err != nil || n > 36500 {
+			return p.syntaxError(arg, "invalid number of days {0}", strconv.Quote(arg.text))
+		}
+		value.Arg = n
+	default:
+		return p.syntaxError(t, "unknown date function {0}", strconv.Quote(t.text))
+	}
+
+	_, err := p.expect(tokenRParen, `")"`)
+	return err
+}
+
+func resolveField(name string) (string, bool) {
+	if alias, ok := fieldAliases[name]; ok {
+		return alias, true
+	}
+	_, ok := fieldTypes[name]
+	return name, ok
+}
+
+func describe(t token) string {
+	if t.kind == tokenEOF {
+		return "end of filter"
+	}
+	return strconv.Quote(t.text)
+}
@@ -0,0 +1,607 @@
+package handler
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"simple-tasks/internal/ical"
+	"simple-tasks/internal/model"
+	"simple-tasks/internal/service"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	nsDav            = "DAV:"
+	nsCalDav         = "urn:ietf:params:xml:ns:caldav"
+	nsCalendarServer = "http://calendarserver.org/ns/"
+
+	// davRoot is both the principal and its calendar home, which holds the
+	// single task calendar at davCollection.
+	davRoot       = "/dav/"
+	davCollection = "/dav/tasks/"
+
+	davMaxResourceBytes = 1 << 20
+
+	xmlContentType  = "application/xml; charset=utf-8"
+	icalContentType = "text/calendar; charset=utf-8"
+)
+
+var (
+	davCalendarQuery    = xml.Name{Space: nsCalDav, Local: "calendar-query"}
+	davCalendarMultiget = xml.Name{Space: nsCalDav, Local: "calendar-multiget"}
+	davCalendarData     = xml.Name{Space: nsCalDav, Local: "calendar-data"}
+)
+
+// DavHandler serves the tasks as a CalDAV calendar of VTODO resources, so
+// calendar and reminder apps can sync them. A resource is named after the
+// UID of its VTODO, which is the task's external id or its id.
+type DavHandler struct {
+	log     *slog.Logger
+	service *service.TaskService
+}
+
+func NewDavHandler(log *slog.Logger, service *service.TaskService) *DavHandler {
+	return &DavHandler{
+		log:     log,
+		service: service,
+	}
+}
+
+type davProperty struct {
+	XMLName xml.Name
+	Inner   string `xml:",innerxml"`
+}
+
+type davProp struct {
+	Props []davProperty
+}
+
+type davPropstat struct {
+	Prop   davProp `xml:"DAV: prop"`
+	Status string  `xml:"DAV: status"`
+}
+
+type davResponse struct {
+	Href     string        `xml:"DAV: href"`
+	Propstat []davPropstat `xml:"DAV: propstat,omitempty"`
+	Status   string        `xml:"DAV: status,omitempty"`
+}
+
+type davMultistatus struct {
+	XMLName   xml.Name      `xml:"DAV: multistatus"`
+	Responses []davResponse `xml:"DAV: response"`
+}
+
+type davError struct {
+	XMLName   xml.Name `xml:"DAV: error"`
+	Condition davProperty
+}
+
+type davPropNames struct {
+	Names []struct {
+		XMLName xml.Name
+	} `xml:",any"`
+}
+
+// davPropSelection is the part of PROPFIND and REPORT bodies that selects
+// the properties to return.
+type davPropSelection struct {
+	AllProp  *struct{}     `xml:"DAV: allprop"`
+	PropName *struct{}     `xml:"DAV: propname"`
+	Prop     *davPropNames `xml:"DAV: prop"`
+}
+
+type davPropfind struct {
+	XMLName xml.Name `xml:"DAV: propfind"`
+	davPropSelection
+}
+
+type davReport struct {
+	XMLName xml.Name
+	davPropSelection
+	Hrefs  []string   `xml:"DAV: href"`
+	Filter *davFilter `xml:"urn:ietf:params:xml:ns:caldav filter"`
+}
+
+type davFilter struct {
+	CompFilter *davCompFilter `xml:"urn:ietf:params:xml:ns:caldav comp-filter"`
+}
+
+type davCompFilter struct {
+	Name         string          `xml:"name,attr"`
+	IsNotDefined *struct{}       `xml:"urn:ietf:params:xml:ns:caldav is-not-defined"`
+	CompFilters  []davCompFilter `xml:"urn:ietf:params:xml:ns:caldav comp-filter"`
+	PropFilters  []davPropFilter `xml:"urn:ietf:params:xml:ns:caldav prop-filter"`
+}
+
+type davPropFilter struct {
+	Name         string        `xml:"name,attr"`
+	IsNotDefined *struct{}     `xml:"urn:ietf:params:xml:ns:caldav is-not-defined"`
+	TextMatch    *davTextMatch `xml:"urn:ietf:params:xml:ns:caldav text-match"`
+}
+
+type davTextMatch struct {
+	Text   string `xml:",chardata"`
+	Negate string `xml:"negate-condition,attr"`
+}
+
+func (h *DavHandler) Options(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("DAV", "1, 3, calendar-access")
+	w.Header().Set("Allow", "OPTIONS, GET, PUT, DELETE, PROPFIND, REPORT")
+	w.WriteHeader(http.StatusOK)
+}
+
+// Propfind describes the principal, the task calendar and its resources.
+// Depth infinity is treated as 1, which already reaches every resource.
+func (h *DavHandler) Propfind(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", xmlContentType)
+
+	var request davPropfind
+	if err := xml.NewDecoder(r.Body).Decode(&request); err != nil && !errors.Is(err, io.EOF) {
+		h.log.ErrorContext(r.Context(), "invalid xml", slog.String("error", err.Error()))
+
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	depth := r.Header.Get("Depth")
+	responses := make([]davResponse, 0)
+
+	switch path := strings.TrimSuffix(r.URL.Path, "/"); path {
+	case strings.TrimSuffix(davRoot, "/"):
+		responses = append(responses, request.response(davRoot, davRootProps()))
+		if depth != "0" {
+			responses = append(responses, request.response(davCollection, h.collectionProps(r)))
+		}
+
+	case strings.TrimSuffix(davCollection, "/"):
+		responses = append(responses, request.response(davCollection, h.collectionProps(r)))
+		if depth == "0" {
+			break
+		}
+
+		tasks, err := h.service.IterTasks(r.Context(), &model.GetTasksRequest{})
+		if err != nil {
+			h.log.ErrorContext(r.Context(), "task list failed", slog.String("error", err.Error()))
+
+			w.WriteHeader(serviceStatus(err))
+			return
+		}
+		stamp := time.Now()
+		for task := range tasks {
+			props := davTaskProps(&task, ical.TaskCalendar(&task, stamp))
+			responses = append(responses, request.response(davHref(&task), props))
+		}
+
+	default:
+		task, err := h.hrefTask(r, path)
+		if err != nil {
+			h.log.ErrorContext(r.Context(), "task lookup failed", slog.String("path", path), slog.String("error", err.Error()))
+
+			w.WriteHeader(serviceStatus(err))
+			return
+		}
+		props := davTaskProps(task, ical.TaskCalendar(task, time.Now()))
+		responses = append(responses, request.response(davHref(task), props))
+	}
+
+	writeMultistatus(w, responses)
+}
+
+// Report answers calendar-query and calendar-multiget reports on the task
+// calendar. Queries support component and property filters; time ranges are
+// not evaluated and match every task, which clients filter again anyway.
+func (h *DavHandler) Report(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", xmlContentType)
+
+	var report davReport
+	if err := xml.NewDecoder(r.Body).Decode(&report); err != nil {
+		h.log.ErrorContext(r.Context(), "invalid xml", slog.String("error", err.Error()))
+
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	stamp := time.Now()
+	responses := make([]davResponse, 0)
+
+	switch report.XMLName {
+	case davCalendarMultiget:
+		for _, href := range report.Hrefs {
+			task, err := h.hrefTask(r, href)
+			if errors.Is(err, service.NotFoundError) {
+				responses = append(responses, davResponse{Href: href, Status: davStatus(http.StatusNotFound)})
+				continue
+			} else if err != nil {
+				h.log.ErrorContext(r.Context(), "task lookup failed", slog.String("error", err.Error()))
+
+				w.WriteHeader(serviceStatus(err))
+				return
+			}
+			props := davTaskProps(task, ical.TaskCalendar(task, stamp))
+			responses = append(responses, report.response(href, props))
+		}
+
+	case davCalendarQuery:
+		tasks, err := h.service.IterTasks(r.Context(), &model.GetTasksRequest{})
+		if err != nil {
+			h.log.ErrorContext(r.Context(), "task list failed", slog.String("error", err.Error()))
+
+			w.WriteHeader(serviceStatus(err))
+			return
+		}
+		for task := range tasks {
+			calendar := ical.TaskCalendar(&task, stamp)
+			if report.Filter != nil && report.Filter.CompFilter != nil &&
+				!report.Filter.CompFilter.matches([]*ical.Component{calendar}) {
+				continue
+			}
+			responses = append(responses, report.response(davHref(&task), davTaskProps(&task, calendar)))
+		}
+
+	default:
+		h.log.ErrorContext(r.Context(), "unsupported report", slog.String("report", report.XMLName.Local))
+
+		writeDavError(w, http.StatusForbidden, xml.Name{Space: nsDav, Local: "supported-report"})
+		return
+	}
+
+	writeMultistatus(w, responses)
+}
+
+func (h *DavHandler) GetTask(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", xmlContentType)
+
+	task, err := h.nameTask(r)
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "task lookup failed", slog.String("name", r.PathValue("name")), slog.String("error", err.Error()))
+
+		w.WriteHeader(serviceStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", icalContentType)
+	w.Header().Set("ETag", davEtag(task))
+	w.WriteHeader(http.StatusOK)
+	_, _ = io.WriteString(w, encodeCalendar(ical.TaskCalendar(task, time.Now())))
+}
+
+// PutTask creates or replaces the task of a resource. The VTODO's UID must
+// match the resource name, and If-Match and If-None-Match guard against
+// overwriting changes the client hasn't seen.
+func (h *DavHandler) PutTask(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", xmlContentType)
+
+	uid, ok := strings.CutSuffix(r.PathValue("name"), ".ics")
+	if !ok || uid == "" {
+		h.log.ErrorContext(r.Context(), "invalid resource name", slog.String("name", r.PathValue("name")))
+
+		writeDavError(w, http.StatusForbidden, xml.Name{Space: nsCalDav, Local: "valid-calendar-object-resource"})
+		return
+	}
+
+	calendar, err := ical.Parse(http.MaxBytesReader(w, r.Body, davMaxResourceBytes))
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "invalid calendar data", slog.String("error", err.Error()))
+
+		writeDavError(w, http.StatusForbidden, xml.Name{Space: nsCalDav, Local: "valid-calendar-data"})
+		return
+	}
+
+	todos := slices.DeleteFunc(slices.Clone(calendar.Components), func(c *ical.Component) bool {
+		return c.Name == "VTIMEZONE"
+	})
+	if calendar.Name != "VCALENDAR" || len(todos) != 1 || todos[0].Name != "VTODO" {
+		h.log.ErrorContext(r.Context(), "unsupported calendar component", slog.String("uid", uid))
+
+		writeDavError(w, http.StatusForbidden, xml.Name{Space: nsCalDav, Local: "supported-calendar-component"})
+		return
+	}
+
+	task, err := ical.TaskFromTodo(todos[0])
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "invalid calendar data", slog.String("error", err.Error()))
+
+		writeDavError(w, http.StatusForbidden, xml.Name{Space: nsCalDav, Local: "valid-calendar-data"})
+		return
+	}
+	if task.ExternalId == "" {
+		task.ExternalId = uid
+	}
+	if task.ExternalId != uid {
+		h.log.ErrorContext(r.Context(), "uid does not match resource name", slog.String("uid", task.ExternalId))
+
+		writeDavError(w, http.StatusForbidden, xml.Name{Space: nsCalDav, Local: "valid-calendar-object-resource"})
+		return
+	}
+	if err := validate.Struct(task); err != nil {
+		h.log.ErrorContext(r.Context(), "invalid task", slog.String("error", err.Error()))
+
+		writeDavError(w, http.StatusForbidden, xml.Name{Space: nsCalDav, Local: "valid-calendar-object-resource"})
+		return
+	}
+
+	saved, status, err := h.service.ImportTaskIf(r.Context(), &task, func(current *model.Task) bool {
+		return checkPreconditions(r, current)
+	})
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "task import failed", slog.String("uid", uid), slog.String("error", err.Error()))
+
+		w.WriteHeader(serviceStatus(err))
+		return
+	}
+
+	w.Header().Set("ETag", davEtag(saved))
+	if status == model.ImportCreated {
+		w.WriteHeader(http.StatusCreated)
+	} else {
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (h *DavHandler) DeleteTask(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", xmlContentType)
+
+	task, err := h.nameTask(r)
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "task lookup failed", slog.String("name", r.PathValue("name")), slog.String("error", err.Error()))
+
+		w.WriteHeader(serviceStatus(err))
+		return
+	}
+
+	err = h.service.DeleteTaskIf(r.Context(), task.Id, func(current *model.Task) bool {
+		return checkPreconditions(r, current)
+	})
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "task delete failed", slog.String("id", task.Id.String()), slog.String("error", err.Error()))
+
+		w.WriteHeader(serviceStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// nameTask finds the task of the resource named in the request path.
+func (h *DavHandler) nameTask(r *http.Request) (*model.Task, error) {
+	uid, ok := strings.CutSuffix(r.PathValue("name"), ".ics")
+	if !ok || uid == "" {
+		return nil, service.NotFoundError
+	}
+	return h.service.GetTaskByExternalId(r.Context(), uid)
+}
+
+// hrefTask finds the task of a resource href, which may be a path or an
+// absolute URL.
+func (h *DavHandler) hrefTask(r *http.Request, href string) (*model.Task, error) {
+	u, err := url.Parse(href)
+	if err != nil {
+		return nil, service.NotFoundError
+	}
+	name, ok := strings.CutPrefix(u.Path, davCollection)
+	if !ok || strings.Contains(name, "/") {
+		return nil, service.NotFoundError
+	}
+	uid, ok := strings.CutSuffix(name, ".ics")
+	if !ok || uid == "" {
+		return nil, service.NotFoundError
+	}
+	return h.service.GetTaskByExternalId(r.Context(), uid)
+}
+
+func (h *DavHandler) collectionProps(r *http.Request) []davProperty {
+	return []davProperty{
+		davXml(nsDav, "resourcetype", `<collection xmlns="DAV:"/><calendar xmlns="urn:ietf:params:xml:ns:caldav"/>`),
+		davText(nsDav, "displayname", "Tasks"),
+		davXml(nsDav, "current-user-principal", davHrefXml(davRoot)),
+		davXml(nsDav, "current-user-privilege-set", davPrivileges),
+		davXml(nsDav, "supported-report-set", `<supported-report xmlns="DAV:"><report>`+
+			`<calendar-query xmlns="urn:ietf:params:xml:ns:caldav"/></report></supported-report>`+
+			`<supported-report xmlns="DAV:"><report>`+
+			`<calendar-multiget xmlns="urn:ietf:params:xml:ns:caldav"/></report></supported-report>`),
+		davXml(nsCalDav, "supported-calendar-component-set", `<comp xmlns="urn:ietf:params:xml:ns:caldav" name="VTODO"/>`),
+		davText(nsCalendarServer, "getctag", h.service.SyncToken(r.Context())),
+	}
+}
+
+const davPrivileges = `<privilege xmlns="DAV:"><read/></privilege>` +
+	`<privilege xmlns="DAV:"><write/></privilege>` +
+	`<privilege xmlns="DAV:"><write-content/></privilege>` +
+	`<privilege xmlns="DAV:"><bind/></privilege>` +
+	`<privilege xmlns="DAV:"><unbind/></privilege>`
+
+func davRootProps() []davProperty {
+	return []davProperty{
+		davXml(nsDav, "resourcetype", `<collection xmlns="DAV:"/><principal xmlns="DAV:"/>`),
+		davText(nsDav, "displayname", "simple-tasks"),
+		davXml(nsDav, "current-user-principal", davHrefXml(davRoot)),
+		davXml(nsDav, "principal-URL", davHrefXml(davRoot)),
+		davXml(nsDav, "current-user-privilege-set", `<privilege xmlns="DAV:"><read/></privilege>`),
+		davXml(nsCalDav, "calendar-home-set", davHrefXml(davRoot)),
+	}
+}
+
+func davTaskProps(task *model.Task, calendar *ical.Component) []davProperty {
+	return []davProperty{
+		davXml(nsDav, "resourcetype", ""),
+		davText(nsDav, "getetag", davEtag(task)),
+		davText(nsDav, "getcontenttype", icalContentType),
+		davText(nsDav, "getlastmodified", task.UpdatedAt.UTC().Format(http.TimeFormat)),
+		davText(nsCalDav, "calendar-data", encodeCalendar(calendar)),
+	}
+}
+
+// response picks the selected properties out of the ones a resource has.
+// Properties it doesn't have are reported as not found; calendar-data is
+// only returned when asked for by name.
+func (s *davPropSelection) response(href string, props []davProperty) davResponse {
+	found := make([]davProperty, 0, len(props))
+	missing := make([]davProperty, 0)
+
+	switch {
+	case s.PropName != nil:
+		for _, prop := range props {
+			found = append(found, davProperty{XMLName: prop.XMLName})
+		}
+	case s.Prop == nil:
+		for _, prop := range props {
+			if prop.XMLName != davCalendarData {
+				found = append(found, prop)
+			}
+		}
+	default:
+		for _, name := range s.Prop.Names {
+			i := slices.IndexFunc(props, func(prop davProperty) bool {
+				return prop.XMLName == name.XMLName
+			})
+			if i >= 0 {
+				found = append(found, props[i])
+			} else {
+				missing = append(missing, davProperty{XMLName: name.XMLName})
+			}
+		}
+	}
+
+	response := davResponse{Href: href}
+	if len(found) > 0 {
+		response.Propstat = append(response.Propstat, davPropstat{Prop: davProp{Props: found}, Status: davStatus(http.StatusOK)})
+	}
+	if len(missing) > 0 {
+		response.Propstat = append(response.Propstat, davPropstat{Prop: davProp{Props: missing}, Status: davStatus(http.StatusNotFound)})
+	}
+	return response
+}
+
+// matches reports whether one of the components satisfies the filter, or
+// none is present for an is-not-defined filter.
+func (f *davCompFilter) matches(components []*ical.Component) bool {
+	for _, c := range components {
+		if !strings.EqualFold(c.Name, f.Name) {
+			continue
+		}
+		if f.IsNotDefined != nil {
+			return false
+		}
+		if f.matchesComponent(c) {
+			return true
+		}
+	}
+	return f.IsNotDefined != nil
+}
+
+func (f *davCompFilter) matchesComponent(c *ical.Component) bool {
+	for _, propFilter := range f.PropFilters {
+		if !propFilter.matches(c) {
+			return false
+		}
+	}
+	for _, compFilter := range f.CompFilters {
+		if !compFilter.matches(c.Components) {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *davPropFilter) matches(c *ical.Component) bool {
+	properties := c.GetAll(strings.ToUpper(f.Name))
+	if f.IsNotDefined != nil {
+		return len(properties) == 0
+	}
+	if f.TextMatch == nil {
+		return len(properties) > 0
+	}
+
+	negate := f.TextMatch.Negate == "yes"
+	text := strings.ToLower(f.TextMatch.Text)
+	return slices.ContainsFunc(properties, func(p ical.Property) bool {
+		return strings.Contains(strings.ToLower(p.Text()), text) != negate
+	})
+}
+
+// checkPreconditions evaluates If-Match and If-None-Match against the
+// current version of a resource, nil when it doesn't exist.
+func checkPreconditions(r *http.Request, task *model.Task) bool {
+	if match := r.Header.Get("If-Match"); match != "" {
+		if task == nil || (match != "*" && !slices.Contains(splitEtags(match), davEtag(task))) {
+			return false
+		}
+	}
+	if noneMatch := r.Header.Get("If-None-Match"); noneMatch != "" && task != nil {
+		if noneMatch == "*" || slices.Contains(splitEtags(noneMatch), davEtag(task)) {
+			return false
+		}
+	}
+	return true
+}
+
+func splitEtags(header string) []string {
+	etags := strings.Split(header, ",")
+	for i, etag := range etags {
+		etags[i] = strings.TrimPrefix(strings.TrimSpace(etag), "W/")
+	}
+	return etags
+}
+
+// davEtag changes with every write to the task, which always moves its
+// UpdatedAt.
+func davEtag(task *model.Task) string {
+	return `"` + strconv.FormatInt(task.UpdatedAt.UnixNano(), 36) + `"`
+}
+
+func davHref(task *model.Task) string {
+	return davCollection + url.PathEscape(task.ExportId()) + ".ics"
+}
+
+func davHrefXml(href string) string {
+	return `<href xmlns="DAV:">` + davEscape(href) + `</href>`
+}
+
+func davStatus(code int) string {
+	return fmt.Sprintf("HTTP/1.1 %d %s", code, http.StatusText(code))
+}
+
+func davText(space string, local string, text string) davProperty {
+	return davXml(space, local, davEscape(text))
+}
+
+func davXml(space string, local string, inner string) davProperty {
+	return davProperty{XMLName: xml.Name{Space: space, Local: local}, Inner: inner}
+}
+
+// davEscape escapes text for element content. Carriage returns are kept as
+// references so calendar data keeps its CRLF line endings.
+var davEscape = strings.NewReplacer(
+	"&", "&amp;",
+	"<", "&lt;",
+	">", "&gt;",
+	"\r", "&#13;",
+).Replace
+
+func encodeCalendar(calendar *ical.Component) string {
+	var sb strings.Builder
+	encoder := ical.NewEncoder(&sb)
+	encoder.Component(calendar)
+	_ = encoder.Flush()
+	return sb.String()
+}
+
+func writeMultistatus(w http.ResponseWriter, responses []davResponse) {
+	w.WriteHeader(http.StatusMultiStatus)
+	_, _ = io.WriteString(w, xml.Header)
+	_ = xml.NewEncoder(w).Encode(davMultistatus{Responses: responses})
+}
+
+func writeDavError(w http.ResponseWriter, status int, condition xml.Name) {
+	w.WriteHeader(status)
+	_, _ = io.WriteString(w, xml.Header)
+	_ = xml.NewEncoder(w).Encode(davError{Condition: davProperty{XMLName: condition}})
+}
@@ -0,0 +1,401 @@
+package handler
+
+import (
+	"encoding/xml"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"simple-tasks/internal/service"
+	"simple-tasks/internal/store"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// The request bodies below are recorded from Apple Reminders and
+// Thunderbird, trimmed to the properties the server answers.
+const (
+	davPrincipalPropfind = `<?xml version="1.0" encoding="UTF-8"?>
+<A:propfind xmlns:A="DAV:">
+  <A:prop>
+    <A:current-user-principal/>
+    <B:calendar-home-set xmlns:B="urn:ietf:params:xml:ns:caldav"/>
+    <A:principal-URL/>
+  </A:prop>
+</A:propfind>`
+
+	davHomePropfind = `<?xml version="1.0" encoding="UTF-8"?>
+<A:propfind xmlns:A="DAV:">
+  <A:prop>
+    <A:resourcetype/>
+    <A:displayname/>
+    <A:current-user-privilege-set/>
+    <B:supported-calendar-component-set xmlns:B="urn:ietf:params:xml:ns:caldav"/>
+    <C:getctag xmlns:C="http://calendarserver.org/ns/"/>
+    <D:calendar-color xmlns:D="http://apple.com/ns/ical/"/>
+  </A:prop>
+</A:propfind>`
+
+	davEtagPropfind = `<?xml version="1.0" encoding="UTF-8"?>
+<D:propfind xmlns:D="DAV:">
+  <D:prop>
+    <D:getetag/>
+    <D:getcontenttype/>
+  </D:prop>
+</D:propfind>`
+
+	davIncompleteQuery = `<?xml version="1.0" encoding="UTF-8"?>
+<B:calendar-query xmlns:B="urn:ietf:params:xml:ns:caldav">
+  <A:prop xmlns:A="DAV:">
+    <A:getetag/>
+  </A:prop>
+  <B:filter>
+    <B:comp-filter name="VCALENDAR">
+      <B:comp-filter name="VTODO">
+        <B:prop-filter name="COMPLETED">
+          <B:is-not-defined/>
+        </B:prop-filter>
+      </B:comp-filter>
+    </B:comp-filter>
+  </B:filter>
+</B:calendar-query>`
+
+	davEventQuery = `<?xml version="1.0" encoding="UTF-8"?>
+<C:calendar-query xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">
+  <D:prop><D:getetag/></D:prop>
+  <C:filter>
+    <C:comp-filter name="VCALENDAR">
+      <C:comp-filter name="VEVENT">
+        <C:time-range start="20250101T000000Z" end="20260101T000000Z"/>
+      </C:comp-filter>
+    </C:comp-filter>
+  </C:filter>
+</C:calendar-query>`
+
+	davMultiget = `<?xml version="1.0" encoding="UTF-8"?>
+<C:calendar-multiget xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">
+  <D:prop>
+    <D:getetag/>
+    <C:calendar-data/>
+  </D:prop>
+  <D:href>/dav/tasks/0C7F9E8A-2B1D-4C6E-9F3A-5D8B7E6A4C21.ics</D:href>
+  <D:href>http://localhost:8080/dav/tasks/missing.ics</D:href>
+</C:calendar-multiget>`
+
+	davReminder = "BEGIN:VCALENDAR\r\n" +
+		"VERSION:2.0\r\n" +
+		"PRODID:-//Apple Inc.//iOS 18.0//EN\r\n" +
+		"CALSCALE:GREGORIAN\r\n" +
+		"BEGIN:VTODO\r\n" +
+		"UID:0C7F9E8A-2B1D-4C6E-9F3A-5D8B7E6A4C21\r\n" +
+		"DTSTAMP:20251001T080000Z\r\n" +
+		"CREATED:20251001T080000Z\r\n" +
+		"SUMMARY:Купить молоко\r\n" +
+		"DUE;VALUE=DATE:20251003\r\n" +
+		"PRIORITY:0\r\n" +
+		"STATUS:NEEDS-ACTION\r\n" +
+		"X-APPLE-SORT-ORDER:752134567\r\n" +
+		"END:VTODO\r\n" +
+		"END:VCALENDAR\r\n"
+
+	davReminderUid = "0C7F9E8A-2B1D-4C6E-9F3A-5D8B7E6A4C21"
+)
+
+type davTestResponse struct {
+	Href     string `xml:"href"`
+	Status   string `xml:"status"`
+	Propstat []struct {
+		Prop struct {
+			Inner string `xml:",innerxml"`
+		} `xml:"prop"`
+		Status string `xml:"status"`
+	} `xml:"propstat"`
+}
+
+func createTestDavHandler() (*TaskHandler, http.Handler) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	taskService := service.NewTaskService(log, store.NewInMemoryTaskRepository())
+	davHandler := NewDavHandler(log, taskService)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("OPTIONS /dav/", davHandler.Options)
+	mux.HandleFunc("PROPFIND /dav/", davHandler.Propfind)
+	mux.HandleFunc("REPORT /dav/tasks/{$}", davHandler.Report)
+	mux.HandleFunc("GET /dav/tasks/{name}", davHandler.GetTask)
+	mux.HandleFunc("PUT /dav/tasks/{name}", davHandler.PutTask)
+	mux.HandleFunc("DELETE /dav/tasks/{name}", davHandler.DeleteTask)
+
+	return NewTaskHandler(log, taskService), mux
+}
+
+func serveDav(mux http.Handler, method string, target string, headers map[string]string, body string) *http.Response {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	return w.Result()
+}
+
+func davMultistatusOf(t *testing.T, resp *http.Response) map[string]davTestResponse {
+	if resp.StatusCode != http.StatusMultiStatus {
+		t.Fatalf("expected status %v, got %v", http.StatusMultiStatus, resp.StatusCode)
+	}
+
+	var multistatus struct {
+		Responses []davTestResponse `xml:"response"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&multistatus); err != nil {
+		t.Fatalf("error reading response body: %v", err)
+	}
+	responses := make(map[string]davTestResponse)
+	for _, response := range multistatus.Responses {
+		responses[response.Href] = response
+	}
+	return responses
+}
+
+// propstat returns the properties reported with the status code.
+func (r davTestResponse) propstat(code int) string {
+	for _, propstat := range r.Propstat {
+		if strings.Contains(propstat.Status, " "+http.StatusText(code)) {
+			return propstat.Prop.Inner
+		}
+	}
+	return ""
+}
+
+func TestDavDiscovery(t *testing.T) {
+	_, mux := createTestDavHandler()
+
+	resp := serveDav(mux, http.MethodOptions, "/dav/", nil, "")
+	if dav := resp.Header.Get("DAV"); !strings.Contains(dav, "calendar-access") {
+		t.Errorf("expected calendar-access in DAV header, got %q", dav)
+	}
+
+	responses := davMultistatusOf(t, serveDav(mux, "PROPFIND", "/dav/", map[string]string{"Depth": "0"}, davPrincipalPropfind))
+	if len(responses) != 1 {
+		t.Fatalf("expected only the principal for depth 0, got %v", responses)
+	}
+	found := responses["/dav/"].propstat(http.StatusOK)
+	if strings.Count(found, "<href xmlns=\"DAV:\">/dav/</href>") != 3 {
+		t.Errorf("expected principal and calendar home at /dav/, got %s", found)
+	}
+
+	responses = davMultistatusOf(t, serveDav(mux, "PROPFIND", "/dav/", map[string]string{"Depth": "1"}, davHomePropfind))
+	collection, ok := responses["/dav/tasks/"]
+	if !ok {
+		t.Fatalf("expected the task calendar, got %v", responses)
+	}
+	found = collection.propstat(http.StatusOK)
+	for _, expected := range []string{`<calendar xmlns="urn:ietf:params:xml:ns:caldav"/>`, `name="VTODO"`, "getctag", "<write/>"} {
+		if !strings.Contains(found, expected) {
+			t.Errorf("expected %q in %s", expected, found)
+		}
+	}
+	if missing := collection.propstat(http.StatusNotFound); !strings.Contains(missing, "calendar-color") {
+		t.Errorf("expected calendar-color to be reported as not found, got %q", missing)
+	}
+}
+
+func TestDavSync(t *testing.T) {
+	taskHandler, mux := createTestDavHandler()
+	resource := "/dav/tasks/" + davReminderUid + ".ics"
+
+	ctag := func() string {
+		responses := davMultistatusOf(t, serveDav(mux, "PROPFIND", "/dav/tasks/", map[string]string{"Depth": "0"}, davHomePropfind))
+		return responses["/dav/tasks/"].propstat(http.StatusOK)
+	}
+	before := ctag()
+
+	resp := serveDav(mux, http.MethodPut, resource, map[string]string{"If-None-Match": "*"}, davReminder)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected status %v, got %v", http.StatusCreated, resp.StatusCode)
+	}
+	etag := resp.Header.Get("ETag")
+	if etag == "" {
+		t.Fatal("expected an ETag")
+	}
+	if ctag() == before {
+		t.Error("expected the ctag to change")
+	}
+
+	resp = serveDav(mux, http.MethodPut, resource, map[string]string{"If-None-Match": "*"}, davReminder)
+	if resp.StatusCode != http.StatusPreconditionFailed {
+		t.Errorf("expected status %v for an existing resource, got %v", http.StatusPreconditionFailed, resp.StatusCode)
+	}
+
+	responses := davMultistatusOf(t, serveDav(mux, "REPORT", "/dav/tasks/", map[string]string{"Depth": "1"}, davIncompleteQuery))
+	if response, ok := responses[resource]; !ok || !strings.Contains(response.propstat(http.StatusOK), etag) {
+		t.Fatalf("expected the incomplete task with its etag, got %v", responses)
+	}
+
+	completed := strings.Replace(davReminder, "STATUS:NEEDS-ACTION", "STATUS:COMPLETED\r\nCOMPLETED:20251002T090000Z", 1)
+	resp = serveDav(mux, http.MethodPut, resource, map[string]string{"If-Match": etag}, completed)
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("expected status %v, got %v", http.StatusNoContent, resp.StatusCode)
+	}
+	newEtag := resp.Header.Get("ETag")
+	if newEtag == etag {
+		t.Error("expected the ETag to change")
+	}
+
+	resp = serveDav(mux, http.MethodPut, resource, map[string]string{"If-Match": etag}, davReminder)
+	if resp.StatusCode != http.StatusPreconditionFailed {
+		t.Errorf("expected status %v for a stale ETag, got %v", http.StatusPreconditionFailed, resp.StatusCode)
+	}
+
+	_, page, _ := getTaskPage(t, taskHandler, "/tasks")
+	if len(page.Tasks) != 1 || page.Tasks[0].Status != "done" || page.Tasks[0].ExternalId != davReminderUid {
+		t.Fatalf("expected a single completed task, got %+v", page.Tasks)
+	}
+
+	responses = davMultistatusOf(t, serveDav(mux, "REPORT", "/dav/tasks/", nil, davIncompleteQuery))
+	if len(responses) != 0 {
+		t.Errorf("expected no incomplete tasks, got %v", responses)
+	}
+	responses = davMultistatusOf(t, serveDav(mux, "REPORT", "/dav/tasks/", nil, davEventQuery))
+	if len(responses) != 0 {
+		t.Errorf("expected no events, got %v", responses)
+	}
+
+	responses = davMultistatusOf(t, serveDav(mux, "REPORT", "/dav/tasks/", nil, davMultiget))
+	if data := responses[resource].propstat(http.StatusOK); !strings.Contains(data, "STATUS:COMPLETED") || !strings.Contains(data, newEtag) {
+		t.Errorf("expected calendar data of the completed task, got %s", data)
+	}
+	if status := responses["http://localhost:8080/dav/tasks/missing.ics"].Status; !strings.Contains(status, "404") {
+		t.Errorf("expected missing resource to be not found, got %q", status)
+	}
+
+	resp = serveDav(mux, http.MethodGet, resource, nil, "")
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("ETag") != newEtag || !strings.Contains(string(body), "SUMMARY:Купить молоко") {
+		t.Errorf("expected the resource, got %v %q\n%s", resp.StatusCode, resp.Header.Get("ETag"), body)
+	}
+
+	resp = serveDav(mux, http.MethodDelete, resource, map[string]string{"If-Match": etag}, "")
+	if resp.StatusCode != http.StatusPreconditionFailed {
+		t.Errorf("expected status %v for a stale ETag, got %v", http.StatusPreconditionFailed, resp.StatusCode)
+	}
+	resp = serveDav(mux, http.MethodDelete, resource, map[string]string{"If-Match": newEtag}, "")
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("expected status %v, got %v", http.StatusNoContent, resp.StatusCode)
+	}
+	resp = serveDav(mux, http.MethodGet, resource, nil, "")
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected status %v after delete, got %v", http.StatusNotFound, resp.StatusCode)
+	}
+}
+
+func TestDavTasksCreatedByApi(t *testing.T) {
+	taskHandler, mux := createTestDavHandler()
+	task := createTask(t, taskHandler, `{"title":"report","priority":"high"}`)
+	resource := "/dav/tasks/" + task.Id.String() + ".ics"
+
+	responses := davMultistatusOf(t, serveDav(mux, "PROPFIND", "/dav/tasks/", map[string]string{"Depth": "1"}, davEtagPropfind))
+	response, ok := responses[resource]
+	if !ok {
+		t.Fatalf("expected %s, got %v", resource, responses)
+	}
+	etag := davEtag(&task)
+	if !strings.Contains(response.propstat(http.StatusOK), etag) {
+		t.Errorf("expected etag %s, got %s", etag, response.propstat(http.StatusOK))
+	}
+
+	resp := serveDav(mux, http.MethodGet, resource, nil, "")
+	body, _ := io.ReadAll(resp.Body)
+	edited := strings.Replace(string(body), "SUMMARY:report", "SUMMARY:final report", 1)
+
+	resp = serveDav(mux, http.MethodPut, resource, map[string]string{"If-Match": etag}, edited)
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("expected status %v, got %v", http.StatusNoContent, resp.StatusCode)
+	}
+
+	_, page, _ := getTaskPage(t, taskHandler, "/tasks")
+	titles := make([]string, 0, len(page.Tasks))
+	for _, task := range page.Tasks {
+		titles = append(titles, task.Title)
+	}
+	if !slices.Equal(titles, []string{"final report"}) || page.Tasks[0].Priority != "high" {
+		t.Errorf("expected the task to be updated in place, got %+v", page.Tasks)
+	}
+}
+
+func TestDavErrors(t *testing.T) {
+	_, mux := createTestDavHandler()
+
+	event := strings.NewReplacer("VTODO", "VEVENT").Replace(davReminder)
+	tests := []struct {
+		name              string
+		method            string
+		target            string
+		body              string
+		expectedStatus    int
+		expectedCondition string
+	}{
+		{
+			name:              "uid does not match resource name",
+			method:            http.MethodPut,
+			target:            "/dav/tasks/other.ics",
+			body:              davReminder,
+			expectedStatus:    http.StatusForbidden,
+			expectedCondition: "valid-calendar-object-resource",
+		},
+		{
+			name:              "event",
+			method:            http.MethodPut,
+			target:            "/dav/tasks/" + davReminderUid + ".ics",
+			body:              event,
+			expectedStatus:    http.StatusForbidden,
+			expectedCondition: "supported-calendar-component",
+		},
+		{
+			name:              "invalid calendar data",
+			method:            http.MethodPut,
+			target:            "/dav/tasks/" + davReminderUid + ".ics",
+			body:              strings.Replace(davReminder, "END:VTODO\r\n", "", 1),
+			expectedStatus:    http.StatusForbidden,
+			expectedCondition: "valid-calendar-data",
+		},
+		{
+			name:              "missing summary",
+			method:            http.MethodPut,
+			target:            "/dav/tasks/" + davReminderUid + ".ics",
+			body:              strings.Replace(davReminder, "SUMMARY:Купить молоко\r\n", "", 1),
+			expectedStatus:    http.StatusForbidden,
+			expectedCondition: "valid-calendar-object-resource",
+		},
+		{
+			name:              "unsupported report",
+			method:            "REPORT",
+			target:            "/dav/tasks/",
+			body:              `<D:sync-collection xmlns:D="DAV:"><D:sync-token/></D:sync-collection>`,
+			expectedStatus:    http.StatusForbidden,
+			expectedCondition: "supported-report",
+		},
+		{
+			name:           "unknown resource",
+			method:         "PROPFIND",
+			target:         "/dav/tasks/missing.ics",
+			body:           davEtagPropfind,
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := serveDav(mux, tt.method, tt.target, nil, tt.body)
+			if resp.StatusCode != tt.expectedStatus {
+				t.Fatalf("expected status %v, got %v", tt.expectedStatus, resp.StatusCode)
+			}
+
+			body, _ := io.ReadAll(resp.Body)
+			if tt.expectedCondition != "" && !strings.Contains(string(body), "<"+tt.expectedCondition) {
+				t.Errorf("expected condition %s, got %s", tt.expectedCondition, body)
+			}
+		})
+	}
+}
@@ -1,16 +1,35 @@
 package handler
 
 import (
+	"bufio"
 	"context"
+	"encoding/csv"
+	json2 "encoding/json"
 	"errors"
+	ut "github.com/go-playground/universal-translator"
 	"github.com/go-playground/validator/v10"
+	"io"
+	"net/http"
+	"reflect"
+	"simple-tasks/internal/codec"
+	"simple-tasks/internal/filter"
+	"simple-tasks/internal/ical"
+	"simple-tasks/internal/importer"
 	"simple-tasks/internal/middleware"
+	"simple-tasks/internal/service"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type ErrorDetail struct {
+	// Field is the path of the invalid field by its JSON name, such as
+	// "tags[1]", or the name of a query parameter.
 	Field   string `json:"field"`
 	Rule    string `json:"rule"`
 	Message string `json:"message"`
+	// Position is the 1-based character position of a filter syntax error.
+	Position *int `json:"position,omitempty"`
 }
 
 type ErrorInfo struct {
@@ -24,6 +43,35 @@ type ErrorResponse struct {
 	RequestId string    `json:"requestId"`
 }
 
+const problemContentType = "application/problem+json"
+
+// problemTypePrefix starts the type URIs of problems, which end with the
+// error code.
+const problemTypePrefix = "urn:simple-tasks:problem:"
+
+// Problem is an RFC 7807 problem document, sent instead of an ErrorResponse
+// to clients that accept application/problem+json.
+type Problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Instance  string `json:"instance,omitempty"`
+	Code      string `json:"code"`
+	RequestId string `json:"requestId,omitempty"`
+	// InvalidParams lists the failed rules of validation errors.
+	InvalidParams []InvalidParam `json:"invalid-params,omitempty"`
+}
+
+type InvalidParam struct {
+	Name string `json:"name"`
+	// Pointer is the RFC 6901 JSON pointer of the field, such as "/tags/1".
+	Pointer  string `json:"pointer"`
+	Rule     string `json:"rule"`
+	Reason   string `json:"reason"`
+	Position *int   `json:"position,omitempty"`
+}
+
 type ErrType = int
 
 const (
@@ -32,17 +80,100 @@ const (
 	errorNotFound
 	errorBadRequest
 	errorInternal
+	errorForbidden
+	errorConflict
+	errorNotAcceptable
+	errorUnsupportedMediaType
+	errorPreconditionFailed
+	errorUnavailable
+	errorPayloadTooLarge
 )
 
 var codeMap = map[int]string{
-	errorInvalidJson: "invalid_json",
-	errorValidation:  "validation_error",
-	errorNotFound:    "not_found",
-	errorBadRequest:  "bad_request",
-	errorInternal:    "errorInternal",
+	errorInvalidJson:          "invalid_json",
+	errorValidation:           "validation_error",
+	errorNotFound:             "not_found",
+	errorBadRequest:           "bad_request",
+	errorInternal:             "internal",
+	errorForbidden:            "forbidden",
+	errorConflict:             "conflict",
+	errorNotAcceptable:        "not_acceptable",
+	errorUnsupportedMediaType: "unsupported_media_type",
+	errorPreconditionFailed:   "precondition_failed",
+	errorUnavailable:          "unavailable",
+	errorPayloadTooLarge:      "payload_too_large",
+}
+
+var problemTitles = map[int]string{
+	errorInvalidJson:          "Malformed request body",
+	errorValidation:           "Validation failed",
+	errorNotFound:             "Resource not found",
+	errorBadRequest:           "Bad request",
+	errorInternal:             "Internal server error",
+	errorForbidden:            "Forbidden",
+	errorConflict:             "Conflict",
+	errorNotAcceptable:        "Not acceptable",
+	errorUnsupportedMediaType: "Unsupported media type",
+	errorPreconditionFailed:   "Precondition failed",
+	errorUnavailable:          "Service unavailable",
+	errorPayloadTooLarge:      "Payload too large",
+}
+
+type serviceErrorMapping struct {
+	status  int
+	errType ErrType
 }
 
-func newError(ctx context.Context, errType ErrType, err error) *ErrorResponse {
+// serviceErrors maps the kinds of service errors to responses.
+var serviceErrors = map[service.ErrorKind]serviceErrorMapping{
+	service.KindInternal:           {http.StatusInternalServerError, errorInternal},
+	service.KindNotFound:           {http.StatusNotFound, errorNotFound},
+	service.KindConflict:           {http.StatusConflict, errorConflict},
+	service.KindPreconditionFailed: {http.StatusPreconditionFailed, errorPreconditionFailed},
+	service.KindForbidden:          {http.StatusForbidden, errorForbidden},
+	service.KindInvalid:            {http.StatusBadRequest, errorBadRequest},
+	service.KindValidation:         {http.StatusUnprocessableEntity, errorValidation},
+	service.KindUnavailable:        {http.StatusServiceUnavailable, errorUnavailable},
+}
+
+// writeServiceError responds with an error returned by a service, with the
+// status code of its kind.
+func writeServiceError(w http.ResponseWriter, r *http.Request, err error) {
+	mapping := serviceErrorOf(err)
+	writeError(w, r, mapping.status, mapping.errType, err)
+}
+
+// serviceStatus returns the status code of a service error, for responses
+// without an error body such as those of CalDAV.
+func serviceStatus(err error) int {
+	return serviceErrorOf(err).status
+}
+
+func serviceErrorOf(err error) serviceErrorMapping {
+	mapping, ok := serviceErrors[service.KindOf(err)]
+	if !ok {
+		mapping = serviceErrors[service.KindInternal]
+	}
+	return mapping
+}
+
+// writeError responds with err as an error of errType, in the format of the
+// response Content-Type, or as a problem document when the client accepts
+// one. Messages are in the language of the Accept-Language header.
+func writeError(w http.ResponseWriter, r *http.Request, status int, errType ErrType, err error) {
+	setContentLanguage(w, r)
+	if acceptsProblem(r) {
+		w.Header().Set("Content-Type", problemContentType)
+		w.WriteHeader(status)
+		_ = json2.NewEncoder(w).Encode(newProblem(r, status, errType, err))
+		return
+	}
+
+	w.WriteHeader(status)
+	_ = encode(w, newError(r.Context(), requestTranslator(r), errType, err))
+}
+
+func newError(ctx context.Context, trans ut.Translator, errType ErrType, err error) *ErrorResponse {
 	reqId, ok := ctx.Value(middleware.RequestId).(string)
 	if !ok {
 		reqId = ""
@@ -51,28 +182,253 @@ func newError(ctx context.Context, errType ErrType, err error) *ErrorResponse {
 	errResponse := &ErrorResponse{
 		Error: ErrorInfo{
 			Code:    codeMap[errType],
-			Message: err.Error(),
+			Message: errorMessage(trans, errType, err),
 			Details: []ErrorDetail{},
 		},
 		RequestId: reqId,
 	}
 
-	if errType == errorValidation {
-		var errFields validator.ValidationErrors
-		if !errors.As(err, &errFields) {
-			return errResponse
-		}
+	var syntaxErr *filter.SyntaxError
+	if errType == errorValidation && errors.As(err, &syntaxErr) {
+		errResponse.Error.Details = []ErrorDetail{{
+			Field:    "filter",
+			Rule:     "syntax",
+			Message:  filterMessage(trans, syntaxErr),
+			Position: &syntaxErr.Pos,
+		}}
+		return errResponse
+	}
 
-		details := make([]ErrorDetail, 0, len(errFields))
-		for _, err := range errFields {
-			details = append(details, ErrorDetail{
-				Field:   err.StructField(),
-				Rule:    err.ActualTag() + " " + err.Param(),
-				Message: err.Error(),
+	var paramErrs paramErrors
+	if errors.As(err, &paramErrs) {
+		for _, paramErr := range paramErrs {
+			errResponse.Error.Details = append(errResponse.Error.Details, ErrorDetail{
+				Field:   paramErr.Name,
+				Rule:    paramErr.Rule,
+				Message: paramMessage(trans, paramErr),
 			})
 		}
-		errResponse.Error.Details = details
+		return errResponse
+	}
+
+	var fieldErr *unknownFieldError
+	if errors.As(err, &fieldErr) {
+		errResponse.Error.Details = []ErrorDetail{{
+			Field:   fieldErr.Field,
+			Rule:    "unknown",
+			Message: errResponse.Error.Message,
+		}}
+		return errResponse
+	}
+
+	if errType == errorValidation {
+		if details := validationDetails(trans, err); details != nil {
+			errResponse.Error.Details = details
+		}
 	}
 
 	return errResponse
 }
+
+func newProblem(r *http.Request, status int, errType ErrType, err error) *Problem {
+	trans := requestTranslator(r)
+	errResponse := newError(r.Context(), trans, errType, err)
+
+	problem := &Problem{
+		Type:      problemTypePrefix + errResponse.Error.Code,
+		Title:     localize(trans, problemTitles[errType]),
+		Status:    status,
+		Detail:    errResponse.Error.Message,
+		Instance:  r.URL.Path,
+		Code:      errResponse.Error.Code,
+		RequestId: errResponse.RequestId,
+	}
+	for _, detail := range errResponse.Error.Details {
+		problem.InvalidParams = append(problem.InvalidParams, InvalidParam{
+			Name:     detail.Field,
+			Pointer:  jsonPointer(detail.Field),
+			Rule:     detail.Rule,
+			Reason:   detail.Message,
+			Position: detail.Position,
+		})
+	}
+	return problem
+}
+
+// validationDetails describes every failed rule of a validator error in the
+// language of trans, or returns nil for other errors.
+func validationDetails(trans ut.Translator, err error) []ErrorDetail {
+	var errFields validator.ValidationErrors
+	if !errors.As(err, &errFields) {
+		return nil
+	}
+
+	details := make([]ErrorDetail, 0, len(errFields))
+	for _, err := range errFields {
+		details = append(details, ErrorDetail{
+			Field:   fieldPath(err),
+			Rule:    err.ActualTag() + " " + err.Param(),
+			Message: ruleMessage(trans, err),
+		})
+	}
+	return details
+}
+
+// requestError is an invalid request described by a message of the catalog,
+// so that clients get it in their language.
+type requestError struct {
+	Template string
+	Args     []string
+	// Err is the error it is a case of, if any.
+	Err error
+}
+
+func newRequestError(template string, args ...string) *requestError {
+	return &requestError{Template: template, Args: args}
+}
+
+func (e *requestError) Error() string {
+	return localize(translations.GetFallback(), e.Template, e.Args...)
+}
+
+func (e *requestError) Unwrap() error { return e.Err }
+
+// errorMessage describes err for clients in the language of trans. Internal
+// errors are not described at all, errors of the standard library, which
+// mention Go types and functions, and of document parsers are rephrased, and
+// the remaining errors get a generic message.
+func errorMessage(trans ut.Translator, errType ErrType, err error) string {
+	if errType == errorInternal {
+		return localize(trans, "internal server error")
+	}
+
+	var errFields validator.ValidationErrors
+	var paramErrs paramErrors
+	var fieldErr *unknownFieldError
+	var syntaxErr *json2.SyntaxError
+	var typeErr *json2.UnmarshalTypeError
+	var timeErr *time.ParseError
+	var numErr *strconv.NumError
+	var maxBytesErr *http.MaxBytesError
+	var filterErr *filter.SyntaxError
+	var reqErr *requestError
+	var serviceErr *service.Error
+	var codecErr *codec.SyntaxError
+	var icalErr *ical.ParseError
+	var csvErr *csv.ParseError
+	var formatErr *importer.FormatError
+	switch {
+	case errors.As(err, &serviceErr):
+		return localize(trans, serviceErr.Msg)
+	case errors.As(err, &reqErr):
+		return localize(trans, reqErr.Template, reqErr.Args...)
+	case errors.As(err, &filterErr):
+		return localize(trans, "filter syntax error at position {0}: {1}", strconv.Itoa(filterErr.Pos), filterMessage(trans, filterErr))
+	case errors.As(err, &errFields):
+		messages := make([]string, 0, len(errFields))
+		for _, fieldErr := range errFields {
+			messages = append(messages, ruleMessage(trans, fieldErr))
+		}
+		return strings.Join(messages, "; ")
+	case errors.As(err, &paramErrs):
+		messages := make([]string, 0, len(paramErrs))
+		for _, paramErr := range paramErrs {
+			messages = append(messages, paramMessage(trans, paramErr))
+		}
+		return strings.Join(messages, "; ")
+	case errors.As(err, &fieldErr):
+		return localize(trans, "{0} is not a known field", fieldErr.Field)
+	case errors.Is(err, errTrailingData):
+		return localize(trans, "request body has data after the first value")
+	case errors.As(err, &maxBytesErr):
+		return localize(trans, "request body is larger than {0}", count(trans, "byte", strconv.FormatInt(maxBytesErr.Limit, 10)))
+	case errors.As(err, &syntaxErr):
+		return localize(trans, "malformed JSON at offset {0}", strconv.FormatInt(syntaxErr.Offset, 10))
+	case errors.As(err, &typeErr):
+		field := typeErr.Field
+		if field == "" {
+			field = "body"
+		}
+		return localize(trans, "{0} must be {1}", field, localize(trans, jsonTypeName(typeErr.Type)))
+	case errors.As(err, &timeErr):
+		return localize(trans, "{0} is not an RFC 3339 date-time", strconv.Quote(strings.Trim(timeErr.Value, `"`)))
+	case errors.As(err, &numErr) && numErr.Func == "ParseBool":
+		return localize(trans, "{0} is not true or false", strconv.Quote(numErr.Num))
+	case errors.As(err, &numErr):
+		return localize(trans, "{0} is not a valid number", strconv.Quote(numErr.Num))
+	case errors.Is(err, io.EOF):
+		return localize(trans, "request body is empty")
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return localize(trans, "request body ends unexpectedly")
+	case errors.Is(err, bufio.ErrTooLong):
+		return localize(trans, "request body has a line longer than {0}", count(trans, "byte", strconv.Itoa(maxLineBytes)))
+	case errors.As(err, &codecErr):
+		return localize(trans, "malformed {0} document: {1}", formatNames[codecErr.Format], codecErr.Msg)
+	case errors.As(err, &icalErr):
+		return localize(trans, "malformed iCalendar on line {0}: {1}", strconv.Itoa(icalErr.Line), icalErr.Msg)
+	case errors.As(err, &csvErr):
+		return localize(trans, "malformed CSV on line {0}: {1}", strconv.Itoa(csvErr.Line), localize(trans, csvErr.Err.Error()))
+	case errors.As(err, &formatErr):
+		return localize(trans, formatErr.Msg)
+	}
+	return localize(trans, "invalid request")
+}
+
+// formatNames are the names of the formats of codec syntax errors.
+var formatNames = map[string]string{
+	"yaml":    "YAML",
+	"xml":     "XML",
+	"msgpack": "MessagePack",
+}
+
+// filterMessage translates the message of a filter syntax error along with
+// the phrases among its arguments.
+func filterMessage(trans ut.Translator, err *filter.SyntaxError) string {
+	args := make([]string, 0, len(err.Args))
+	for _, arg := range err.Args {
+		args = append(args, localize(trans, arg))
+	}
+	return localize(trans, err.Template, args...)
+}
+
+func jsonTypeName(t reflect.Type) string {
+	if t == nil {
+		return "a valid value"
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "true or false"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	}
+	return "a valid value"
+}
+
+// jsonPointer converts a field path such as "changes[0].field" or "tags[1]"
+// to a JSON pointer.
+func jsonPointer(field string) string {
+	if field == "" {
+		return ""
+	}
+
+	var b strings.Builder
+	for _, part := range strings.Split(field, ".") {
+		name, index, _ := strings.Cut(part, "[")
+		b.WriteString("/" + strings.NewReplacer("~", "~0", "/", "~1").Replace(name))
+		for index != "" {
+			var key string
+			key, index, _ = strings.Cut(index, "]")
+			b.WriteString("/" + strings.NewReplacer("~", "~0", "/", "~1").Replace(key))
+			index = strings.TrimPrefix(index, "[")
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,424 @@
+package handler
+
+import (
+	json2 "encoding/json"
+	"errors"
+	"github.com/go-playground/validator/v10"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"simple-tasks/internal/service"
+	"strings"
+	"testing"
+)
+
+func TestErrorDetails(t *testing.T) {
+	handler := createTestHandler()
+
+	tests := []struct {
+		name            string
+		requestBody     string
+		expectedStatus  int
+		expectedMessage string
+		expectedDetails []ErrorDetail
+	}{
+		{
+			name:            "json field names",
+			requestBody:     `{"title":"","status":"later","tags":["ok",""]}`,
+			expectedStatus:  http.StatusUnprocessableEntity,
+			expectedMessage: "title is required; status must be one of: todo, in_progress, done; tags[1] must be at least 1 character long",
+			expectedDetails: []ErrorDetail{
+				{Field: "title", Rule: "required ", Message: "title is required"},
+				{Field: "status", Rule: "oneof todo in_progress done", Message: "status must be one of: todo, in_progress, done"},
+				{Field: "tags[1]", Rule: "gte 1", Message: "tags[1] must be at least 1 character long"},
+			},
+		},
+		{
+			name:            "too many tags",
+			requestBody:     `{"title":"Test","tags":["a","b","c","d","e","f","g","h","i","j","k"]}`,
+			expectedStatus:  http.StatusUnprocessableEntity,
+			expectedMessage: "tags must contain at most 10 items",
+			expectedDetails: []ErrorDetail{{Field: "tags", Rule: "lte 10", Message: "tags must contain at most 10 items"}},
+		},
+		{
+			name:            "wrong json type",
+			requestBody:     `{"title":5}`,
+			expectedStatus:  http.StatusBadRequest,
+			expectedMessage: "title must be a string",
+		},
+		{
+			name:            "invalid date",
+			requestBody:     `{"title":"Test","dueDate":"tomorrow"}`,
+			expectedStatus:  http.StatusBadRequest,
+			expectedMessage: `"tomorrow" is not an RFC 3339 date-time`,
+		},
+		{
+			name:            "empty body",
+			expectedStatus:  http.StatusBadRequest,
+			expectedMessage: "request body is empty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(tt.requestBody))
+			w := httptest.NewRecorder()
+			handler.CreateTask(w, req)
+
+			resp := w.Result()
+			if resp.StatusCode != tt.expectedStatus {
+				t.Fatalf("expected status %v, got %v", tt.expectedStatus, resp.StatusCode)
+			}
+			var errResp ErrorResponse
+			if err := json2.NewDecoder(resp.Body).Decode(&errResp); err != nil {
+				t.Fatalf("error reading response body: %v", err)
+			}
+			if errResp.Error.Message != tt.expectedMessage {
+				t.Errorf("expected message %q, got %q", tt.expectedMessage, errResp.Error.Message)
+			}
+			if tt.expectedDetails == nil {
+				return
+			}
+			if len(errResp.Error.Details) != len(tt.expectedDetails) {
+				t.Fatalf("expected details %+v, got %+v", tt.expectedDetails, errResp.Error.Details)
+			}
+			for i, detail := range tt.expectedDetails {
+				if got := errResp.Error.Details[i]; got.Field != detail.Field || got.Rule != detail.Rule || got.Message != detail.Message {
+					t.Errorf("expected detail %+v, got %+v", detail, got)
+				}
+			}
+		})
+	}
+}
+
+func TestProblemDetails(t *testing.T) {
+	handler := createTestHandler()
+
+	req := httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(`{"title":"","tags":["ok",""]}`))
+	req.Header.Set("Accept", "application/json, application/problem+json")
+	w := httptest.NewRecorder()
+	handler.CreateTask(w, req)
+
+	resp := w.Result()
+	if resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("expected status %v, got %v", http.StatusUnprocessableEntity, resp.StatusCode)
+	}
+	if contentType := resp.Header.Get("Content-Type"); contentType != "application/problem+json" {
+		t.Fatalf("expected a problem document, got %q", contentType)
+	}
+
+	var problem Problem
+	if err := json2.NewDecoder(resp.Body).Decode(&problem); err != nil {
+		t.Fatalf("error reading response body: %v", err)
+	}
+	if problem.Type != "urn:simple-tasks:problem:validation_error" || problem.Title != "Validation failed" ||
+		problem.Status != http.StatusUnprocessableEntity || problem.Instance != "/tasks" || problem.Code != "validation_error" {
+		t.Errorf("unexpected problem %+v", problem)
+	}
+	expected := []InvalidParam{
+		{Name: "title", Pointer: "/title", Rule: "required ", Reason: "title is required"},
+		{Name: "tags[1]", Pointer: "/tags/1", Rule: "gte 1", Reason: "tags[1] must be at least 1 character long"},
+	}
+	if len(problem.InvalidParams) != len(expected) {
+		t.Fatalf("expected invalid params %+v, got %+v", expected, problem.InvalidParams)
+	}
+	for i, param := range expected {
+		if got := problem.InvalidParams[i]; got.Name != param.Name || got.Pointer != param.Pointer || got.Rule != param.Rule || got.Reason != param.Reason {
+			t.Errorf("expected invalid param %+v, got %+v", param, got)
+		}
+	}
+}
+
+func TestServiceErrors(t *testing.T) {
+	tests := []struct {
+		name            string
+		err             error
+		expectedStatus  int
+		expectedCode    string
+		expectedMessage string
+	}{
+		{"not found", service.NotFoundError, http.StatusNotFound, "not_found", "task not found"},
+		{"conflict", service.TagExistsError, http.StatusConflict, "conflict", "tag already exists"},
+		{"forbidden", service.ViewForbiddenError, http.StatusForbidden, "forbidden", "view belongs to another user"},
+		{"invalid", service.InvalidCursorError, http.StatusBadRequest, "bad_request", "invalid cursor"},
+		{"validation", service.TooManyTagsError, http.StatusUnprocessableEntity, "validation_error", "task can have at most 10 tags"},
+		{"unavailable", service.BrokerClosedError, http.StatusServiceUnavailable, "unavailable", "event broker closed"},
+		{"precondition failed", &service.Error{Kind: service.KindPreconditionFailed, Msg: "task has changed"}, http.StatusPreconditionFailed, "precondition_failed", "task has changed"},
+		{"internal", errors.New("open /var/lib/tasks.db: permission denied"), http.StatusInternalServerError, "internal", "internal server error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+			w := httptest.NewRecorder()
+			writeServiceError(w, req, tt.err)
+
+			resp := w.Result()
+			if resp.StatusCode != tt.expectedStatus {
+				t.Fatalf("expected status %v, got %v", tt.expectedStatus, resp.StatusCode)
+			}
+			var errResp ErrorResponse
+			if err := json2.NewDecoder(resp.Body).Decode(&errResp); err != nil {
+				t.Fatalf("error reading response body: %v", err)
+			}
+			if errResp.Error.Code != tt.expectedCode || errResp.Error.Message != tt.expectedMessage {
+				t.Errorf("expected %s %q, got %+v", tt.expectedCode, tt.expectedMessage, errResp.Error)
+			}
+		})
+	}
+}
+
+func TestLocalizedErrors(t *testing.T) {
+	handler := createTestHandler()
+
+	tests := []struct {
+		name                    string
+		acceptLanguage          string
+		requestBody             string
+		expectedContentLanguage string
+		expectedMessage         string
+	}{
+		{
+			name:                    "no accept language",
+			requestBody:             `{"title":""}`,
+			expectedContentLanguage: "en",
+			expectedMessage:         "title is required",
+		},
+		{
+			name:                    "russian",
+			acceptLanguage:          "ru",
+			requestBody:             `{"title":"","status":"later"}`,
+			expectedContentLanguage: "ru",
+			expectedMessage:         "поле title обязательно для заполнения; поле status должно быть одним из значений: todo, in_progress, done",
+		},
+		{
+			name:                    "regional variant",
+			acceptLanguage:          "ru-RU,ru;q=0.9,en;q=0.8",
+			requestBody:             `{"title":"Test","tags":["ok",""]}`,
+			expectedContentLanguage: "ru",
+			expectedMessage:         "поле tags[1] должно содержать минимум 1 символ",
+		},
+		{
+			name:                    "russian plural",
+			acceptLanguage:          "ru-RU",
+			requestBody:             `{"title":"Test","tags":["a","b","c","d","e","f","g","h","i","j","k"]}`,
+			expectedContentLanguage: "ru",
+			expectedMessage:         "поле tags должно содержать максимум 10 элементов",
+		},
+		{
+			name:                    "unsupported language first",
+			acceptLanguage:          "de-DE, ru;q=0.5",
+			requestBody:             `{"title":5}`,
+			expectedContentLanguage: "ru",
+			expectedMessage:         "поле title должно быть строкой",
+		},
+		{
+			name:                    "unsupported language",
+			acceptLanguage:          "fr-CH, fr;q=0.9",
+			requestBody:             `{"title":""}`,
+			expectedContentLanguage: "en",
+			expectedMessage:         "title is required",
+		},
+		{
+			name:                    "malformed header",
+			acceptLanguage:          "ru;q=x",
+			requestBody:             `{"title":""}`,
+			expectedContentLanguage: "en",
+			expectedMessage:         "title is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(tt.requestBody))
+			req.Header.Set("Accept-Language", tt.acceptLanguage)
+			w := httptest.NewRecorder()
+			handler.CreateTask(w, req)
+
+			resp := w.Result()
+			if contentLanguage := resp.Header.Get("Content-Language"); contentLanguage != tt.expectedContentLanguage {
+				t.Errorf("expected content language %q, got %q", tt.expectedContentLanguage, contentLanguage)
+			}
+			var errResp ErrorResponse
+			if err := json2.NewDecoder(resp.Body).Decode(&errResp); err != nil {
+				t.Fatalf("error reading response body: %v", err)
+			}
+			if errResp.Error.Message != tt.expectedMessage {
+				t.Errorf("expected message %q, got %q", tt.expectedMessage, errResp.Error.Message)
+			}
+		})
+	}
+}
+
+func TestLocalizedFilterErrors(t *testing.T) {
+	handler := createTestHandler()
+
+	tests := []struct {
+		name            string
+		acceptLanguage  string
+		filter          string
+		expectedMessage string
+		expectedDetail  string
+	}{
+		{
+			name:            "english",
+			filter:          `status = "later"`,
+			expectedMessage: `filter syntax error at position 10: invalid status "later", expected one of todo, in_progress, done`,
+			expectedDetail:  `invalid status "later", expected one of todo, in_progress, done`,
+		},
+		{
+			name:            "russian",
+			acceptLanguage:  "ru",
+			filter:          `status = "later"`,
+			expectedMessage: `синтаксическая ошибка фильтра в позиции 10: некорректное значение поля status: "later", ожидалось одно из: todo, in_progress, done`,
+			expectedDetail:  `некорректное значение поля status: "later", ожидалось одно из: todo, in_progress, done`,
+		},
+		{
+			name:            "russian phrase argument",
+			acceptLanguage:  "ru",
+			filter:          `title =`,
+			expectedMessage: "синтаксическая ошибка фильтра в позиции 8: ожидалось значение, получено конец фильтра",
+			expectedDetail:  "ожидалось значение, получено конец фильтра",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/tasks?"+url.Values{"filter": {tt.filter}}.Encode(), nil)
+			req.Header.Set("Accept-Language", tt.acceptLanguage)
+			w := httptest.NewRecorder()
+			handler.GetTasks(w, req)
+
+			var errResp ErrorResponse
+			if err := json2.NewDecoder(w.Result().Body).Decode(&errResp); err != nil {
+				t.Fatalf("error reading response body: %v", err)
+			}
+			if errResp.Error.Message != tt.expectedMessage {
+				t.Errorf("expected message %q, got %q", tt.expectedMessage, errResp.Error.Message)
+			}
+			if len(errResp.Error.Details) != 1 || errResp.Error.Details[0].Message != tt.expectedDetail {
+				t.Errorf("expected detail %q, got %+v", tt.expectedDetail, errResp.Error.Details)
+			}
+		})
+	}
+}
+
+func TestLocalizedServiceErrors(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+	req.Header.Set("Accept", "application/problem+json")
+	req.Header.Set("Accept-Language", "ru")
+	w := httptest.NewRecorder()
+	writeServiceError(w, req, service.NotFoundError)
+
+	var problem Problem
+	if err := json2.NewDecoder(w.Result().Body).Decode(&problem); err != nil {
+		t.Fatalf("error reading response body: %v", err)
+	}
+	if problem.Title != "Ресурс не найден" || problem.Detail != "задача не найдена" {
+		t.Errorf("expected a russian problem, got %+v", problem)
+	}
+}
+
+func TestLocalizedRequestErrors(t *testing.T) {
+	handler := createTestHandler()
+
+	tests := []struct {
+		name            string
+		method          string
+		target          string
+		id              string
+		contentType     string
+		requestBody     string
+		serve           func(w http.ResponseWriter, r *http.Request)
+		expectedMessage string
+	}{
+		{
+			name:            "invalid id",
+			method:          http.MethodGet,
+			target:          "/tasks/abc",
+			id:              "abc",
+			serve:           handler.GetTaskById,
+			expectedMessage: `"abc" не является допустимым идентификатором`,
+		},
+		{
+			name:            "import mapping",
+			method:          http.MethodPost,
+			target:          "/tasks/import?format=csv&mapping=title",
+			contentType:     "text/csv",
+			requestBody:     "title\nTest\n",
+			serve:           handler.ImportTasks,
+			expectedMessage: `некорректное сопоставление "title", ожидался формат поле:столбец`,
+		},
+		{
+			name:            "csv syntax",
+			method:          http.MethodPost,
+			target:          "/tasks/import?format=csv",
+			contentType:     "text/csv",
+			requestBody:     "title\n\"Test\"x\n",
+			serve:           handler.ImportTasks,
+			expectedMessage: `некорректный CSV в строке 2: лишняя или недостающая кавычка " в поле в кавычках`,
+		},
+		{
+			name:            "yaml syntax",
+			method:          http.MethodPost,
+			target:          "/tasks",
+			contentType:     "application/yaml",
+			requestBody:     "title: [",
+			serve:           handler.CreateTask,
+			expectedMessage: "некорректный документ YAML: line 1: did not find expected node content",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.requestBody))
+			req.SetPathValue("id", tt.id)
+			req.Header.Set("Content-Type", tt.contentType)
+			req.Header.Set("Accept-Language", "ru")
+			w := httptest.NewRecorder()
+			tt.serve(w, req)
+
+			var errResp ErrorResponse
+			if err := json2.NewDecoder(w.Result().Body).Decode(&errResp); err != nil {
+				t.Fatalf("error reading response body: %v", err)
+			}
+			if errResp.Error.Message != tt.expectedMessage {
+				t.Errorf("expected message %q, got %q", tt.expectedMessage, errResp.Error.Message)
+			}
+		})
+	}
+}
+
+func TestDefaultRuleMessages(t *testing.T) {
+	type request struct {
+		Email string `json:"email" validate:"email"`
+	}
+
+	tests := []struct {
+		locale          string
+		expectedMessage string
+	}{
+		{"en", "email must be a valid email address"},
+		{"ru", "email должен быть email адресом"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			trans, _ := translations.GetTranslator(tt.locale)
+			var errFields validator.ValidationErrors
+			if !errors.As(validate.Struct(request{Email: "tasks"}), &errFields) {
+				t.Fatal("expected a validation error")
+			}
+			if message := ruleMessage(trans, errFields[0]); message != tt.expectedMessage {
+				t.Errorf("expected message %q, got %q", tt.expectedMessage, message)
+			}
+		})
+	}
+}
+
+func TestLocalizeFillsParametersOnce(t *testing.T) {
+	message := localize(translations.GetFallback(), "invalid mapping {0}, unknown field {1}", `"due{1}:Due"`, `"due{1}"`)
+	if expected := `invalid mapping "due{1}:Due", unknown field "due{1}"`; message != expected {
+		t.Errorf("expected message %q, got %q", expected, message)
+	}
+}
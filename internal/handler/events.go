@@ -0,0 +1,139 @@
+package handler
+
+import (
+	json2 "encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"simple-tasks/internal/model"
+	"simple-tasks/internal/service"
+	"strconv"
+	"time"
+)
+
+const (
+	eventResync      = "resync"
+	sseRetryInterval = 3 * time.Second
+)
+
+type EventHandler struct {
+	log       *slog.Logger
+	broker    *service.EventBroker
+	heartbeat time.Duration
+}
+
+func NewEventHandler(log *slog.Logger, broker *service.EventBroker, heartbeat time.Duration) *EventHandler {
+	return &EventHandler{
+		log:       log,
+		broker:    broker,
+		heartbeat: heartbeat,
+	}
+}
+
+func (h *EventHandler) StreamTaskEvents(w http.ResponseWriter, r *http.Request) {
+	if err := checkQuery(r, taskFilterParams); err != nil {
+		h.log.ErrorContext(r.Context(), "invalid query", slog.String("error", err.Error()))
+
+		w.Header().Set("Content-Type", errorContentType(r))
+		writeError(w, r, http.StatusUnprocessableEntity, errorValidation, err)
+		return
+	}
+
+	req := parseTaskFilters(r.URL.Query())
+
+	if err := validate.Struct(req); err != nil {
+		h.log.ErrorContext(r.Context(), "invalid request", slog.String("error", err.Error()))
+
+		w.Header().Set("Content-Type", errorContentType(r))
+		writeError(w, r, http.StatusUnprocessableEntity, errorValidation, err)
+		return
+	}
+
+	var lastSeq uint64
+	if lastEventId := r.Header.Get("Last-Event-ID"); lastEventId != "" {
+		seq, err := strconv.ParseUint(lastEventId, 10, 64)
+		if err != nil {
+			h.log.ErrorContext(r.Context(), "invalid Last-Event-ID", slog.String("error", err.Error()))
+
+			w.Header().Set("Content-Type", errorContentType(r))
+			writeError(w, r, http.StatusBadRequest, errorBadRequest, err)
+			return
+		}
+		lastSeq = seq
+	}
+
+	sub, replay, complete, err := h.broker.Subscribe(lastSeq)
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "subscribe failed", slog.String("error", err.Error()))
+
+		w.Header().Set("Content-Type", errorContentType(r))
+		writeServiceError(w, r, err)
+		return
+	}
+	defer h.broker.Unsubscribe(sub)
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := fmt.Fprintf(w, "retry: %d\n\n", sseRetryInterval.Milliseconds()); err != nil {
+		return
+	}
+	if !complete {
+		// Some events were evicted from the buffer, so the client has to
+		// reload the task list before applying the stream.
+		if _, err := fmt.Fprintf(w, "event: %s\ndata: {}\n\n", eventResync); err != nil {
+			return
+		}
+	}
+	for _, event := range replay {
+		if err := h.writeEvent(w, req, event); err != nil {
+			return
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		h.log.ErrorContext(r.Context(), "streaming unsupported", slog.String("error", err.Error()))
+		return
+	}
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		case event, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			if err := h.writeEvent(w, req, event); err != nil {
+				return
+			}
+		}
+
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func (h *EventHandler) writeEvent(w http.ResponseWriter, req *model.GetTasksRequest, event service.StreamEvent) error {
+	if !req.Matches(&event.Task) {
+		return nil
+	}
+
+	data, err := json2.Marshal(event.TaskEvent)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.Seq, event.Type, data)
+	return err
+}
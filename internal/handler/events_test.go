@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"bufio"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"simple-tasks/internal/service"
+	"simple-tasks/internal/store"
+	"strings"
+	"testing"
+	"time"
+)
+
+func createTestEventHandler() (*TaskHandler, *service.EventBroker, *httptest.Server) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	taskService := service.NewTaskService(log, store.NewInMemoryTaskRepository())
+	broker := service.NewEventBroker(3)
+	taskService.AddListener(broker.Publish)
+
+	eventHandler := NewEventHandler(log, broker, time.Hour)
+	server := httptest.NewServer(http.HandlerFunc(eventHandler.StreamTaskEvents))
+
+	return NewTaskHandler(log, taskService), broker, server
+}
+
+type sseEvent struct {
+	id    string
+	event string
+	data  string
+}
+
+// readEvents reads server-sent events until count events are collected.
+func readEvents(t *testing.T, scanner *bufio.Scanner, count int) []sseEvent {
+	events := make([]sseEvent, 0, count)
+	var current sseEvent
+	for len(events) < count && scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if current.event != "" {
+				events = append(events, current)
+			}
+			current = sseEvent{}
+		case strings.HasPrefix(line, "id: "):
+			current.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			current.event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			current.data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+	if len(events) < count {
+		t.Fatalf("expected %d events, got %d", count, len(events))
+	}
+	return events
+}
+
+func openStream(t *testing.T, url string, lastEventId string) *http.Response {
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	if lastEventId != "" {
+		req.Header.Set("Last-Event-ID", lastEventId)
+	}
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("error opening stream: %v", err)
+	}
+	if resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("expected Content-Type text/event-stream, got %s", resp.Header.Get("Content-Type"))
+	}
+	return resp
+}
+
+func TestStreamTaskEvents(t *testing.T) {
+	taskHandler, broker, server := createTestEventHandler()
+	defer server.Close()
+
+	resp := openStream(t, server.URL+"?status=done", "")
+	defer resp.Body.Close()
+
+	for _, body := range []string{`{"title":"Todo task"}`, `{"title":"Done task","status":"done"}`} {
+		req := httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(body))
+		taskHandler.CreateTask(httptest.NewRecorder(), req)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	events := readEvents(t, scanner, 1)
+	if events[0].event != "task.created" {
+		t.Errorf("expected event task.created, got %v", events[0].event)
+	}
+	if events[0].id != "2" {
+		t.Errorf("expected event id 2, got %v", events[0].id)
+	}
+	if !strings.Contains(events[0].data, "Done task") {
+		t.Errorf("expected filtered event for done task, got %v", events[0].data)
+	}
+
+	broker.Close()
+	if scanner.Scan() {
+		t.Errorf("expected stream to end on broker close, got %q", scanner.Text())
+	}
+}
+
+func TestStreamTaskEventsResume(t *testing.T) {
+	taskHandler, _, server := createTestEventHandler()
+	defer server.Close()
+
+	for _, title := range []string{"first", "second", "third", "fourth", "fifth"} {
+		req := httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(`{"title":"`+title+`"}`))
+		taskHandler.CreateTask(httptest.NewRecorder(), req)
+	}
+
+	tests := []struct {
+		name           string
+		lastEventId    string
+		expectedEvents []string
+	}{
+		{
+			name:           "resume from buffer",
+			lastEventId:    "3",
+			expectedEvents: []string{"4", "5"},
+		},
+		{
+			name:           "resume from evicted event",
+			lastEventId:    "1",
+			expectedEvents: []string{"resync", "3", "4", "5"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := openStream(t, server.URL, tt.lastEventId)
+			defer resp.Body.Close()
+
+			events := readEvents(t, bufio.NewScanner(resp.Body), len(tt.expectedEvents))
+			for i, event := range events {
+				actual := event.id
+				if event.event == eventResync {
+					actual = eventResync
+				}
+				if actual != tt.expectedEvents[i] {
+					t.Errorf("expected event %v at %d, got %v", tt.expectedEvents[i], i, actual)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,357 @@
+package handler
+
+import (
+	"encoding/csv"
+	json2 "encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"log/slog"
+	"net/http"
+	"simple-tasks/internal/ical"
+	"simple-tasks/internal/model"
+	"simple-tasks/internal/taskwarrior"
+	"simple-tasks/internal/todotxt"
+	"simple-tasks/internal/xlsx"
+	"strings"
+	"time"
+)
+
+const (
+	exportFlushEvery = 100
+	// csvTagSeparator joins the tags of a task into a single CSV cell; see
+	// joinTags.
+	csvTagSeparator = ";"
+)
+
+type exportFormat struct {
+	contentType string
+	// extension defaults to the format name.
+	extension string
+	write     func(w io.Writer, tasks iter.Seq[model.Task], flush func()) error
+}
+
+var exportFormats = map[string]exportFormat{
+	"csv":     {contentType: "text/csv; charset=utf-8", write: writeCsv},
+	"ndjson":  {contentType: "application/x-ndjson", write: writeNdjson},
+	"json":    {contentType: "application/json", write: writeJsonArray},
+	"ics":     {contentType: icalContentType, write: writeIcal},
+	"todotxt": {contentType: "text/plain; charset=utf-8", extension: "txt", write: writeTodotxt},
+	// taskwarrior is the JSON array read by `task import`.
+	"taskwarrior": {contentType: "application/json", extension: "json", write: writeTaskwarrior},
+	"xlsx":        {contentType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", write: writeXlsx},
+}
+
+// xlsxWidths are the column widths of the XLSX export, in the order of
+// csvHeader.
+var xlsxWidths = []float64{38, 40, 50, 12, 10, 24, 17, 17, 17, 17, 20}
+
+var csvHeader = []string{
+	"id", "title", "content", "status", "priority", "tags",
+	"dueDate", "createdAt", "updatedAt", "completedAt", "externalId",
+}
+
+// ExportTasks writes every task matching the filters of the request, in the
+// requested order, as a downloadable file. Rows are encoded and flushed as
+// they go instead of building the whole document first.
+func (h *TaskHandler) ExportTasks(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", errorContentType(r))
+
+	if err := checkQuery(r, taskListParams, []string{"format"}); err != nil {
+		h.log.ErrorContext(r.Context(), "invalid query", slog.String("error", err.Error()))
+
+		writeError(w, r, http.StatusUnprocessableEntity, errorValidation, err)
+		return
+	}
+
+	query := r.URL.Query()
+	formatName := query.Get("format")
+	if formatName == "" {
+		formatName = "csv"
+	}
+	if err := validate.Var(formatName, "oneof=csv ndjson json ics todotxt taskwarrior xlsx"); err != nil {
+		h.log.ErrorContext(r.Context(), "invalid format", slog.String("error", err.Error()))
+
+		writeError(w, r, http.StatusUnprocessableEntity, errorValidation, err)
+		return
+	}
+
+	extension := exportFormats[formatName].extension
+	if extension == "" {
+		extension = formatName
+	}
+	filename := fmt.Sprintf("tasks-%s.%s", time.Now().UTC().Format("20060102-150405"), extension)
+	h.exportTasks(w, r, formatName, fmt.Sprintf(`attachment; filename="%s"`, filename))
+}
+
+// GetTasksIcal serves the tasks matching the list filters as an iCalendar
+// feed that calendar clients can subscribe to.
+func (h *TaskHandler) GetTasksIcal(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", errorContentType(r))
+
+	if err := checkQuery(r, taskListParams); err != nil {
+		h.log.ErrorContext(r.Context(), "invalid query", slog.String("error", err.Error()))
+
+		writeError(w, r, http.StatusUnprocessableEntity, errorValidation, err)
+		return
+	}
+
+	h.exportTasks(w, r, "ics", `inline; filename="tasks.ics"`)
+}
+
+// GetTasksTodotxt serves the tasks matching the list filters as a todo.txt
+// file.
+func (h *TaskHandler) GetTasksTodotxt(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", errorContentType(r))
+
+	if err := checkQuery(r, taskListParams); err != nil {
+		h.log.ErrorContext(r.Context(), "invalid query", slog.String("error", err.Error()))
+
+		writeError(w, r, http.StatusUnprocessableEntity, errorValidation, err)
+		return
+	}
+
+	h.exportTasks(w, r, "todotxt", `inline; filename="todo.txt"`)
+}
+
+func (h *TaskHandler) exportTasks(w http.ResponseWriter, r *http.Request, formatName string, disposition string) {
+	req, err := parseTasksRequest(r.URL.Query())
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "invalid request", slog.String("error", err.Error()))
+
+		writeError(w, r, http.StatusUnprocessableEntity, errorValidation, err)
+		return
+	}
+	req.Page, req.PageSize, req.Cursor = nil, nil, ""
+
+	tasks, err := h.service.IterTasks(r.Context(), req)
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "internal error", slog.String("error", err.Error()))
+
+		writeError(w, r, http.StatusInternalServerError, errorInternal, err)
+		return
+	}
+
+	format := exportFormats[formatName]
+	w.Header().Set("Content-Type", format.contentType)
+	w.Header().Set("Content-Disposition", disposition)
+	w.WriteHeader(http.StatusOK)
+
+	rc := http.NewResponseController(w)
+	flush := func() { _ = rc.Flush() }
+	if err := format.write(w, tasks, flush); err != nil {
+		// The status line is already sent; all we can do is stop.
+		h.log.ErrorContext(r.Context(), "export failed", slog.String("error", err.Error()))
+	}
+}
+
+func writeCsv(w io.Writer, tasks iter.Seq[model.Task], flush func()) error {
+	cw := csv.NewWriter(w)
+	cw.UseCRLF = true
+
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+
+	n := 0
+	for task := range tasks {
+		if err := cw.Write(csvRecord(&task)); err != nil {
+			return err
+		}
+		if n++; n%exportFlushEvery == 0 {
+			cw.Flush()
+			flush()
+		}
+	}
+
+	cw.Flush()
+	flush()
+	return cw.Error()
+}
+
+func csvRecord(task *model.Task) []string {
+	formatTime := func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.UTC().Format(time.RFC3339)
+	}
+
+	return []string{
+		task.Id.String(),
+		task.Title,
+		task.Content,
+		task.Status,
+		task.Priority,
+		joinTags(task.Tags),
+		formatTime(task.DueDate),
+		formatTime(&task.CreatedAt),
+		formatTime(&task.UpdatedAt),
+		formatTime(task.CompletedAt),
+		task.ExternalId,
+	}
+}
+
+// joinTags joins tags with csvTagSeparator, escaping separators and
+// backslashes within tags with a backslash.
+func joinTags(tags []string) string {
+	escaped := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ReplaceAll(tag, `\`, `\\`)
+		escaped = append(escaped, strings.ReplaceAll(tag, csvTagSeparator, `\`+csvTagSeparator))
+	}
+	return strings.Join(escaped, csvTagSeparator)
+}
+
+// splitTags reverses joinTags. Other backslashes, as in tags written by other
+// tools, are kept as they are.
+func splitTags(s string) []string {
+	var tags []string
+	var tag strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s) && (s[i+1] == '\\' || s[i+1] == csvTagSeparator[0]):
+			i++
+			tag.WriteByte(s[i])
+		case s[i] == csvTagSeparator[0]:
+			tags = append(tags, tag.String())
+			tag.Reset()
+		default:
+			tag.WriteByte(s[i])
+		}
+	}
+	return append(tags, tag.String())
+}
+
+func writeNdjson(w io.Writer, tasks iter.Seq[model.Task], flush func()) error {
+	encoder := json2.NewEncoder(w)
+
+	n := 0
+	for task := range tasks {
+		if err := encoder.Encode(task); err != nil {
+			return err
+		}
+		if n++; n%exportFlushEvery == 0 {
+			flush()
+		}
+	}
+
+	flush()
+	return nil
+}
+
+func writeJsonArray(w io.Writer, tasks iter.Seq[model.Task], flush func()) error {
+	return writeArray(w, tasks, flush, func(task *model.Task) any { return task })
+}
+
+func writeTaskwarrior(w io.Writer, tasks iter.Seq[model.Task], flush func()) error {
+	return writeArray(w, tasks, flush, func(task *model.Task) any { return taskwarrior.FromTask(task) })
+}
+
+// writeArray writes a JSON array of the tasks converted by element.
+func writeArray(w io.Writer, tasks iter.Seq[model.Task], flush func(), element func(*model.Task) any) error {
+	if _, err := io.WriteString(w, "["); err != nil {
+		return err
+	}
+
+	n := 0
+	for task := range tasks {
+		data, err := json2.Marshal(element(&task))
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return err
+			}
+		}
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+		if n++; n%exportFlushEvery == 0 {
+			flush()
+		}
+	}
+
+	_, err := io.WriteString(w, "]\n")
+	flush()
+	return err
+}
+
+func writeIcal(w io.Writer, tasks iter.Seq[model.Task], flush func()) error {
+	encoder := ical.NewEncoder(w)
+	encoder.Begin("VCALENDAR")
+	encoder.Property(ical.Property{Name: "VERSION", Value: "2.0"})
+	encoder.Property(ical.Property{Name: "PRODID", Value: ical.ProductId})
+
+	stamp := time.Now()
+	n := 0
+	for task := range tasks {
+		encoder.Component(ical.TodoFromTask(&task, stamp))
+		if n++; n%exportFlushEvery == 0 {
+			if err := encoder.Flush(); err != nil {
+				return err
+			}
+			flush()
+		}
+	}
+
+	encoder.End("VCALENDAR")
+	err := encoder.Flush()
+	flush()
+	return err
+}
+
+func writeTodotxt(w io.Writer, tasks iter.Seq[model.Task], flush func()) error {
+	n := 0
+	for task := range tasks {
+		if _, err := io.WriteString(w, todotxt.Format(&task)+"\n"); err != nil {
+			return err
+		}
+		if n++; n%exportFlushEvery == 0 {
+			flush()
+		}
+	}
+
+	flush()
+	return nil
+}
+
+// writeXlsx writes a workbook with a sheet per status. A zip archive can't
+// be written in parts, so the tasks are collected before anything is sent.
+func writeXlsx(w io.Writer, tasks iter.Seq[model.Task], flush func()) error {
+	columns := make([]xlsx.Column, 0, len(csvHeader))
+	for i, name := range csvHeader {
+		columns = append(columns, xlsx.Column{Name: name, Width: xlsxWidths[i]})
+	}
+
+	workbook := xlsx.NewWorkbook()
+	sheets := make(map[string]*xlsx.Sheet)
+	for _, status := range []string{model.StatusTodo, model.StatusInProgress, model.StatusDone} {
+		sheets[status] = workbook.AddSheet(status, columns)
+	}
+
+	for task := range tasks {
+		sheet, ok := sheets[task.Status]
+		if !ok {
+			continue
+		}
+		sheet.AddRow(
+			xlsx.String(task.Id.String()),
+			xlsx.String(task.Title),
+			xlsx.String(task.Content),
+			xlsx.String(task.Status),
+			xlsx.String(task.Priority),
+			xlsx.String(joinTags(task.Tags)),
+			xlsx.Date(task.DueDate),
+			xlsx.Date(&task.CreatedAt),
+			xlsx.Date(&task.UpdatedAt),
+			xlsx.Date(task.CompletedAt),
+			xlsx.String(task.ExternalId),
+		)
+	}
+
+	err := workbook.Write(w)
+	flush()
+	return err
+}
@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"bufio"
+	"encoding/csv"
+	json2 "encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"simple-tasks/internal/model"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func exportTasks(t *testing.T, handler *TaskHandler, query string) *http.Response {
+	req := httptest.NewRequest(http.MethodGet, "/tasks/export"+query, nil)
+	w := httptest.NewRecorder()
+	handler.ExportTasks(w, req)
+	return w.Result()
+}
+
+func TestExportTasksCsv(t *testing.T) {
+	handler := createTestHandler()
+	addTasks(handler)
+	createTask(t, handler, `{"title":"Отчет, квартал","content":"строка 1\nстрока \"2\"","tags":["финансы","q3"],"dueDate":"2025-10-01T00:00:00Z"}`)
+
+	resp := exportTasks(t, handler, "?format=csv&tag=финансы&sort=title")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status %v, got %v", http.StatusOK, resp.StatusCode)
+	}
+	if contentType := resp.Header.Get("Content-Type"); !strings.HasPrefix(contentType, "text/csv") {
+		t.Errorf("expected csv content type, got %q", contentType)
+	}
+	if disposition := resp.Header.Get("Content-Disposition"); !strings.HasPrefix(disposition, `attachment; filename="tasks-`) || !strings.HasSuffix(disposition, `.csv"`) {
+		t.Errorf("unexpected Content-Disposition %q", disposition)
+	}
+
+	records, err := csv.NewReader(resp.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("error reading csv: %v", err)
+	}
+	if len(records) != 3 || !slices.Equal(records[0], csvHeader) {
+		t.Fatalf("expected header and 2 rows, got %v", records)
+	}
+
+	row := records[1]
+	if row[1] != "Отчет, квартал" || row[2] != "строка 1\nстрока \"2\"" {
+		t.Errorf("expected title and multiline content to round-trip, got %q %q", row[1], row[2])
+	}
+	if row[5] != "финансы;q3" || row[6] != "2025-10-01T00:00:00Z" {
+		t.Errorf("unexpected tags or due date %q %q", row[5], row[6])
+	}
+	if records[2][1] != "Подготовить отчет" {
+		t.Errorf("expected rows in sort order, got %q", records[2][1])
+	}
+}
+
+func TestExportTasksCsvTagRoundTrip(t *testing.T) {
+	handler := createTestHandler()
+	tags := []string{"a;b", `c\d`, `e\;f`, "plain"}
+	createTask(t, handler, `{"title":"Tags","tags":["a;b","c\\d","e\\;f","plain"]}`)
+
+	resp := exportTasks(t, handler, "?format=csv")
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("error reading response body: %v", err)
+	}
+	records, err := csv.NewReader(strings.NewReader(string(body))).ReadAll()
+	if err != nil {
+		t.Fatalf("error reading csv: %v", err)
+	}
+	if cell := records[1][5]; cell != `a\;b;c\\d;e\\\;f;plain` {
+		t.Errorf("expected escaped separators, got %q", cell)
+	}
+
+	imported := createTestHandler()
+	if resp, report := importTasks(t, imported, "?format=csv", "text/csv", string(body)); resp.StatusCode != http.StatusOK || report.Created != 1 {
+		t.Fatalf("expected the export to import, got %v %+v", resp.StatusCode, report)
+	}
+	_, response, _ := getTaskPage(t, imported, "/tasks")
+	if len(response.Tasks) != 1 || !slices.Equal(response.Tasks[0].Tags, tags) {
+		t.Errorf("expected tags %q, got %+v", tags, response.Tasks)
+	}
+}
+
+func TestExportTasksJson(t *testing.T) {
+	handler := createTestHandler()
+	addTasks(handler)
+
+	tests := []struct {
+		name                string
+		query               string
+		expectedStatus      int
+		expectedContentType string
+		expectedCount       int
+	}{
+		{
+			name:                "ndjson",
+			query:               "?format=ndjson&status=todo",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/x-ndjson",
+			expectedCount:       4,
+		},
+		{
+			name:                "json ignores pagination",
+			query:               "?format=json&pageSize=1",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/json",
+			expectedCount:       len(tasks),
+		},
+		{
+			name:                "json without matches",
+			query:               "?format=json&q=nothing",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/json",
+			expectedCount:       0,
+		},
+		{
+			name:           "unknown format",
+			query:          "?format=xml",
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "invalid filter",
+			query:          "?format=json&filter=status",
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := exportTasks(t, handler, tt.query)
+			if resp.StatusCode != tt.expectedStatus {
+				t.Fatalf("expected status %v, got %v", tt.expectedStatus, resp.StatusCode)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			if contentType := resp.Header.Get("Content-Type"); contentType != tt.expectedContentType {
+				t.Errorf("expected content type %q, got %q", tt.expectedContentType, contentType)
+			}
+
+			exported := make([]model.Task, 0)
+			if tt.expectedContentType == "application/x-ndjson" {
+				scanner := bufio.NewScanner(resp.Body)
+				for scanner.Scan() {
+					var task model.Task
+					if err := json2.Unmarshal(scanner.Bytes(), &task); err != nil {
+						t.Fatalf("invalid line %q: %v", scanner.Text(), err)
+					}
+					exported = append(exported, task)
+				}
+			} else if err := json2.NewDecoder(resp.Body).Decode(&exported); err != nil {
+				t.Fatalf("error reading response body: %v", err)
+			}
+
+			if len(exported) != tt.expectedCount {
+				t.Errorf("expected %d tasks, got %d", tt.expectedCount, len(exported))
+			}
+		})
+	}
+}
@@ -0,0 +1,263 @@
+package handler
+
+import (
+	json2 "encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"testing"
+)
+
+func TestGetTasksFilter(t *testing.T) {
+	handler := createTestHandler()
+	addTasks(handler)
+
+	for _, body := range []string{
+		`{"title":"apple","priority":"high","dueDate":"2025-09-15T10:00:00Z"}`,
+		`{"title":"Banana","priority":"normal","tags":["archive"],"dueDate":"2025-09-01T00:00:00Z"}`,
+		`{"title":"cherry","priority":"low","dueDate":"2025-09-10T15:00:00Z"}`,
+	} {
+		createTask(t, handler, body)
+	}
+
+	tests := []struct {
+		name           string
+		filter         string
+		expectedTitles []string
+	}{
+		{
+			name:           "example from the docs",
+			filter:         "priority in (high, normal) and due < 2025-10-01 and not tag:archive",
+			expectedTitles: []string{"apple"},
+		},
+		{
+			name:           "or with parentheses",
+			filter:         "status = done or (tag:покупки and priority > low)",
+			expectedTitles: []string{"Купить машину", "Погулять с собакой"},
+		},
+		{
+			name:           "whole day equality",
+			filter:         "due = 2025-09-10",
+			expectedTitles: []string{"cherry"},
+		},
+		{
+			name:           "whole day upper bound",
+			filter:         "due <= 2025-09-10",
+			expectedTitles: []string{"Banana", "cherry"},
+		},
+		{
+			name:           "relative date",
+			filter:         "has(due) and due < today() and created >= daysAgo(1)",
+			expectedTitles: []string{"apple", "Banana", "cherry"},
+		},
+		{
+			name:           "case-insensitive contains",
+			filter:         `title ~ "купить"`,
+			expectedTitles: []string{"Купить машину", "Купить молоко"},
+		},
+		{
+			name:           "field functions",
+			filter:         "len(tags) >= 3 or lower(title) = banana",
+			expectedTitles: []string{"Banana", "Подготовить отчет", "Сходить в спортзал"},
+		},
+		{
+			name:           "not in",
+			filter:         "status NOT IN (todo, in_progress)",
+			expectedTitles: []string{"Погулять с собакой"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := url.Values{"filter": {tt.filter}, "sort": {"title"}}
+			resp, response, _ := getTaskPage(t, handler, "/tasks?"+query.Encode())
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("expected status %v, got %v", http.StatusOK, resp.StatusCode)
+			}
+
+			actualTitles := make([]string, 0, len(response.Tasks))
+			for _, task := range response.Tasks {
+				actualTitles = append(actualTitles, task.Title)
+			}
+			if !slices.Equal(actualTitles, tt.expectedTitles) {
+				t.Errorf("expected titles %v, got %v", tt.expectedTitles, actualTitles)
+			}
+		})
+	}
+}
+
+func TestGetTasksFilterErrors(t *testing.T) {
+	handler := createTestHandler()
+
+	tests := []struct {
+		name             string
+		filter           string
+		expectedPosition int
+	}{
+		{
+			name:             "unknown enum value",
+			filter:           "priority in (high, urgent)",
+			expectedPosition: 20,
+		},
+		{
+			name:             "unknown field",
+			filter:           "status = done and owner = me",
+			expectedPosition: 19,
+		},
+		{
+			name:             "dangling operator",
+			filter:           "status = done and",
+			expectedPosition: 18,
+		},
+		{
+			name:             "unclosed parenthesis",
+			filter:           "(status = done",
+			expectedPosition: 15,
+		},
+		{
+			name:             "unsupported operator",
+			filter:           "title < b",
+			expectedPosition: 7,
+		},
+		{
+			name:             "invalid date",
+			filter:           "due > 2025-13-01",
+			expectedPosition: 7,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := url.Values{"filter": {tt.filter}}
+			req := httptest.NewRequest(http.MethodGet, "/tasks?"+query.Encode(), nil)
+			w := httptest.NewRecorder()
+			handler.GetTasks(w, req)
+
+			resp := w.Result()
+			if resp.StatusCode != http.StatusUnprocessableEntity {
+				t.Fatalf("expected status %v, got %v", http.StatusUnprocessableEntity, resp.StatusCode)
+			}
+
+			var errResponse ErrorResponse
+			if err := json2.NewDecoder(resp.Body).Decode(&errResponse); err != nil {
+				t.Fatalf("error reading response body: %v", err)
+			}
+			if len(errResponse.Error.Details) != 1 || errResponse.Error.Details[0].Position == nil {
+				t.Fatalf("expected a detail with the error position, got %+v", errResponse.Error.Details)
+			}
+			if position := *errResponse.Error.Details[0].Position; position != tt.expectedPosition {
+				t.Errorf("expected position %v, got %v", tt.expectedPosition, position)
+			}
+		})
+	}
+}
+
+func TestGetTasksQueryFilters(t *testing.T) {
+	handler := createTestHandler()
+	addTasks(handler)
+
+	for _, body := range []string{
+		`{"title":"apple","priority":"high","tags":["fruit","red"],"dueDate":"2025-09-15T10:00:00Z"}`,
+		`{"title":"Banana","status":"done","tags":["fruit"],"dueDate":"2025-09-01T00:00:00Z"}`,
+		`{"title":"cherry","status":"in_progress","tags":["red"],"dueDate":"2099-01-01T00:00:00Z"}`,
+	} {
+		createTask(t, handler, body)
+	}
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedTitles []string
+		expectedStatus int
+	}{
+		{
+			name:           "due range",
+			query:          "?dueAfter=2025-09-01&dueBefore=2025-10-01",
+			expectedTitles: []string{"apple", "Banana"},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "due before timestamp is exclusive",
+			query:          "?dueBefore=2025-09-01T00:00:00Z",
+			expectedTitles: []string{},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "overdue",
+			query:          "?overdue=true",
+			expectedTitles: []string{"apple"},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "has due date with multi-value status",
+			query:          "?hasDueDate=true&status=todo,in_progress",
+			expectedTitles: []string{"apple", "cherry"},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "repeated priority",
+			query:          "?priority=high&priority=normal&hasDueDate=false&status=todo",
+			expectedTitles: []string{"Погулять с друзьями", "Сходить в спортзал"},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "all tags",
+			query:          "?tag=fruit&tag=red&tagMode=all",
+			expectedTitles: []string{"apple"},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "none of the tags",
+			query:          "?tag=fruit&tag=red&tag=покупки&tag=прогулка&tagMode=none",
+			expectedTitles: []string{"Подготовить отчет", "Сходить в спортзал", "Уборка дома"},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "created and updated bounds",
+			query:          "?createdAfter=2000-01-01&createdBefore=2099-01-01&updatedSince=2000-01-01T00:00:00Z&tag=red",
+			expectedTitles: []string{"apple", "cherry"},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "invalid date",
+			query:          "?dueBefore=01.10.2025",
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "invalid status",
+			query:          "?status=todo,archived",
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "invalid tag mode",
+			query:          "?tag=red&tagMode=some",
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "invalid boolean",
+			query:          "?overdue=yes",
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, response, _ := getTaskPage(t, handler, "/tasks"+tt.query+"&sort=title")
+			if resp.StatusCode != tt.expectedStatus {
+				t.Fatalf("expected status %v, got %v", tt.expectedStatus, resp.StatusCode)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			actualTitles := make([]string, 0, len(response.Tasks))
+			for _, task := range response.Tasks {
+				actualTitles = append(actualTitles, task.Title)
+			}
+			if !slices.Equal(actualTitles, tt.expectedTitles) {
+				t.Errorf("expected titles %v, got %v", tt.expectedTitles, actualTitles)
+			}
+		})
+	}
+}
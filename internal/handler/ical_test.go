@@ -0,0 +1,228 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func getTasksIcal(t *testing.T, handler *TaskHandler, query string) (*http.Response, string) {
+	req := httptest.NewRequest(http.MethodGet, "/tasks.ics"+query, nil)
+	w := httptest.NewRecorder()
+	handler.GetTasksIcal(w, req)
+
+	resp := w.Result()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("error reading response body: %v", err)
+	}
+	return resp, string(body)
+}
+
+func importIcal(t *testing.T, handler *TaskHandler, body string) (*http.Response, ImportReport) {
+	return importTasks(t, handler, "", "text/calendar", body)
+}
+
+func TestGetTasksIcal(t *testing.T) {
+	handler := createTestHandler()
+
+	task := createTask(t, handler, `{"title":"Позвонить; маме, срочно","content":"line 1\nline 2","priority":"high",`+
+		`"tags":["семья","звонки"],"dueDate":"2025-10-01T09:30:00Z","status":"in_progress"}`)
+	createTask(t, handler, `{"title":"`+strings.Repeat("длинный заголовок ", 8)+`","status":"done"}`)
+	createTask(t, handler, `{"title":"other","tags":["work"]}`)
+
+	resp, body := getTasksIcal(t, handler, "?tag=семья&tag=work&tagMode=none")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status %v, got %v", http.StatusOK, resp.StatusCode)
+	}
+	if contentType := resp.Header.Get("Content-Type"); contentType != "text/calendar; charset=utf-8" {
+		t.Errorf("expected calendar content type, got %q", contentType)
+	}
+
+	for _, line := range strings.SplitAfter(body, "\r\n") {
+		if len(strings.TrimSuffix(line, "\r\n")) > 75 {
+			t.Errorf("expected folded lines, got %d octets: %q", len(line), line)
+		}
+		if line != "" && !strings.HasSuffix(line, "\r\n") {
+			t.Errorf("expected CRLF line endings, got %q", line)
+		}
+	}
+	if strings.Count(body, "BEGIN:VTODO") != 1 || !strings.Contains(body, "STATUS:COMPLETED") {
+		t.Errorf("expected only the done task, got\n%s", body)
+	}
+
+	_, body = getTasksIcal(t, handler, "?tag=семья")
+	for _, expected := range []string{
+		"BEGIN:VCALENDAR\r\nVERSION:2.0\r\n",
+		"UID:" + task.Id.String() + "\r\n",
+		`SUMMARY:Позвонить\; маме\, срочно` + "\r\n",
+		`DESCRIPTION:line 1\nline 2` + "\r\n",
+		"DUE:20251001T093000Z\r\n",
+		"STATUS:IN-PROCESS\r\n",
+		"PRIORITY:1\r\n",
+		"CATEGORIES:семья,звонки\r\n",
+		"END:VTODO\r\nEND:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("expected %q in\n%s", expected, body)
+		}
+	}
+
+	resp, _ = getTasksIcal(t, handler, "?status=archived")
+	if resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("expected status %v, got %v", http.StatusUnprocessableEntity, resp.StatusCode)
+	}
+}
+
+func TestImportIcal(t *testing.T) {
+	handler := createTestHandler()
+
+	body := "BEGIN:VCALENDAR\r\n" +
+		"VERSION:2.0\r\n" +
+		"PRODID:-//other//EN\r\n" +
+		"BEGIN:VTODO\r\n" +
+		"UID:remote-1@example.com\r\n" +
+		"SUMMARY:Купить\\, наконец\\, молоко\r\n" +
+		"DESCRIPTION:две строки\\nвторая\r\n" +
+		"DUE;VALUE=DATE:20251001\r\n" +
+		"STATUS:NEEDS-ACTION\r\n" +
+		"PRIORITY:5\r\n" +
+		"CATEGORIES:Shopping,Дом\r\n" +
+		"CATEGORIES:urgent\r\n" +
+		"END:VTODO\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:event-1\r\n" +
+		"SUMMARY:ignored\r\n" +
+		"END:VEVENT\r\n" +
+		"BEGIN:VTODO\r\n" +
+		"UID:remote-2@example.com\r\n" +
+		"SUMMARY:очень длинный заголовок, который клиент свернул на несколько \r\n" +
+		" строк\r\n" +
+		"DUE;TZID=Europe/Moscow:20251002T120000\r\n" +
+		"STATUS:COMPLETED\r\n" +
+		"END:VTODO\r\n" +
+		"BEGIN:VTODO\r\n" +
+		"UID:remote-3@example.com\r\n" +
+		"SUMMARY:bad priority\r\n" +
+		"PRIORITY:high\r\n" +
+		"END:VTODO\r\n" +
+		"BEGIN:VTODO\r\n" +
+		"UID:remote-4@example.com\r\n" +
+		"END:VTODO\r\n" +
+		"END:VCALENDAR\r\n"
+
+	resp, report := importIcal(t, handler, body)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status %v, got %v", http.StatusOK, resp.StatusCode)
+	}
+	expected := []string{"created", "created", "failed", "failed"}
+	if statuses := rowStatuses(report); !slices.Equal(statuses, expected) {
+		t.Fatalf("expected statuses %v, got %v", expected, statuses)
+	}
+	if row := report.Rows[0].Row; row != 4 {
+		t.Errorf("expected the line of BEGIN:VTODO, got %d", row)
+	}
+	if field := report.Rows[2].Errors[0].Field; field != "PRIORITY" {
+		t.Errorf("expected priority error, got %q", field)
+	}
+
+	_, response, _ := getTaskPage(t, handler, "/tasks?sort=title")
+	if len(response.Tasks) != 2 {
+		t.Fatalf("expected 2 tasks, got %d", len(response.Tasks))
+	}
+	first, second := response.Tasks[0], response.Tasks[1]
+	if first.Title != "Купить, наконец, молоко" || first.Content != "две строки\nвторая" {
+		t.Errorf("expected unescaped text, got %q and %q", first.Title, first.Content)
+	}
+	if first.Priority != "normal" || first.Status != "todo" || first.ExternalId != "remote-1@example.com" {
+		t.Errorf("unexpected task %+v", first)
+	}
+	if !slices.Equal(first.Tags, []string{"shopping", "дом", "urgent"}) {
+		t.Errorf("expected normalized categories, got %v", first.Tags)
+	}
+	if first.DueDate == nil || first.DueDate.Format("2006-01-02T15:04:05Z07:00") != "2025-10-01T00:00:00Z" {
+		t.Errorf("expected date due, got %v", first.DueDate)
+	}
+	if !strings.HasSuffix(second.Title, "на несколько строк") || second.Status != "done" {
+		t.Errorf("expected unfolded completed task, got %+v", second)
+	}
+	if second.DueDate == nil || second.DueDate.Format("2006-01-02T15:04:05Z07:00") != "2025-10-02T09:00:00Z" {
+		t.Errorf("expected due date converted from Moscow time, got %v", second.DueDate)
+	}
+
+	_, report = importIcal(t, handler, strings.Replace(body, "PRIORITY:5", "PRIORITY:1", 1))
+	expected = []string{"updated", "skipped", "failed", "failed"}
+	if statuses := rowStatuses(report); !slices.Equal(statuses, expected) {
+		t.Errorf("expected statuses %v on re-import, got %v", expected, statuses)
+	}
+}
+
+func TestImportIcalRoundTrip(t *testing.T) {
+	handler := createTestHandler()
+	addTasks(handler)
+
+	_, before, _ := getTaskPage(t, handler, "/tasks?pageSize=100")
+	_, feed := getTasksIcal(t, handler, "")
+
+	resp, report := importIcal(t, handler, feed)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status %v, got %v", http.StatusOK, resp.StatusCode)
+	}
+	if report.Skipped != len(before.Tasks) || report.Created != 0 {
+		t.Errorf("expected every task to be skipped, got %+v", report)
+	}
+
+	edited := strings.Replace(feed, "SUMMARY:Купить молоко", "SUMMARY:Купить кефир", 1)
+	_, report = importIcal(t, handler, edited)
+	if report.Updated != 1 || report.Created != 0 {
+		t.Errorf("expected a single update, got %+v", report)
+	}
+
+	_, after, _ := getTaskPage(t, handler, "/tasks?pageSize=100")
+	if len(after.Tasks) != len(before.Tasks) {
+		t.Errorf("expected %d tasks after re-import, got %d", len(before.Tasks), len(after.Tasks))
+	}
+}
+
+func TestImportIcalErrors(t *testing.T) {
+	handler := createTestHandler()
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+	}{
+		{
+			name:           "missing end",
+			body:           "BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nSUMMARY:x\r\nEND:VCALENDAR\r\n",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "not a calendar",
+			body:           "BEGIN:VCARD\r\nFN:x\r\nEND:VCARD\r\n",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid content line",
+			body:           "BEGIN:VCALENDAR\r\nnonsense\r\nEND:VCALENDAR\r\n",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "empty calendar",
+			body:           "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nEND:VCALENDAR\r\n",
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, _ := importIcal(t, handler, tt.body)
+			if resp.StatusCode != tt.expectedStatus {
+				t.Errorf("expected status %v, got %v", tt.expectedStatus, resp.StatusCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,650 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	json2 "encoding/json"
+	"errors"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/google/uuid"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"simple-tasks/internal/codec"
+	"simple-tasks/internal/ical"
+	"simple-tasks/internal/model"
+	"simple-tasks/internal/taskwarrior"
+	"simple-tasks/internal/todotxt"
+	"strconv"
+	"strings"
+)
+
+const (
+	maxImportBytes = 32 << 20
+	maxImportRows  = 10000
+	// maxLineBytes is the length limit of a line of NDJSON and todo.txt.
+	maxLineBytes = 1 << 20
+)
+
+// importColumns are the task fields a CSV column can be mapped to.
+var importColumns = []string{"externalId", "title", "content", "status", "priority", "tags", "dueDate"}
+
+// todotxtFields are the task fields todo.txt has; the content is not one of
+// them.
+var todotxtFields = []string{model.FieldTitle, model.FieldStatus, model.FieldPriority, model.FieldTags, model.FieldDueDate}
+
+type ImportRowResult struct {
+	// Row is the line number for CSV and NDJSON, and the 1-based element
+	// index for a JSON array.
+	Row        int           `json:"row"`
+	Status     string        `json:"status"`
+	TaskId     *uuid.UUID    `json:"taskId,omitempty"`
+	ExternalId string        `json:"externalId,omitempty"`
+	Errors     []ErrorDetail `json:"errors,omitempty"`
+	// Warnings list what the task doesn't keep of the row, such as
+	// Taskwarrior attributes without a task field.
+	Warnings []ErrorDetail `json:"warnings,omitempty"`
+}
+
+type ImportReport struct {
+	DryRun  bool              `json:"dryRun"`
+	Created int               `json:"created"`
+	Updated int               `json:"updated"`
+	Skipped int               `json:"skipped"`
+	Failed  int               `json:"failed"`
+	Rows    []ImportRowResult `json:"rows"`
+}
+
+type importRow struct {
+	row  int
+	task model.Task
+	// errors are problems found while decoding the row.
+	errors []ErrorDetail
+	// warnings are the parts of the row that the task doesn't keep.
+	warnings []ErrorDetail
+	// fields are the task fields the row carries, nil for all of them.
+	// Updates keep the other fields of the existing task.
+	fields []string
+	// skip marks rows that are read but not imported, such as deleted
+	// Taskwarrior tasks.
+	skip bool
+}
+
+// importSyntaxError is a malformed document that can't be read any further.
+type importSyntaxError struct {
+	err error
+}
+
+func (e *importSyntaxError) Error() string { return e.err.Error() }
+func (e *importSyntaxError) Unwrap() error { return e.err }
+
+// ImportTasks loads tasks from a CSV, JSON array, NDJSON, iCalendar,
+// todo.txt or Taskwarrior export body, or a YAML, XML or MessagePack list.
+// Every row is validated like a created task and reported on its own, so one
+// bad row doesn't fail the whole import. Updates from CSV and todo.txt only
+// set the fields the import has columns for.
+func (h *TaskHandler) ImportTasks(w http.ResponseWriter, r *http.Request) {
+	if !negotiate(w, r) {
+		return
+	}
+
+	if err := checkQuery(r, importParams, []string{"format"}); err != nil {
+		h.log.ErrorContext(r.Context(), "invalid query", slog.String("error", err.Error()))
+
+		writeError(w, r, http.StatusUnprocessableEntity, errorValidation, err)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = importFormat(r.Header.Get("Content-Type"))
+	}
+	if err := validate.Var(format, "oneof=csv json ndjson ics todotxt taskwarrior"); err != nil {
+		h.log.ErrorContext(r.Context(), "invalid format", slog.String("error", err.Error()))
+
+		writeError(w, r, http.StatusUnprocessableEntity, errorValidation, err)
+		return
+	}
+
+	h.importTasks(w, r, format)
+}
+
+// ImportIcal imports the VTODO entries of an .ics file. UIDs round-trip: a
+// feed exported by GetTasksIcal updates the same tasks when imported again.
+func (h *TaskHandler) ImportIcal(w http.ResponseWriter, r *http.Request) {
+	if !negotiate(w, r) {
+		return
+	}
+
+	if err := checkQuery(r, importParams); err != nil {
+		h.log.ErrorContext(r.Context(), "invalid query", slog.String("error", err.Error()))
+
+		writeError(w, r, http.StatusUnprocessableEntity, errorValidation, err)
+		return
+	}
+
+	h.importTasks(w, r, model.ImportFormatIcs)
+}
+
+func (h *TaskHandler) importTasks(w http.ResponseWriter, r *http.Request, format string) {
+	query := r.URL.Query()
+
+	dryRun := false
+	if dryRunStr := query.Get("dryRun"); dryRunStr != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(dryRunStr); err != nil {
+			h.log.ErrorContext(r.Context(), "invalid dryRun", slog.String("error", err.Error()))
+
+			writeError(w, r, http.StatusUnprocessableEntity, errorValidation, err)
+			return
+		}
+	}
+
+	mapping, err := parseImportMapping(query.Get("mapping"))
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "invalid mapping", slog.String("error", err.Error()))
+
+		writeError(w, r, http.StatusUnprocessableEntity, errorValidation, err)
+		return
+	}
+
+	trans := requestTranslator(r)
+	var body io.Reader = http.MaxBytesReader(w, r.Body, maxImportBytes)
+	var rows []importRow
+	switch format {
+	case model.ImportFormatCsv:
+		rows, err = readCsvRows(body, mapping, trans)
+	case model.ImportFormatJson:
+		if body, err = transcodeImport(body, r.Header.Get("Content-Type")); err == nil {
+			rows, err = readJsonRows(body)
+		}
+	case model.ImportFormatNdjson:
+		rows, err = readNdjsonRows(body)
+	case model.ImportFormatIcs:
+		rows, err = readIcalRows(body)
+	case model.ImportFormatTodotxt:
+		rows, err = readTodotxtRows(body)
+	case model.ImportFormatTaskwarrior:
+		rows, err = readTaskwarriorRows(body)
+	}
+
+	var maxBytesErr *http.MaxBytesError
+	var syntaxErr *importSyntaxError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		h.log.ErrorContext(r.Context(), "import too large", slog.String("error", err.Error()))
+
+		writeError(w, r, http.StatusRequestEntityTooLarge, errorPayloadTooLarge, err)
+		return
+	case errors.As(err, &syntaxErr) && (format == model.ImportFormatJson || format == model.ImportFormatNdjson || format == model.ImportFormatTaskwarrior):
+		h.log.ErrorContext(r.Context(), "invalid json", slog.String("error", err.Error()))
+
+		writeError(w, r, http.StatusBadRequest, errorInvalidJson, err)
+		return
+	case errors.As(err, &syntaxErr):
+		h.log.ErrorContext(r.Context(), "invalid document", slog.String("error", err.Error()))
+
+		writeError(w, r, http.StatusBadRequest, errorBadRequest, err)
+		return
+	case err != nil:
+		h.log.ErrorContext(r.Context(), "invalid import", slog.String("error", err.Error()))
+
+		writeError(w, r, http.StatusUnprocessableEntity, errorValidation, err)
+		return
+	}
+
+	report := &ImportReport{
+		DryRun: dryRun,
+		Rows:   make([]ImportRowResult, 0, len(rows)),
+	}
+	seen := make(map[string]int)
+
+	for _, row := range rows {
+		result := ImportRowResult{
+			Row:        row.row,
+			ExternalId: row.task.ExternalId,
+			Errors:     row.errors,
+			Warnings:   row.warnings,
+		}
+		if row.skip && len(result.Errors) == 0 {
+			result.Status = model.ImportSkipped
+			report.Skipped++
+			report.Rows = append(report.Rows, result)
+			continue
+		}
+
+		if len(result.Errors) == 0 {
+			if err := validate.Struct(row.task); err != nil {
+				result.Errors = validationDetails(trans, err)
+			}
+		}
+		if first, ok := seen[row.task.ExternalId]; ok && len(result.Errors) == 0 {
+			result.Errors = []ErrorDetail{{
+				Field:   "externalId",
+				Rule:    "unique",
+				Message: localize(trans, "external id {0} already appears in row {1}", strconv.Quote(row.task.ExternalId), strconv.Itoa(first)),
+			}}
+		}
+
+		if len(result.Errors) == 0 {
+			task, status, err := h.service.ImportTaskFields(r.Context(), &row.task, row.fields, dryRun)
+			if err != nil {
+				result.Errors = []ErrorDetail{{Rule: "internal", Message: errorMessage(trans, errorInternal, err)}}
+			} else {
+				result.Status = status
+				if task.Id != uuid.Nil {
+					result.TaskId = &task.Id
+				}
+			}
+		}
+		if row.task.ExternalId != "" {
+			if _, ok := seen[row.task.ExternalId]; !ok {
+				seen[row.task.ExternalId] = row.row
+			}
+		}
+
+		switch {
+		case len(result.Errors) > 0:
+			result.Status = model.ImportFailed
+			report.Failed++
+		case result.Status == model.ImportCreated:
+			report.Created++
+		case result.Status == model.ImportUpdated:
+			report.Updated++
+		case result.Status == model.ImportSkipped:
+			report.Skipped++
+		}
+		report.Rows = append(report.Rows, result)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = encode(w, report)
+}
+
+func importFormat(contentType string) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "text/csv":
+		return model.ImportFormatCsv
+	case "application/x-ndjson":
+		return model.ImportFormatNdjson
+	case "text/calendar":
+		return model.ImportFormatIcs
+	case "text/plain":
+		return model.ImportFormatTodotxt
+	case "application/json", "":
+		return model.ImportFormatJson
+	}
+	if _, ok := codec.Lookup(mediaType); ok {
+		return model.ImportFormatJson
+	}
+	return mediaType
+}
+
+// transcodeImport converts a YAML, XML or MessagePack list of tasks to the
+// JSON array the import reads.
+func transcodeImport(body io.Reader, contentType string) (io.Reader, error) {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	c, ok := codec.Lookup(mediaType)
+	if !ok || c == codec.JSON {
+		return body, nil
+	}
+
+	data, err := codec.Transcode(c, body, []model.Task(nil))
+	if err != nil {
+		return nil, jsonError(err)
+	}
+	return bytes.NewReader(data), nil
+}
+
+// parseImportMapping reads a CSV column mapping such as
+// "title:Name,dueDate:Deadline", keyed by task field. Unmapped fields are
+// read from the column with the field's own name.
+func parseImportMapping(value string) (map[string]string, error) {
+	mapping := make(map[string]string)
+	for _, part := range multiValue([]string{value}) {
+		field, column, ok := strings.Cut(part, ":")
+		if !ok || strings.TrimSpace(column) == "" {
+			return nil, newRequestError("invalid mapping {0}, expected field:column", strconv.Quote(part))
+		}
+		field = strings.TrimSpace(field)
+		if err := validate.Var(field, "oneof="+strings.Join(importColumns, " ")); err != nil {
+			return nil, newRequestError("invalid mapping {0}, unknown field {1}", strconv.Quote(part), strconv.Quote(field))
+		}
+		mapping[field] = strings.TrimSpace(column)
+	}
+	return mapping, nil
+}
+
+func readCsvRows(body io.Reader, mapping map[string]string, trans ut.Translator) ([]importRow, error) {
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, newRequestError("csv has no header row")
+	} else if err != nil {
+		return nil, csvError(err)
+	}
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+
+	columns := make(map[string]int)
+	for _, field := range importColumns {
+		name := field
+		if mapped, ok := mapping[field]; ok {
+			name = mapped
+		}
+		index := -1
+		for i, column := range header {
+			if strings.EqualFold(strings.TrimSpace(column), name) {
+				index = i
+				break
+			}
+		}
+		if index < 0 && mapping[field] != "" {
+			return nil, newRequestError("column {0} mapped to {1} is missing", strconv.Quote(name), field)
+		}
+		if index >= 0 {
+			columns[field] = index
+		}
+	}
+	if _, ok := columns["title"]; !ok {
+		return nil, newRequestError("csv has no title column")
+	}
+	fields := make([]string, 0, len(columns))
+	for field := range columns {
+		fields = append(fields, field)
+	}
+
+	rows := make([]importRow, 0)
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, csvError(err)
+		}
+		if len(rows) == maxImportRows {
+			return nil, newRequestError("import is limited to {0}", count(translations.GetFallback(), "row", strconv.Itoa(maxImportRows)))
+		}
+
+		line, _ := reader.FieldPos(0)
+		row := csvRow(trans, line, record, columns)
+		row.fields = fields
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+func csvError(err error) error {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return err
+	}
+	return &importSyntaxError{err: err}
+}
+
+func csvRow(trans ut.Translator, line int, record []string, columns map[string]int) importRow {
+	value := func(field string) string {
+		if i, ok := columns[field]; ok && i < len(record) {
+			return record[i]
+		}
+		return ""
+	}
+
+	row := importRow{
+		row: line,
+		task: model.Task{
+			ExternalId: strings.TrimSpace(value("externalId")),
+			Title:      value("title"),
+			Content:    value("content"),
+			Status:     strings.TrimSpace(value("status")),
+			Priority:   strings.TrimSpace(value("priority")),
+		},
+	}
+
+	if tags := strings.TrimSpace(value("tags")); tags != "" {
+		row.task.Tags = model.NormalizeTags(splitTags(tags))
+	}
+
+	if dueDate := strings.TrimSpace(value("dueDate")); dueDate != "" {
+		t, err := model.ParseDate(dueDate)
+		if err != nil {
+			row.errors = append(row.errors, ErrorDetail{
+				Field:   "dueDate",
+				Rule:    "date",
+				Message: localize(trans, "invalid date {0}, expected YYYY-MM-DD or RFC 3339", strconv.Quote(dueDate)),
+			})
+		} else {
+			row.task.DueDate = &t
+		}
+	}
+
+	return row
+}
+
+func readJsonRows(body io.Reader) ([]importRow, error) {
+	return readJsonArray(body, taskRow)
+}
+
+func readNdjsonRows(body io.Reader) ([]importRow, error) {
+	return readJsonLines(body, taskRow)
+}
+
+func taskRow(n int, data []byte) importRow {
+	row := importRow{row: n}
+	if err := json2.Unmarshal(data, &row.task); err != nil {
+		row.errors = []ErrorDetail{jsonDetail(err)}
+	}
+	return row
+}
+
+// readJsonArray decodes every element of a JSON array into a row numbered by
+// its 1-based index.
+func readJsonArray(body io.Reader, decode func(n int, data []byte) importRow) ([]importRow, error) {
+	decoder := json2.NewDecoder(body)
+
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, jsonError(err)
+	}
+	if delim, ok := token.(json2.Delim); !ok || delim != '[' {
+		return nil, &importSyntaxError{err: newRequestError("expected a JSON array of tasks")}
+	}
+
+	rows := make([]importRow, 0)
+	for decoder.More() {
+		if len(rows) == maxImportRows {
+			return nil, newRequestError("import is limited to {0}", count(translations.GetFallback(), "row", strconv.Itoa(maxImportRows)))
+		}
+
+		var data json2.RawMessage
+		if err := decoder.Decode(&data); err != nil {
+			return nil, jsonError(err)
+		}
+		rows = append(rows, decode(len(rows)+1, data))
+	}
+
+	if _, err := decoder.Token(); err != nil {
+		return nil, jsonError(err)
+	}
+
+	return rows, nil
+}
+
+// readJsonLines decodes every non-blank line into a row numbered by the
+// line.
+func readJsonLines(body io.Reader, decode func(n int, data []byte) importRow) ([]importRow, error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineBytes)
+
+	rows := make([]importRow, 0)
+	line := 0
+	for scanner.Scan() {
+		line++
+		data := strings.TrimSpace(scanner.Text())
+		if data == "" {
+			continue
+		}
+		if len(rows) == maxImportRows {
+			return nil, newRequestError("import is limited to {0}", count(translations.GetFallback(), "row", strconv.Itoa(maxImportRows)))
+		}
+
+		rows = append(rows, decode(line, []byte(data)))
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, jsonError(err)
+	}
+
+	return rows, nil
+}
+
+// readIcalRows reads the VTODO components of a calendar; a row is the line of
+// its BEGIN:VTODO. Other components, such as events, are ignored.
+func readIcalRows(body io.Reader) ([]importRow, error) {
+	calendar, err := ical.Parse(body)
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return nil, err
+	} else if err != nil {
+		return nil, &importSyntaxError{err: err}
+	}
+	if calendar.Name != "VCALENDAR" {
+		return nil, &importSyntaxError{err: newRequestError("expected VCALENDAR, got {0}", calendar.Name)}
+	}
+
+	rows := make([]importRow, 0)
+	for _, c := range calendar.Components {
+		if c.Name != "VTODO" {
+			continue
+		}
+		if len(rows) == maxImportRows {
+			return nil, newRequestError("import is limited to {0}", count(translations.GetFallback(), "row", strconv.Itoa(maxImportRows)))
+		}
+
+		row := importRow{row: c.Line}
+		task, err := ical.TaskFromTodo(c)
+		row.task = task
+
+		var propertyErr *ical.PropertyError
+		if errors.As(err, &propertyErr) {
+			row.errors = []ErrorDetail{{Field: propertyErr.Property, Rule: "ical", Message: propertyErr.Msg}}
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// readTodotxtRows reads a todo.txt file, one task per non-blank line.
+func readTodotxtRows(body io.Reader) ([]importRow, error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineBytes)
+
+	rows := make([]importRow, 0)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+		if line == 1 {
+			text = strings.TrimPrefix(text, "\ufeff")
+		}
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		if len(rows) == maxImportRows {
+			return nil, newRequestError("import is limited to {0}", count(translations.GetFallback(), "row", strconv.Itoa(maxImportRows)))
+		}
+
+		row := importRow{row: line, fields: todotxtFields}
+		task, err := todotxt.Parse(text)
+		row.task = task
+
+		var tokenErr *todotxt.TokenError
+		if errors.As(err, &tokenErr) {
+			row.errors = []ErrorDetail{{Field: tokenErr.Key, Rule: "todotxt", Message: tokenErr.Msg}}
+		}
+		rows = append(rows, row)
+	}
+
+	if err := scanner.Err(); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return nil, err
+		}
+		return nil, &importSyntaxError{err: err}
+	}
+
+	return rows, nil
+}
+
+// readTaskwarriorRows reads the output of `task export`: a JSON array, or
+// one task per line as written before Taskwarrior 2.4.
+func readTaskwarriorRows(body io.Reader) ([]importRow, error) {
+	reader := bufio.NewReader(body)
+	for {
+		b, err := reader.Peek(1)
+		if err != nil {
+			break
+		}
+		if b[0] == '[' {
+			return readJsonArray(reader, taskwarriorRow)
+		}
+		if !strings.ContainsRune(" \t\r\n", rune(b[0])) {
+			break
+		}
+		_, _ = reader.ReadByte()
+	}
+
+	return readJsonLines(reader, taskwarriorRow)
+}
+
+// taskwarriorRow decodes a Taskwarrior task; deleted tasks are skipped.
+func taskwarriorRow(n int, data []byte) importRow {
+	row := importRow{row: n}
+	// Objects on their own line were separated by commas.
+	data = []byte(strings.TrimSuffix(string(data), ","))
+
+	var tw taskwarrior.Task
+	if err := json2.Unmarshal(data, &tw); err != nil {
+		row.errors = []ErrorDetail{jsonDetail(err)}
+		return row
+	}
+	task, err := tw.ToTask()
+	row.task = task
+	row.skip = tw.Status == taskwarrior.StatusDeleted
+	for _, loss := range tw.Losses() {
+		row.warnings = append(row.warnings, ErrorDetail{Field: loss.Attribute, Rule: "lossy", Message: loss.Msg})
+	}
+
+	var attributeErr *taskwarrior.AttributeError
+	if errors.As(err, &attributeErr) {
+		row.errors = []ErrorDetail{{Field: attributeErr.Attribute, Rule: "taskwarrior", Message: attributeErr.Msg}}
+	}
+	return row
+}
+
+func jsonError(err error) error {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return err
+	}
+	return &importSyntaxError{err: err}
+}
+
+// jsonDetail describes a row that isn't a task. JSON rows are decoded without
+// the request's translator, so the message is in English.
+func jsonDetail(err error) ErrorDetail {
+	english := translations.GetFallback()
+	var typeErr *json2.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return ErrorDetail{Field: typeErr.Field, Rule: "type", Message: errorMessage(english, errorInvalidJson, err)}
+	}
+	return ErrorDetail{Rule: "json", Message: errorMessage(english, errorInvalidJson, err)}
+}
@@ -0,0 +1,299 @@
+package handler
+
+import (
+	json2 "encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func importTasks(t *testing.T, handler *TaskHandler, query string, contentType string, body string) (*http.Response, ImportReport) {
+	req := httptest.NewRequest(http.MethodPost, "/tasks/import"+query, strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	w := httptest.NewRecorder()
+	handler.ImportTasks(w, req)
+
+	resp := w.Result()
+	var report ImportReport
+	if resp.StatusCode == http.StatusOK {
+		if err := json2.NewDecoder(resp.Body).Decode(&report); err != nil {
+			t.Fatalf("error reading response body: %v", err)
+		}
+	}
+	return resp, report
+}
+
+func rowStatuses(report ImportReport) []string {
+	statuses := make([]string, 0, len(report.Rows))
+	for _, row := range report.Rows {
+		statuses = append(statuses, row.Status)
+	}
+	return statuses
+}
+
+func TestImportTasksCsv(t *testing.T) {
+	handler := createTestHandler()
+
+	body := "Key,Name,Notes,State,Labels,Deadline\r\n" +
+		"A-1,Первая,\"строка 1\nстрока 2\",in_progress,Work;Urgent,2025-10-01\r\n" +
+		"A-2,Вторая,,archived,,\r\n" +
+		"A-3,Третья,,,,01.10.2025\r\n" +
+		"A-4,,,,,\r\n" +
+		"A-1,Дубликат,,,,\r\n" +
+		",Без ключа,,done,,2025-10-01T12:00:00Z\r\n"
+	mapping := url.Values{"mapping": {"externalId:Key,title:Name,content:Notes,status:State,tags:Labels,dueDate:Deadline"}}
+
+	resp, report := importTasks(t, handler, "?"+mapping.Encode(), "text/csv", body)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status %v, got %v", http.StatusOK, resp.StatusCode)
+	}
+
+	expected := []string{"created", "failed", "failed", "failed", "failed", "created"}
+	if statuses := rowStatuses(report); !slices.Equal(statuses, expected) {
+		t.Fatalf("expected statuses %v, got %v", expected, statuses)
+	}
+	if report.Created != 2 || report.Failed != 4 {
+		t.Errorf("expected 2 created and 4 failed, got %+v", report)
+	}
+
+	rows := make([]int, 0, len(report.Rows))
+	for _, row := range report.Rows {
+		rows = append(rows, row.Row)
+	}
+	if !slices.Equal(rows, []int{2, 4, 5, 6, 7, 8}) {
+		t.Errorf("expected line numbers, got %v", rows)
+	}
+	for _, i := range []int{1, 2, 3, 4} {
+		if len(report.Rows[i].Errors) == 0 {
+			t.Errorf("expected error details for row %d", report.Rows[i].Row)
+		}
+	}
+	if field := report.Rows[1].Errors[0].Field; field != "status" {
+		t.Errorf("expected status error, got %q", field)
+	}
+
+	_, response, _ := getTaskPage(t, handler, "/tasks?q=Первая")
+	if len(response.Tasks) != 1 {
+		t.Fatalf("expected imported task, got %d", len(response.Tasks))
+	}
+	task := response.Tasks[0]
+	if task.Content != "строка 1\nстрока 2" || !slices.Equal(task.Tags, []string{"work", "urgent"}) ||
+		task.DueDate == nil || task.ExternalId != "A-1" {
+		t.Errorf("unexpected imported task %+v", task)
+	}
+}
+
+func TestImportTasksUpsert(t *testing.T) {
+	handler := createTestHandler()
+
+	tests := []struct {
+		name           string
+		query          string
+		body           string
+		expectedStatus string
+		expectedTasks  int
+	}{
+		{
+			name:           "dry run",
+			query:          "?dryRun=true",
+			body:           `[{"externalId":"A","title":"One"}]`,
+			expectedStatus: "created",
+			expectedTasks:  0,
+		},
+		{
+			name:           "create",
+			body:           `[{"externalId":"A","title":"One"}]`,
+			expectedStatus: "created",
+			expectedTasks:  1,
+		},
+		{
+			name:           "unchanged",
+			body:           `[{"externalId":"A","title":"One"}]`,
+			expectedStatus: "skipped",
+			expectedTasks:  1,
+		},
+		{
+			name:           "update",
+			body:           `[{"externalId":"A","title":"One, renamed","status":"done"}]`,
+			expectedStatus: "updated",
+			expectedTasks:  1,
+		},
+	}
+
+	var taskId string
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, report := importTasks(t, handler, tt.query, "application/json", tt.body)
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("expected status %v, got %v", http.StatusOK, resp.StatusCode)
+			}
+			if statuses := rowStatuses(report); !slices.Equal(statuses, []string{tt.expectedStatus}) {
+				t.Fatalf("expected status %v, got %v", tt.expectedStatus, statuses)
+			}
+
+			row := report.Rows[0]
+			if report.DryRun {
+				if row.TaskId != nil {
+					t.Errorf("expected no task id on dry run, got %v", row.TaskId)
+				}
+			} else if taskId == "" {
+				taskId = row.TaskId.String()
+			} else if row.TaskId.String() != taskId {
+				t.Errorf("expected task %v to be updated, got %v", taskId, row.TaskId)
+			}
+
+			_, response, _ := getTaskPage(t, handler, "/tasks")
+			if len(response.Tasks) != tt.expectedTasks {
+				t.Errorf("expected %d tasks, got %d", tt.expectedTasks, len(response.Tasks))
+			}
+		})
+	}
+
+	_, response, _ := getTaskPage(t, handler, "/tasks")
+	if task := response.Tasks[0]; task.Title != "One, renamed" || task.CompletedAt == nil {
+		t.Errorf("expected updated and completed task, got %+v", task)
+	}
+}
+
+func TestImportTasksCsvPartialColumns(t *testing.T) {
+	handler := createTestHandler()
+
+	body := "externalId,title,content,status,priority,tags,dueDate\n" +
+		"A-1,Report,Draft outline,in_progress,high,work;q4,2025-10-01\n"
+	if _, report := importTasks(t, handler, "", "text/csv", body); report.Created != 1 {
+		t.Fatalf("expected a created task, got %+v", report)
+	}
+
+	resp, report := importTasks(t, handler, "", "text/csv", "externalId,title\nA-1,Quarterly report\n")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status %v, got %v", http.StatusOK, resp.StatusCode)
+	}
+	if statuses := rowStatuses(report); !slices.Equal(statuses, []string{"updated"}) {
+		t.Fatalf("expected the task to be updated, got %v", statuses)
+	}
+
+	task, err := handler.service.GetTaskByExternalId(t.Context(), "A-1")
+	if err != nil {
+		t.Fatalf("expected the imported task: %v", err)
+	}
+	if task.Title != "Quarterly report" || task.Content != "Draft outline" || task.Status != "in_progress" ||
+		task.Priority != "high" || !slices.Equal(task.Tags, []string{"work", "q4"}) || task.DueDate == nil {
+		t.Errorf("expected only the title to change, got %+v", task)
+	}
+}
+
+func TestImportTasksCsvLocalizedDetails(t *testing.T) {
+	handler := createTestHandler()
+
+	req := httptest.NewRequest(http.MethodPost, "/tasks/import", strings.NewReader("title,dueDate\nTest,01.10.2025\n"))
+	req.Header.Set("Content-Type", "text/csv")
+	req.Header.Set("Accept-Language", "ru")
+	w := httptest.NewRecorder()
+	handler.ImportTasks(w, req)
+
+	var report ImportReport
+	if err := json2.NewDecoder(w.Result().Body).Decode(&report); err != nil {
+		t.Fatalf("error reading response body: %v", err)
+	}
+	if len(report.Rows) != 1 || len(report.Rows[0].Errors) != 1 {
+		t.Fatalf("expected a row error, got %+v", report.Rows)
+	}
+	detail := report.Rows[0].Errors[0]
+	expected := `некорректная дата "01.10.2025", ожидался формат YYYY-MM-DD или RFC 3339`
+	if detail.Field != "dueDate" || detail.Message != expected {
+		t.Errorf("expected dueDate detail %q, got %+v", expected, detail)
+	}
+}
+
+func TestImportTasksNdjson(t *testing.T) {
+	handler := createTestHandler()
+
+	body := `{"title":"One","priority":"high"}` + "\n" +
+		"\n" +
+		`{"title":5}` + "\n" +
+		`{"title":` + "\n" +
+		`{"title":"Two","tags":["a","b","c","d","e","f","g","h","i","j","k"]}` + "\n" +
+		`{"title":"Three"}`
+
+	resp, report := importTasks(t, handler, "", "application/x-ndjson", body)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status %v, got %v", http.StatusOK, resp.StatusCode)
+	}
+
+	expected := []string{"created", "failed", "failed", "failed", "created"}
+	if statuses := rowStatuses(report); !slices.Equal(statuses, expected) {
+		t.Fatalf("expected statuses %v, got %v", expected, statuses)
+	}
+	if report.Rows[1].Row != 3 || report.Rows[1].Errors[0].Rule != "type" {
+		t.Errorf("expected type error on line 3, got %+v", report.Rows[1])
+	}
+}
+
+func TestImportTasksErrors(t *testing.T) {
+	handler := createTestHandler()
+
+	tests := []struct {
+		name           string
+		query          string
+		contentType    string
+		body           string
+		expectedStatus int
+	}{
+		{
+			name:           "broken json array",
+			contentType:    "application/json",
+			body:           `[{"title":"One"},`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "json object instead of array",
+			contentType:    "application/json",
+			body:           `{"title":"One"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "unknown format",
+			contentType:    "application/pdf",
+			body:           `%PDF-1.7`,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "unknown mapping field",
+			query:          "?mapping=owner:Owner",
+			contentType:    "text/csv",
+			body:           "title\nOne\n",
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "csv without title column",
+			contentType:    "text/csv",
+			body:           "name\nOne\n",
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "malformed csv",
+			query:          "?format=csv",
+			body:           "title\n\"One\n",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid dry run flag",
+			query:          "?dryRun=maybe",
+			contentType:    "application/json",
+			body:           `[]`,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, _ := importTasks(t, handler, tt.query, tt.contentType, tt.body)
+			if resp.StatusCode != tt.expectedStatus {
+				t.Errorf("expected status %v, got %v", tt.expectedStatus, resp.StatusCode)
+			}
+		})
+	}
+}
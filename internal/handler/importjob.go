@@ -0,0 +1,187 @@
+package handler
+
+import (
+	"encoding/csv"
+	json2 "encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"simple-tasks/internal/importer"
+	"simple-tasks/internal/model"
+	"simple-tasks/internal/service"
+	"strconv"
+	"strings"
+)
+
+type ImportJobHandler struct {
+	log     *slog.Logger
+	service *service.ImportJobService
+}
+
+func NewImportJobHandler(log *slog.Logger, service *service.ImportJobService) *ImportJobHandler {
+	return &ImportJobHandler{
+		log:     log,
+		service: service,
+	}
+}
+
+// CreateImportJob reads the export of another tool, such as a Trello board,
+// and imports its tasks in the background. The export is read and validated
+// right away; the response is the queued job, which reports the progress
+// when polled.
+func (h *ImportJobHandler) CreateImportJob(w http.ResponseWriter, r *http.Request) {
+	if !negotiate(w, r) {
+		return
+	}
+
+	if err := checkQuery(r, importJobParams); err != nil {
+		h.log.ErrorContext(r.Context(), "invalid query", slog.String("error", err.Error()))
+
+		writeError(w, r, http.StatusUnprocessableEntity, errorValidation, err)
+		return
+	}
+
+	query := r.URL.Query()
+	statuses, err := parseListStatuses(query.Get("lists"))
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "invalid lists", slog.String("error", err.Error()))
+
+		writeError(w, r, http.StatusUnprocessableEntity, errorValidation, err)
+		return
+	}
+
+	source := query.Get("source")
+	imp, ok := importer.New(source, importer.Options{Statuses: statuses})
+	if !ok {
+		err := newRequestError("unknown source {0}, expected one of {1}", strconv.Quote(source), strings.Join(importer.Sources(), ", "))
+		h.log.ErrorContext(r.Context(), "invalid source", slog.String("error", err.Error()))
+
+		writeError(w, r, http.StatusUnprocessableEntity, errorValidation, err)
+		return
+	}
+
+	doc, err := imp.Read(http.MaxBytesReader(w, r.Body, maxImportBytes))
+	if err == nil && len(doc.Items) > maxImportRows {
+		err = newRequestError("import is limited to {0}", count(translations.GetFallback(), "task", strconv.Itoa(maxImportRows)))
+	}
+
+	var maxBytesErr *http.MaxBytesError
+	var jsonSyntaxErr *json2.SyntaxError
+	var jsonTypeErr *json2.UnmarshalTypeError
+	var csvErr *csv.ParseError
+	var formatErr *importer.FormatError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		h.log.ErrorContext(r.Context(), "import too large", slog.String("error", err.Error()))
+
+		writeError(w, r, http.StatusRequestEntityTooLarge, errorPayloadTooLarge, err)
+		return
+	case errors.As(err, &jsonSyntaxErr), errors.As(err, &jsonTypeErr):
+		h.log.ErrorContext(r.Context(), "invalid json", slog.String("error", err.Error()))
+
+		writeError(w, r, http.StatusBadRequest, errorInvalidJson, err)
+		return
+	case errors.As(err, &csvErr):
+		h.log.ErrorContext(r.Context(), "invalid document", slog.String("error", err.Error()))
+
+		writeError(w, r, http.StatusBadRequest, errorBadRequest, err)
+		return
+	case errors.As(err, &formatErr):
+		h.log.ErrorContext(r.Context(), "invalid import", slog.String("error", err.Error()))
+
+		writeError(w, r, http.StatusUnprocessableEntity, errorValidation, err)
+		return
+	case err != nil:
+		h.log.ErrorContext(r.Context(), "invalid document", slog.String("error", err.Error()))
+
+		writeError(w, r, http.StatusBadRequest, errorBadRequest, err)
+		return
+	}
+
+	job := &model.ImportJob{
+		Source:   source,
+		Total:    len(doc.Items),
+		Errors:   make([]model.ImportJobError, 0),
+		Unmapped: doc.Unmapped,
+	}
+	trans := requestTranslator(r)
+	items := make([]importer.Item, 0, len(doc.Items))
+	for _, item := range doc.Items {
+		item.Task.Tags = model.NormalizeTags(item.Task.Tags)
+		if err := validate.Struct(item.Task); err != nil {
+			job.Errors = append(job.Errors, model.ImportJobError{Item: item.Ref, Message: errorMessage(trans, errorValidation, err)})
+			job.Failed++
+			job.Processed++
+			continue
+		}
+		items = append(items, item)
+	}
+
+	createdJob, err := h.service.StartImportJob(r.Context(), job, items)
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "import job start failed", slog.String("error", err.Error()))
+
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/imports/%s", createdJob.Id))
+	w.WriteHeader(http.StatusAccepted)
+	_ = encode(w, createdJob)
+}
+
+func (h *ImportJobHandler) GetImportJobs(w http.ResponseWriter, r *http.Request) {
+	if !negotiate(w, r) {
+		return
+	}
+
+	jobs := h.service.GetImportJobs(r.Context())
+
+	w.WriteHeader(http.StatusOK)
+	_ = encode(w, jobs)
+}
+
+func (h *ImportJobHandler) GetImportJobById(w http.ResponseWriter, r *http.Request) {
+	if !negotiate(w, r) {
+		return
+	}
+
+	id, err := pathId(r)
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "invalid id", slog.String("error", err.Error()))
+
+		writeError(w, r, http.StatusUnprocessableEntity, errorValidation, err)
+		return
+	}
+
+	job, err := h.service.GetImportJobById(r.Context(), id)
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "import job lookup failed", slog.String("error", err.Error()))
+
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = encode(w, job)
+}
+
+// parseListStatuses reads list to status mappings such as
+// "Doing:in_progress,Shipped:done". List names may contain colons; the
+// status follows the last one.
+func parseListStatuses(value string) (map[string]string, error) {
+	statuses := make(map[string]string)
+	for _, part := range multiValue([]string{value}) {
+		i := strings.LastIndex(part, ":")
+		if i <= 0 {
+			return nil, newRequestError("invalid list mapping {0}, expected list:status", strconv.Quote(part))
+		}
+		list, status := strings.TrimSpace(part[:i]), strings.TrimSpace(part[i+1:])
+		if err := validate.Var(status, "oneof=todo in_progress done"); err != nil {
+			return nil, newRequestError("invalid list mapping {0}, unknown status {1}", strconv.Quote(part), strconv.Quote(status))
+		}
+		statuses[list] = status
+	}
+	return statuses, nil
+}
@@ -0,0 +1,282 @@
+package handler
+
+import (
+	"context"
+	json2 "encoding/json"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"simple-tasks/internal/model"
+	"simple-tasks/internal/service"
+	"simple-tasks/internal/store"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+const trelloBoard = `{
+	"name": "Launch",
+	"lists": [
+		{"id": "l1", "name": "To Do", "closed": false},
+		{"id": "l2", "name": "Doing", "closed": false},
+		{"id": "l3", "name": "Shipped", "closed": false},
+		{"id": "l4", "name": "Old ideas", "closed": true}
+	],
+	"cards": [
+		{"id": "c1", "name": "Write landing page", "desc": "Hero and pricing", "idList": "l2", "closed": false,
+			"due": "2025-10-03T15:00:00.000Z", "dueComplete": false, "labels": [{"name": "Marketing", "color": "green"}, {"name": "", "color": "red"}],
+			"idMembers": ["m1", "m2"], "attachments": [{"id": "a1"}]},
+		{"id": "c2", "name": "Set up domain", "desc": "", "idList": "l1", "closed": false, "due": null, "dueComplete": true, "labels": []},
+		{"id": "c3", "name": "Announce", "desc": "", "idList": "l3", "closed": false, "labels": [], "start": "2025-10-01T00:00:00.000Z"},
+		{"id": "c4", "name": "Archived card", "desc": "", "idList": "l1", "closed": true},
+		{"id": "c5", "name": "Card of an archived list", "desc": "", "idList": "l4", "closed": false},
+		{"id": "c6", "name": "", "desc": "", "idList": "l1", "closed": false}
+	],
+	"checklists": [
+		{"id": "k2", "idCard": "c1", "name": "Review", "pos": 2, "checkItems": [{"name": "Legal", "state": "incomplete", "pos": 1}]},
+		{"id": "k1", "idCard": "c1", "name": "Sections", "pos": 1, "checkItems": [
+			{"name": "Pricing", "state": "incomplete", "pos": 2},
+			{"name": "Hero", "state": "complete", "pos": 1}
+		]}
+	],
+	"actions": [
+		{"type": "commentCard", "data": {"card": {"id": "c1"}}},
+		{"type": "updateCard", "data": {"card": {"id": "c1"}}}
+	]
+}`
+
+func createTestImportJobHandler(t *testing.T) (*TaskHandler, *ImportJobHandler) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	taskService := service.NewTaskService(log, store.NewInMemoryTaskRepository())
+	importJobService := service.NewImportJobService(log, store.NewInMemoryImportJobRepository(), taskService)
+	t.Cleanup(func() { importJobService.Close(context.Background()) })
+
+	return NewTaskHandler(log, taskService), NewImportJobHandler(log, importJobService)
+}
+
+func createImportJob(t *testing.T, handler *ImportJobHandler, query string, body string) (*http.Response, model.ImportJob) {
+	req := httptest.NewRequest(http.MethodPost, "/imports"+query, strings.NewReader(body))
+	w := httptest.NewRecorder()
+	handler.CreateImportJob(w, req)
+
+	resp := w.Result()
+	var job model.ImportJob
+	if resp.StatusCode == http.StatusAccepted {
+		if err := json2.NewDecoder(resp.Body).Decode(&job); err != nil {
+			t.Fatalf("error reading response body: %v", err)
+		}
+	}
+	return resp, job
+}
+
+// waitImportJob polls the job until it is no longer queued or running.
+func waitImportJob(t *testing.T, handler *ImportJobHandler, job model.ImportJob) model.ImportJob {
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		req := httptest.NewRequest(http.MethodGet, "/imports/"+job.Id.String(), nil)
+		req.SetPathValue("id", job.Id.String())
+		w := httptest.NewRecorder()
+		handler.GetImportJobById(w, req)
+
+		resp := w.Result()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status %v, got %v", http.StatusOK, resp.StatusCode)
+		}
+		if err := json2.NewDecoder(resp.Body).Decode(&job); err != nil {
+			t.Fatalf("error reading response body: %v", err)
+		}
+		if job.Status != model.ImportJobQueued && job.Status != model.ImportJobRunning {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("import job still %s", job.Status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestImportJobTrello(t *testing.T) {
+	taskHandler, handler := createTestImportJobHandler(t)
+
+	resp, job := createImportJob(t, handler, "?source=trello", trelloBoard)
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("expected status %v, got %v", http.StatusAccepted, resp.StatusCode)
+	}
+	if location := resp.Header.Get("Location"); location != "/imports/"+job.Id.String() {
+		t.Errorf("unexpected location %q", location)
+	}
+
+	job = waitImportJob(t, handler, job)
+	if job.Status != model.ImportJobCompleted || job.Total != 4 || job.Processed != 4 || job.Created != 3 || job.Failed != 1 {
+		t.Fatalf("unexpected job %+v", job)
+	}
+	if len(job.Errors) != 1 || job.Errors[0].Item != "" || job.Errors[0].Message != "title is required" {
+		t.Errorf("expected the card without a name to fail, got %+v", job.Errors)
+	}
+	expectedUnmapped := map[string]int{"archivedCards": 2, "members": 2, "attachments": 1, "comments": 1, "startDates": 1}
+	if !maps.Equal(job.Unmapped, expectedUnmapped) {
+		t.Errorf("expected unmapped %v, got %v", expectedUnmapped, job.Unmapped)
+	}
+
+	_, response, _ := getTaskPage(t, taskHandler, "/tasks?sort=title")
+	tasks := response.Tasks
+	if len(tasks) != 3 {
+		t.Fatalf("expected 3 tasks, got %d", len(tasks))
+	}
+	if task := tasks[0]; task.Title != "Announce" || task.Status != "done" {
+		t.Errorf("expected the card of the shipped list to be done, got %+v", task)
+	}
+	if task := tasks[1]; task.Title != "Set up domain" || task.Status != "done" || task.ExternalId != "trello:c2" {
+		t.Errorf("expected the card with a completed due date to be done, got %+v", task)
+	}
+	landing := tasks[2]
+	if landing.Status != "in_progress" || !slices.Equal(landing.Tags, []string{"marketing", "red"}) || landing.DueDate == nil {
+		t.Errorf("unexpected task %+v", landing)
+	}
+	expectedContent := "Hero and pricing\n\nSections\n- [x] Hero\n- [ ] Pricing\n\nReview\n- [ ] Legal"
+	if landing.Content != expectedContent {
+		t.Errorf("expected content %q, got %q", expectedContent, landing.Content)
+	}
+
+	// Importing the board again updates the same tasks.
+	_, job = createImportJob(t, handler, "?source=trello&lists=Shipped:in_progress", trelloBoard)
+	job = waitImportJob(t, handler, job)
+	if job.Created != 0 || job.Updated != 1 || job.Skipped != 2 {
+		t.Errorf("expected only the remapped card to be updated, got %+v", job)
+	}
+}
+
+func TestImportJobTodoist(t *testing.T) {
+	tests := []struct {
+		name             string
+		body             string
+		expectedTitles   []string
+		expectedUnmapped map[string]int
+	}{
+		{
+			name: "csv",
+			body: "TYPE,CONTENT,DESCRIPTION,PRIORITY,INDENT,AUTHOR,RESPONSIBLE,DATE,DATE_LANG,TIMEZONE\n" +
+				"task,Buy milk @errands,,1,1,Ann,,2025-10-03,en,UTC\n" +
+				"task,Check expiry date,,4,2,Ann,,,en,UTC\n" +
+				"note,Lactose free,,,,Ann,,,en,UTC\n" +
+				",,,,,,,,,\n" +
+				"section,In progress,,,,,,,,\n" +
+				"task,Call plumber,,3,1,Ann,Bob,every monday,en,UTC\n",
+			expectedTitles:   []string{"Buy milk", "Call plumber"},
+			expectedUnmapped: map[string]int{"assignees": 1, "dueDates": 1},
+		},
+		{
+			name: "api json",
+			body: `{
+				"projects": [{"id": "p1"}, {"id": "p2"}],
+				"sections": [{"id": "s1", "name": "In progress"}],
+				"items": [
+					{"id": "1", "content": "Buy milk", "priority": 4, "labels": ["errands"], "due": {"date": "2025-10-03"}},
+					{"id": 2, "content": "Check expiry date", "priority": 1, "parent_id": "1"},
+					{"id": "3", "content": "Call plumber", "priority": 2, "section_id": "s1", "responsible_uid": "u2",
+						"due": {"date": "2025-10-06", "string": "every monday", "is_recurring": true}}
+				],
+				"notes": [{"item_id": "2", "content": "Lactose free"}]
+			}`,
+			expectedTitles:   []string{"Buy milk", "Call plumber"},
+			expectedUnmapped: map[string]int{"assignees": 1, "projects": 2, "recurrence": 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			taskHandler, handler := createTestImportJobHandler(t)
+
+			resp, job := createImportJob(t, handler, "?source=todoist", tt.body)
+			if resp.StatusCode != http.StatusAccepted {
+				t.Fatalf("expected status %v, got %v", http.StatusAccepted, resp.StatusCode)
+			}
+			job = waitImportJob(t, handler, job)
+			if job.Status != model.ImportJobCompleted || job.Created != len(tt.expectedTitles) {
+				t.Fatalf("unexpected job %+v", job)
+			}
+			if !maps.Equal(job.Unmapped, tt.expectedUnmapped) {
+				t.Errorf("expected unmapped %v, got %v", tt.expectedUnmapped, job.Unmapped)
+			}
+
+			_, response, _ := getTaskPage(t, taskHandler, "/tasks?sort=title")
+			tasks := response.Tasks
+			titles := make([]string, 0, len(tasks))
+			for _, task := range tasks {
+				titles = append(titles, task.Title)
+			}
+			if !slices.Equal(titles, tt.expectedTitles) {
+				t.Fatalf("expected titles %v, got %v", tt.expectedTitles, titles)
+			}
+			if task := tasks[0]; task.Priority != "high" || !slices.Equal(task.Tags, []string{"errands"}) ||
+				task.Content != "- [ ] Check expiry date\n\nLactose free" || task.DueDate == nil {
+				t.Errorf("unexpected task %+v", task)
+			}
+			if task := tasks[1]; task.Status != "in_progress" || task.Priority != "normal" {
+				t.Errorf("expected the task of the section in progress, got %+v", task)
+			}
+		})
+	}
+}
+
+func TestImportJobErrors(t *testing.T) {
+	_, handler := createTestImportJobHandler(t)
+
+	tests := []struct {
+		name           string
+		query          string
+		body           string
+		expectedStatus int
+	}{
+		{
+			name:           "unknown source",
+			query:          "?source=asana",
+			body:           "{}",
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "invalid list mapping",
+			query:          "?source=trello&lists=Doing:started",
+			body:           trelloBoard,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "broken json",
+			query:          "?source=trello",
+			body:           `{"cards": [`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "not a board",
+			query:          "?source=trello",
+			body:           `{"name": "Launch"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "csv without content column",
+			query:          "?source=todoist",
+			body:           "TYPE,TITLE\ntask,Buy milk\n",
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, _ := createImportJob(t, handler, tt.query, tt.body)
+			if resp.StatusCode != tt.expectedStatus {
+				t.Errorf("expected status %v, got %v", tt.expectedStatus, resp.StatusCode)
+			}
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/imports/00000000-0000-0000-0000-000000000000", nil)
+	req.SetPathValue("id", "00000000-0000-0000-0000-000000000000")
+	w := httptest.NewRecorder()
+	handler.GetImportJobById(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status %v for an unknown job, got %v", http.StatusNotFound, w.Code)
+	}
+}
@@ -0,0 +1,291 @@
+package handler
+
+import (
+	"fmt"
+	"github.com/go-playground/locales"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/ru"
+	ut "github.com/go-playground/universal-translator"
+	"golang.org/x/text/language"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// languages are the languages of client messages; the first one is the
+// fallback.
+var languages = []language.Tag{language.English, language.Russian}
+
+var (
+	languageMatcher = language.NewMatcher(languages)
+	translations    = ut.New(en.New(), en.New(), ru.New())
+)
+
+// messages translate the messages sent to clients, keyed by their English
+// text with {0}-style parameters, which translations must keep in order.
+// Messages missing from a catalog, such as errors from other packages, are
+// sent in English.
+var messages = map[string]map[string]string{
+	"ru": {
+		// Validation rules.
+		"{0} is required":                                                "поле {0} обязательно для заполнения",
+		"{0} must be at least {1} long":                                  "поле {0} должно содержать минимум {1}",
+		"{0} must be at most {1} long":                                   "поле {0} должно содержать максимум {1}",
+		"{0} must be exactly {1} long":                                   "поле {0} должно содержать ровно {1}",
+		"{0} must contain at least {1}":                                  "поле {0} должно содержать минимум {1}",
+		"{0} must contain at most {1}":                                   "поле {0} должно содержать максимум {1}",
+		"{0} must contain exactly {1}":                                   "поле {0} должно содержать ровно {1}",
+		"{0} must be at least {1}":                                       "поле {0} должно быть не меньше {1}",
+		"{0} must be at most {1}":                                        "поле {0} должно быть не больше {1}",
+		"{0} must be {1}":                                                "поле {0} должно быть {1}",
+		"{0} must be one of: {1}":                                        "поле {0} должно быть одним из значений: {1}",
+		"{0} must be an absolute URL":                                    "поле {0} должно быть абсолютным URL",
+		"{0} must be true or false":                                      "поле {0} должно быть true или false",
+		"{0} must be a date such as 2025-10-01 or an RFC 3339 date-time": "поле {0} должно быть датой, например 2025-10-01, или датой и временем в формате RFC 3339",
+		"{0} must be a date such as 2025-10-01":                          "поле {0} должно быть датой, например 2025-10-01",
+		"{0} must be a hex color such as #1e90ff":                        "поле {0} должно быть цветом в формате hex, например #1e90ff",
+		"{0} must list sort keys such as priority:desc,dueDate:asc":      "поле {0} должно перечислять ключи сортировки, например priority:desc,dueDate:asc",
+
+		// Requests.
+		"{0} is not a known query parameter":          "параметр {0} не поддерживается",
+		"{0} must be an integer":                      "параметр {0} должен быть целым числом",
+		"{0} is not a known field":                    "поле {0} не поддерживается",
+		"request body has data after the first value": "тело запроса содержит данные после первого значения",
+		"request body is larger than {0}":             "тело запроса больше {0}",
+		"malformed JSON at offset {0}":                "некорректный JSON в позиции {0}",
+		"{0} is not an RFC 3339 date-time":            "{0} не является датой и временем в формате RFC 3339",
+		"{0} is not true or false":                    "{0} не является значением true или false",
+		"{0} is not a valid number":                   "{0} не является числом",
+		"request body is empty":                       "тело запроса пустое",
+		"request body ends unexpectedly":              "тело запроса неожиданно обрывается",
+		"a string":                                    "строкой",
+		"true or false":                               "true или false",
+		"a number":                                    "числом",
+		"an array":                                    "массивом",
+		"an object":                                   "объектом",
+		"a valid value":                               "допустимым значением",
+		"internal server error":                       "внутренняя ошибка сервера",
+		"external id {0} already appears in row {1}":  "внешний идентификатор {0} уже встречается в строке {1}",
+		"invalid request":                             "некорректный запрос",
+		"{0} is not a valid id":                       "{0} не является допустимым идентификатором",
+		"{0} is not a valid query string":             "{0} не является допустимой строкой запроса",
+		"request body has a line longer than {0}":     "тело запроса содержит строку длиннее {0}",
+		"none of the accepted media types is supported, expected one of {0}": "ни один из принимаемых типов данных не поддерживается, ожидался один из: {0}",
+		"unsupported media type {0}":                                         "неподдерживаемый тип данных {0}",
+		"unsupported media type {0}, expected one of {1}":                    "неподдерживаемый тип данных {0}, ожидался один из: {1}",
+		"stats range must cover 1 to {0}":                                    "период статистики должен составлять от 1 до {0}",
+
+		// Imports.
+		"import is limited to {0}":                            "импорт ограничен {0}",
+		"invalid mapping {0}, expected field:column":          "некорректное сопоставление {0}, ожидался формат поле:столбец",
+		"invalid mapping {0}, unknown field {1}":              "некорректное сопоставление {0}, неизвестное поле {1}",
+		"invalid list mapping {0}, expected list:status":      "некорректное сопоставление списков {0}, ожидался формат список:статус",
+		"invalid list mapping {0}, unknown status {1}":        "некорректное сопоставление списков {0}, неизвестный статус {1}",
+		"unknown source {0}, expected one of {1}":             "неизвестный источник {0}, ожидался один из: {1}",
+		"csv has no header row":                               "в CSV нет строки заголовков",
+		"csv has no title column":                             "в CSV нет столбца title",
+		"column {0} mapped to {1} is missing":                 "столбец {0}, сопоставленный полю {1}, отсутствует",
+		"expected a JSON array of tasks":                      "ожидался JSON-массив задач",
+		"expected VCALENDAR, got {0}":                         "ожидался VCALENDAR, получено {0}",
+		"malformed {0} document: {1}":                         "некорректный документ {0}: {1}",
+		"malformed iCalendar on line {0}: {1}":                "некорректный iCalendar в строке {0}: {1}",
+		"malformed CSV on line {0}: {1}":                      "некорректный CSV в строке {0}: {1}",
+		"bare \" in non-quoted-field":                         "кавычка \" в поле без кавычек",
+		"extraneous or missing \" in quoted-field":            "лишняя или недостающая кавычка \" в поле в кавычках",
+		"wrong number of fields":                              "неверное число полей",
+		"not a Todoist export: it has no items or tasks":      "это не экспорт Todoist: в нём нет задач",
+		"not a Trello board export: it has no lists or cards": "это не экспорт доски Trello: в нём нет списков и карточек",
+
+		// Filter syntax errors.
+		"filter syntax error at position {0}: {1}":          "синтаксическая ошибка фильтра в позиции {0}: {1}",
+		"unexpected {0}, did you mean \"!=\"":               "неожиданный символ {0}, возможно, имелось в виду \"!=\"",
+		"unexpected character {0}":                          "неожиданный символ {0}",
+		"unterminated string":                               "незакрытая строка",
+		"empty filter":                                      "пустой фильтр",
+		"unexpected {0}":                                    "неожиданный фрагмент {0}",
+		"expected {0}, got {1}":                             "ожидалось {0}, получено {1}",
+		"expression is nested too deeply":                   "слишком глубокая вложенность выражения",
+		"unknown function {0}":                              "неизвестная функция {0}",
+		"lower() needs a text field, got {0}":               "lower() применима только к текстовому полю, получено {0}",
+		"len() needs a text or tag field, got {0}":          "len() применима только к текстовому полю или тегам, получено {0}",
+		"unknown field {0}":                                 "неизвестное поле {0}",
+		"expected operator, got {0}":                        "ожидался оператор, получено {0}",
+		"operator {0} is not supported for {1}":             "оператор {0} не поддерживается для поля {1}",
+		"expected number, got {0}":                          "ожидалось число, получено {0}",
+		"invalid number {0}":                                "некорректное число {0}",
+		"invalid date {0}, expected YYYY-MM-DD or RFC 3339": "некорректная дата {0}, ожидался формат YYYY-MM-DD или RFC 3339",
+		"expected date, got {0}":                            "ожидалась дата, получено {0}",
+		"expected value, got {0}":                           "ожидалось значение, получено {0}",
+		"invalid {0} {1}, expected one of {2}":              "некорректное значение поля {0}: {1}, ожидалось одно из: {2}",
+		"invalid number of days {0}":                        "некорректное число дней {0}",
+		"unknown date function {0}":                         "неизвестная функция даты {0}",
+		"end of filter":                                     "конец фильтра",
+		"field name":                                        "имя поля",
+		"number of days":                                    "число дней",
+		"\"(\" after function name":                         "\"(\" после имени функции",
+
+		// Service errors.
+		"task not found":                  "задача не найдена",
+		"view not found":                  "представление не найдено",
+		"view belongs to another user":    "представление принадлежит другому пользователю",
+		"import job not found":            "задание импорта не найдено",
+		"too many import jobs are queued": "в очереди слишком много заданий импорта",
+		"webhook not found":               "вебхук не найден",
+		"tag not found":                   "тег не найден",
+		"tag already exists":              "тег уже существует",
+		"task can have at most 10 tags":   "у задачи может быть не больше 10 тегов",
+		"invalid cursor":                  "недействительный курсор",
+		"invalid sync token":              "недействительный токен синхронизации",
+		"event broker closed":             "рассылка событий остановлена",
+		"precondition failed":             "предусловие не выполнено",
+
+		// Problem titles.
+		"Malformed request body": "Некорректное тело запроса",
+		"Validation failed":      "Ошибка валидации",
+		"Resource not found":     "Ресурс не найден",
+		"Bad request":            "Некорректный запрос",
+		"Internal server error":  "Внутренняя ошибка сервера",
+		"Forbidden":              "Доступ запрещён",
+		"Conflict":               "Конфликт",
+		"Not acceptable":         "Неприемлемый формат",
+		"Unsupported media type": "Неподдерживаемый тип данных",
+		"Precondition failed":    "Предусловие не выполнено",
+		"Service unavailable":    "Сервис недоступен",
+		"Payload too large":      "Слишком большое тело запроса",
+	},
+}
+
+// counts are the plural forms of counted words, by the plural rules of each
+// language.
+var counts = map[string]map[string]map[locales.PluralRule]string{
+	"en": {
+		"character": {locales.PluralRuleOne: "{0} character", locales.PluralRuleOther: "{0} characters"},
+		"item":      {locales.PluralRuleOne: "{0} item", locales.PluralRuleOther: "{0} items"},
+		"byte":      {locales.PluralRuleOne: "{0} byte", locales.PluralRuleOther: "{0} bytes"},
+		"row":       {locales.PluralRuleOne: "{0} row", locales.PluralRuleOther: "{0} rows"},
+		"task":      {locales.PluralRuleOne: "{0} task", locales.PluralRuleOther: "{0} tasks"},
+		"day":       {locales.PluralRuleOne: "{0} day", locales.PluralRuleOther: "{0} days"},
+	},
+	"ru": {
+		"character": {
+			locales.PluralRuleOne:   "{0} символ",
+			locales.PluralRuleFew:   "{0} символа",
+			locales.PluralRuleMany:  "{0} символов",
+			locales.PluralRuleOther: "{0} символа",
+		},
+		"item": {
+			locales.PluralRuleOne:   "{0} элемент",
+			locales.PluralRuleFew:   "{0} элемента",
+			locales.PluralRuleMany:  "{0} элементов",
+			locales.PluralRuleOther: "{0} элемента",
+		},
+		"byte": {
+			locales.PluralRuleOne:   "{0} байт",
+			locales.PluralRuleFew:   "{0} байта",
+			locales.PluralRuleMany:  "{0} байт",
+			locales.PluralRuleOther: "{0} байта",
+		},
+		"row": {
+			locales.PluralRuleOne:   "{0} строкой",
+			locales.PluralRuleFew:   "{0} строками",
+			locales.PluralRuleMany:  "{0} строками",
+			locales.PluralRuleOther: "{0} строки",
+		},
+		"task": {
+			locales.PluralRuleOne:   "{0} задачей",
+			locales.PluralRuleFew:   "{0} задачами",
+			locales.PluralRuleMany:  "{0} задачами",
+			locales.PluralRuleOther: "{0} задачи",
+		},
+		"day": {
+			locales.PluralRuleOne:   "{0} дня",
+			locales.PluralRuleFew:   "{0} дней",
+			locales.PluralRuleMany:  "{0} дней",
+			locales.PluralRuleOther: "{0} дня",
+		},
+	},
+}
+
+func init() {
+	for locale, catalog := range messages {
+		trans, _ := translations.GetTranslator(locale)
+		for key, text := range catalog {
+			if err := trans.Add(key, text, false); err != nil {
+				panic(err)
+			}
+		}
+	}
+	for locale, words := range counts {
+		trans, _ := translations.GetTranslator(locale)
+		for word, forms := range words {
+			for rule, text := range forms {
+				if err := trans.AddCardinal(word, text, rule, false); err != nil {
+					panic(err)
+				}
+			}
+		}
+	}
+	for _, tag := range languages {
+		trans, _ := translations.GetTranslator(tag.String())
+		if err := registerRuleTranslations(trans); err != nil {
+			panic(err)
+		}
+	}
+	if err := translations.VerifyTranslations(); err != nil {
+		panic(err)
+	}
+}
+
+// requestLanguage picks the language of messages from the Accept-Language
+// header. Regional variants such as ru-RU get their base language, and
+// requests without a supported language get English.
+func requestLanguage(r *http.Request) language.Tag {
+	tags, _, err := language.ParseAcceptLanguage(r.Header.Get("Accept-Language"))
+	if err != nil || len(tags) == 0 {
+		return languages[0]
+	}
+	_, index, confidence := languageMatcher.Match(tags...)
+	if confidence == language.No {
+		return languages[0]
+	}
+	return languages[index]
+}
+
+// requestTranslator returns the translator of the request's language.
+func requestTranslator(r *http.Request) ut.Translator {
+	trans, _ := translations.GetTranslator(requestLanguage(r).String())
+	return trans
+}
+
+// setContentLanguage announces the language of messages in the response.
+func setContentLanguage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Vary", "Accept-Language")
+	w.Header().Set("Content-Language", requestLanguage(r).String())
+}
+
+// localize translates a message of the catalog and fills in its parameters;
+// messages the catalog lacks are filled in as they are.
+func localize(trans ut.Translator, text string, params ...string) string {
+	if translated, err := trans.T(text, params...); err == nil {
+		return translated
+	}
+	placeholders := make([]string, 0, 2*len(params))
+	for i, param := range params {
+		placeholders = append(placeholders, "{"+strconv.Itoa(i)+"}", param)
+	}
+	return strings.NewReplacer(placeholders...).Replace(text)
+}
+
+// count phrases a number of words, such as "1 character", in the plural
+// form of the translator's language.
+func count(trans ut.Translator, word string, n string) string {
+	num, err := strconv.ParseFloat(n, 64)
+	if err != nil {
+		return fmt.Sprintf("%s %s", n, word)
+	}
+	counted, err := trans.C(word, num, 0, n)
+	if err != nil {
+		return fmt.Sprintf("%s %s", n, word)
+	}
+	return counted
+}
@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"simple-tasks/internal/service"
+	"simple-tasks/internal/store"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func createTestMarkdownHandler(t *testing.T, dir string) (*TaskHandler, *store.MarkdownTaskRepository) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	repo, err := store.NewMarkdownTaskRepository(log, dir, time.Hour)
+	if err != nil {
+		t.Fatalf("error opening task directory: %v", err)
+	}
+	t.Cleanup(repo.Close)
+
+	return NewTaskHandler(log, service.NewTaskService(log, repo)), repo
+}
+
+func getTaskTitles(t *testing.T, handler *TaskHandler, target string) []string {
+	resp, response, _ := getTaskPage(t, handler, target)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status %v, got %v", http.StatusOK, resp.StatusCode)
+	}
+	titles := make([]string, 0, len(response.Tasks))
+	for _, task := range response.Tasks {
+		titles = append(titles, task.Title)
+	}
+	return titles
+}
+
+func TestMarkdownStorage(t *testing.T) {
+	dir := t.TempDir()
+	handler, repo := createTestMarkdownHandler(t, dir)
+
+	task := createTask(t, handler, `{"title":"Write docs","content":"# Outline\n\n- intro","priority":"high","tags":["Docs"],"dueDate":"2025-10-01T00:00:00Z"}`)
+	path := filepath.Join(dir, task.Id.String()+".md")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("expected a file for the task: %v", err)
+	}
+	for _, expected := range []string{
+		"---\nid: " + task.Id.String() + "\ntitle: Write docs\n",
+		"priority: high\n",
+		"tags: [docs]\n",
+		"dueDate: 2025-10-01T00:00:00Z\n",
+		"---\n\n# Outline\n\n- intro\n",
+	} {
+		if !strings.Contains(string(data), expected) {
+			t.Errorf("expected %q in\n%s", expected, data)
+		}
+	}
+
+	edited := strings.Replace(string(data), "title: Write docs", "title: Write the docs", 1)
+	edited = strings.Replace(edited, "status: todo", "status: done", 1)
+	if err := os.WriteFile(path, []byte(edited), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	handWritten := "---\ntitle: Review PR\ntags: [work, review]\ndueDate: 2025-10-02\n---\nLooks good.\n"
+	if err := os.WriteFile(filepath.Join(dir, "review.md"), []byte(handWritten), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "broken.md"), []byte("no front matter"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for name, matter := range map[string]string{
+		"status.md":   "title: Unknown status\nstatus: someday",
+		"priority.md": "title: Unknown priority\npriority: urgent",
+		"title.md":    "title: " + strings.Repeat("a", 201),
+		"tags.md":     "title: Too many tags\ntags: [a, b, c, d, e, f, g, h, i, j, k]",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("---\n"+matter+"\n---\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := repo.Scan(); err != nil {
+		t.Fatalf("error scanning: %v", err)
+	}
+
+	if titles := getTaskTitles(t, handler, "/tasks?sort=title"); !slices.Equal(titles, []string{"Review PR", "Write the docs"}) {
+		t.Fatalf("expected edited and hand-written tasks, got %v", titles)
+	}
+	updated, err := handler.service.GetTaskById(t.Context(), task.Id)
+	if err != nil {
+		t.Fatalf("expected the task to keep its id: %v", err)
+	}
+	if updated.Status != "done" || updated.CompletedAt == nil || !updated.UpdatedAt.After(task.UpdatedAt) {
+		t.Errorf("expected an updated completed task, got %+v", updated)
+	}
+	if titles := getTaskTitles(t, handler, "/tasks?tag=review&dueBefore=2025-10-03"); !slices.Equal(titles, []string{"Review PR"}) {
+		t.Errorf("expected hand-written task to be indexed, got %v", titles)
+	}
+
+	if err := os.Rename(path, filepath.Join(dir, "docs.md")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(dir, "review.md")); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Scan(); err != nil {
+		t.Fatalf("error scanning: %v", err)
+	}
+	if titles := getTaskTitles(t, handler, "/tasks"); !slices.Equal(titles, []string{"Write the docs"}) {
+		t.Fatalf("expected renamed task to remain and removed one to be gone, got %v", titles)
+	}
+
+	deleteTestTask(t, handler, task.Id.String())
+	if _, err := os.Stat(filepath.Join(dir, "docs.md")); !os.IsNotExist(err) {
+		t.Errorf("expected the file of the deleted task to be removed, got %v", err)
+	}
+}
+
+func TestMarkdownStorageReload(t *testing.T) {
+	dir := t.TempDir()
+	handler, _ := createTestMarkdownHandler(t, dir)
+
+	task := createTask(t, handler, `{"title":"Persisted","content":"line 1\nline 2","status":"in_progress","tags":["a","b"]}`)
+	removed := createTask(t, handler, `{"title":"Removed"}`)
+	deleteTestTask(t, handler, removed.Id.String())
+
+	reopened, _ := createTestMarkdownHandler(t, dir)
+	if titles := getTaskTitles(t, reopened, "/tasks"); !slices.Equal(titles, []string{"Persisted"}) {
+		t.Fatalf("expected the stored task, got %v", titles)
+	}
+	loaded, err := reopened.service.GetTaskById(t.Context(), task.Id)
+	if err != nil {
+		t.Fatalf("expected the task to keep its id: %v", err)
+	}
+	if loaded.Content != task.Content || loaded.Status != task.Status || !slices.Equal(loaded.Tags, task.Tags) ||
+		!loaded.CreatedAt.Equal(task.CreatedAt) || !loaded.UpdatedAt.Equal(task.UpdatedAt) {
+		t.Errorf("expected %+v, got %+v", task, loaded)
+	}
+}
+
+func TestMarkdownStorageInvalidEdit(t *testing.T) {
+	dir := t.TempDir()
+	handler, repo := createTestMarkdownHandler(t, dir)
+
+	task := createTask(t, handler, `{"title":"Edited by hand"}`)
+	path := filepath.Join(dir, task.Id.String()+".md")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	invalid := strings.Replace(string(data), "status: todo", "status: someday", 1)
+	if err := os.WriteFile(path, []byte(invalid), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Scan(); err != nil {
+		t.Fatalf("error scanning: %v", err)
+	}
+	if titles := getTaskTitles(t, handler, "/tasks"); !slices.Equal(titles, []string{"Edited by hand"}) {
+		t.Fatalf("expected the task to be kept as last read, got %v", titles)
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Scan(); err != nil {
+		t.Fatalf("error scanning: %v", err)
+	}
+	if titles := getTaskTitles(t, handler, "/tasks"); len(titles) != 0 {
+		t.Errorf("expected the task of the removed file to be gone, got %v", titles)
+	}
+}
+
+func deleteTestTask(t *testing.T, handler *TaskHandler, id string) {
+	req := httptest.NewRequest(http.MethodDelete, "/tasks/"+id, nil)
+	req.SetPathValue("id", id)
+	w := httptest.NewRecorder()
+	handler.DeleteTask(w, req)
+
+	if resp := w.Result(); resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("expected status %v, got %v", http.StatusNoContent, resp.StatusCode)
+	}
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+	"simple-tasks/internal/model"
+)
+
+func (h *TaskHandler) MergeChanges(w http.ResponseWriter, r *http.Request) {
+	if !negotiate(w, r) {
+		return
+	}
+
+	id, err := pathId(r)
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "invalid id", slog.String("error", err.Error()))
+
+		writeError(w, r, http.StatusUnprocessableEntity, errorValidation, err)
+		return
+	}
+
+	var req model.MergeRequest
+	if err := decode(w, r, &req); err != nil {
+		h.log.ErrorContext(r.Context(), "invalid request body", slog.String("error", err.Error()))
+
+		writeDecodeError(w, r, err)
+		return
+	}
+
+	if err := validate.Struct(req); err != nil {
+		h.log.ErrorContext(r.Context(), "invalid changes", slog.String("error", err.Error()))
+
+		writeError(w, r, http.StatusUnprocessableEntity, errorValidation, err)
+		return
+	}
+
+	for _, change := range req.Changes {
+		value, err := change.DecodeValue()
+		if err == nil {
+			err = validate.Struct(value)
+		}
+		if err != nil {
+			h.log.ErrorContext(r.Context(), "invalid change", slog.String("error", err.Error()))
+
+			writeError(w, r, http.StatusUnprocessableEntity, errorValidation, err)
+			return
+		}
+	}
+
+	merged, err := h.service.MergeChanges(r.Context(), id, &req)
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "task merge failed", slog.String("error", err.Error()))
+
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = encode(w, merged)
+}
@@ -0,0 +1,183 @@
+package handler
+
+import (
+	json2 "encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"simple-tasks/internal/model"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func mergeChanges(t *testing.T, handler *TaskHandler, id string, body string) (*http.Response, model.MergeResponse) {
+	req := httptest.NewRequest(http.MethodPost, "/tasks/", strings.NewReader(body))
+	req.SetPathValue("id", id)
+	w := httptest.NewRecorder()
+	handler.MergeChanges(w, req)
+	resp := w.Result()
+
+	var response model.MergeResponse
+	if resp.StatusCode == http.StatusOK {
+		if err := json2.NewDecoder(resp.Body).Decode(&response); err != nil {
+			t.Fatalf("error reading response body: %v", err)
+		}
+	}
+	return resp, response
+}
+
+func TestMergeChanges(t *testing.T) {
+	handler := createTestHandler()
+	allTasks := addTasks(handler)
+	task := allTasks[0]
+
+	later := time.Now().Add(time.Hour).Format(time.RFC3339Nano)
+	earlier := task.CreatedAt.Add(-time.Hour).Format(time.RFC3339Nano)
+
+	_, merged := mergeChanges(t, handler, task.Id.String(), fmt.Sprintf(`{"clientId":"a","changes":[
+		{"field":"title","value":"Client A title","timestamp":%q},
+		{"field":"tags","op":"add","value":"new","timestamp":%q},
+		{"field":"tags","op":"remove","value":%q,"timestamp":%q}
+	]}`, later, later, task.Tags[0], later))
+
+	if merged.Task.Title != "Client A title" {
+		t.Errorf("expected title %v, got %v", "Client A title", merged.Task.Title)
+	}
+	if !slices.Contains(merged.Task.Tags, "new") || slices.Contains(merged.Task.Tags, task.Tags[0]) {
+		t.Errorf("expected tag %v added and %v removed, got %v", "new", task.Tags[0], merged.Task.Tags)
+	}
+	if len(merged.Conflicts) != 0 {
+		t.Errorf("expected no conflicts, got %+v", merged.Conflicts)
+	}
+
+	_, merged = mergeChanges(t, handler, task.Id.String(), fmt.Sprintf(`{"clientId":"b","changes":[
+		{"field":"title","value":"Client B title","timestamp":%q},
+		{"field":"priority","value":"high","timestamp":%q},
+		{"field":"tags","op":"remove","value":"new","timestamp":%q}
+	]}`, earlier, later, earlier))
+
+	if merged.Task.Title != "Client A title" {
+		t.Errorf("expected newer title %v to win, got %v", "Client A title", merged.Task.Title)
+	}
+	if merged.Task.Priority != model.PriorityHigh {
+		t.Errorf("expected priority %v, got %v", model.PriorityHigh, merged.Task.Priority)
+	}
+	if !slices.Contains(merged.Task.Tags, "new") {
+		t.Errorf("expected newer tag add to win, got %v", merged.Task.Tags)
+	}
+	if len(merged.Conflicts) != 2 {
+		t.Fatalf("expected 2 conflicts, got %+v", merged.Conflicts)
+	}
+	for _, conflict := range merged.Conflicts {
+		if conflict.Resolution != model.ResolutionServer {
+			t.Errorf("expected server to win conflict on %v, got %v", conflict.Field, conflict.Resolution)
+		}
+	}
+}
+
+func TestMergeChangesVectorClock(t *testing.T) {
+	handler := createTestHandler()
+	task := addTasks(handler)[0]
+
+	now := time.Now()
+	_, _ = mergeChanges(t, handler, task.Id.String(), fmt.Sprintf(`{"clientId":"a","changes":[
+		{"field":"content","value":"from a","timestamp":%q,"clock":{"a":2,"b":1}}
+	]}`, now.Format(time.RFC3339Nano)))
+
+	tests := []struct {
+		name               string
+		clock              string
+		timestamp          time.Time
+		expectedContent    string
+		expectedConcurrent bool
+		expectedConflicts  int
+	}{
+		{
+			name:              "causally older change loses despite newer timestamp",
+			clock:             `{"a":1,"b":1}`,
+			timestamp:         now.Add(time.Hour),
+			expectedContent:   "from a",
+			expectedConflicts: 1,
+		},
+		{
+			name:               "concurrent change falls back to timestamp",
+			clock:              `{"a":1,"b":2}`,
+			timestamp:          now.Add(time.Hour),
+			expectedContent:    "from b",
+			expectedConcurrent: true,
+			expectedConflicts:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, merged := mergeChanges(t, handler, task.Id.String(), fmt.Sprintf(`{"clientId":"b","changes":[
+				{"field":"content","value":"from b","timestamp":%q,"clock":%s}
+			]}`, tt.timestamp.Format(time.RFC3339Nano), tt.clock))
+
+			if merged.Task.Content != tt.expectedContent {
+				t.Errorf("expected content %v, got %v", tt.expectedContent, merged.Task.Content)
+			}
+			if len(merged.Conflicts) != tt.expectedConflicts {
+				t.Fatalf("expected %d conflicts, got %+v", tt.expectedConflicts, merged.Conflicts)
+			}
+			if merged.Conflicts[0].Concurrent != tt.expectedConcurrent {
+				t.Errorf("expected concurrent %v, got %v", tt.expectedConcurrent, merged.Conflicts[0].Concurrent)
+			}
+		})
+	}
+}
+
+func TestMergeChangesValidation(t *testing.T) {
+	handler := createTestHandler()
+	task := addTasks(handler)[0]
+	now := time.Now().Format(time.RFC3339Nano)
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+	}{
+		{
+			name:           "missing client id",
+			body:           fmt.Sprintf(`{"changes":[{"field":"title","value":"x","timestamp":%q}]}`, now),
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "unknown field",
+			body:           fmt.Sprintf(`{"clientId":"a","changes":[{"field":"owner","value":"x","timestamp":%q}]}`, now),
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "invalid status value",
+			body:           fmt.Sprintf(`{"clientId":"a","changes":[{"field":"status","value":"later","timestamp":%q}]}`, now),
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "empty title",
+			body:           fmt.Sprintf(`{"clientId":"a","changes":[{"field":"title","value":"","timestamp":%q}]}`, now),
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "add operation on scalar field",
+			body:           fmt.Sprintf(`{"clientId":"a","changes":[{"field":"title","op":"add","value":"x","timestamp":%q}]}`, now),
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "invalid json",
+			body:           `{"clientId":`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, _ := mergeChanges(t, handler, task.Id.String(), tt.body)
+			if resp.StatusCode != tt.expectedStatus {
+				t.Errorf("expected status %v, got %v", tt.expectedStatus, resp.StatusCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package handler
+
+import (
+	"bytes"
+	json2 "encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"simple-tasks/internal/codec"
+	"strconv"
+	"strings"
+)
+
+var errUnsupportedMediaType = errors.New("unsupported media type")
+
+// negotiate picks the response format from the Accept header and sets it as
+// the Content-Type, along with the Content-Language of messages. When none of
+// the accepted formats is supported it responds 406 in JSON and returns false.
+func negotiate(w http.ResponseWriter, r *http.Request) bool {
+	w.Header().Add("Vary", "Accept")
+
+	c, ok := acceptedCodec(r.Header.Values("Accept"))
+	if !ok {
+		err := newRequestError("none of the accepted media types is supported, expected one of {0}", strings.Join(contentTypes(), ", "))
+
+		w.Header().Set("Content-Type", codec.JSON.ContentType())
+		writeError(w, r, http.StatusNotAcceptable, errorNotAcceptable, err)
+		return false
+	}
+
+	w.Header().Set("Content-Type", c.ContentType())
+	setContentLanguage(w, r)
+	return true
+}
+
+// errorContentType is the format of the errors of endpoints that serve
+// files, such as exports, where the Accept header is about the file: the
+// accepted format if there is one, and JSON otherwise.
+func errorContentType(r *http.Request) string {
+	if c, ok := acceptedCodec(r.Header.Values("Accept")); ok {
+		return c.ContentType()
+	}
+	return codec.JSON.ContentType()
+}
+
+// encode writes v in the format of the response Content-Type.
+func encode(w http.ResponseWriter, v any) error {
+	mediaType, _, _ := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	c, ok := codec.Lookup(mediaType)
+	if !ok {
+		c = codec.JSON
+	}
+	return c.Encode(w, v)
+}
+
+// decode reads the request body into v in the format of its Content-Type; a
+// body without one is read as JSON. Unsupported formats fail with
+// errUnsupportedMediaType and bodies over maxBodyBytes with a MaxBytesError.
+// The body must be a single value without fields unknown to v, unless the
+// request is lenient.
+func decode(w http.ResponseWriter, r *http.Request, v any) error {
+	c, err := requestCodec(r)
+	if err != nil {
+		return err
+	}
+	data, err := codec.Transcode(c, http.MaxBytesReader(w, r.Body, maxBodyBytes), v)
+	if err != nil {
+		return err
+	}
+
+	decoder := json2.NewDecoder(bytes.NewReader(data))
+	if lenient(r) {
+		return decoder.Decode(v)
+	}
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		// The decoder has no error type for unknown fields.
+		if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+			name, _ := strconv.Unquote(field)
+			return &unknownFieldError{Field: name}
+		}
+		return err
+	}
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return errTrailingData
+	}
+	return nil
+}
+
+func requestCodec(r *http.Request) (codec.Codec, error) {
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" {
+		return codec.JSON, nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, &requestError{Template: "unsupported media type {0}", Args: []string{strconv.Quote(contentType)}, Err: errUnsupportedMediaType}
+	}
+	c, ok := codec.Lookup(mediaType)
+	if !ok {
+		return nil, &requestError{Template: "unsupported media type {0}, expected one of {1}", Args: []string{mediaType, strings.Join(contentTypes(), ", ")}, Err: errUnsupportedMediaType}
+	}
+	return c, nil
+}
+
+// acceptedCodec picks the codec with the highest quality in the Accept
+// header values. Ties go to the most specific media range and then to the
+// order of codec.Codecs, so JSON wins for */*. Without an Accept header the
+// response is JSON.
+func acceptedCodec(accept []string) (codec.Codec, bool) {
+	ranges := parseAccept(accept)
+	if len(ranges) == 0 {
+		return codec.JSON, true
+	}
+
+	var best codec.Codec
+	bestQuality, bestSpecificity := 0.0, 0
+	for _, c := range codec.Codecs() {
+		quality, specificity := 0.0, 0
+		for _, ar := range ranges {
+			if s := ar.matches(c); s > specificity {
+				quality, specificity = ar.quality, s
+			}
+		}
+		if quality > bestQuality || (quality == bestQuality && quality > 0 && specificity > bestSpecificity) {
+			best, bestQuality, bestSpecificity = c, quality, specificity
+		}
+	}
+	return best, best != nil
+}
+
+// acceptsProblem reports whether the client accepts RFC 7807 problem
+// documents for errors.
+func acceptsProblem(r *http.Request) bool {
+	for _, ar := range parseAccept(r.Header.Values("Accept")) {
+		if ar.mediaType == problemContentType && ar.quality > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+type acceptRange struct {
+	mediaType string
+	quality   float64
+}
+
+func parseAccept(accept []string) []acceptRange {
+	ranges := make([]acceptRange, 0)
+	for _, value := range accept {
+		for _, part := range strings.Split(value, ",") {
+			if strings.TrimSpace(part) == "" {
+				continue
+			}
+			mediaType, params, err := mime.ParseMediaType(part)
+			if err != nil {
+				continue
+			}
+
+			quality := 1.0
+			if q, ok := params["q"]; ok {
+				if quality, err = strconv.ParseFloat(q, 64); err != nil || quality < 0 || quality > 1 {
+					continue
+				}
+			}
+			ranges = append(ranges, acceptRange{mediaType: mediaType, quality: quality})
+		}
+	}
+	return ranges
+}
+
+// matches returns how specifically the range matches the codec: 3 for one of
+// its media types, 2 for a type wildcard such as application/* and 1 for
+// */*, or 0 when it doesn't match.
+func (ar acceptRange) matches(c codec.Codec) int {
+	switch {
+	case ar.mediaType == "*/*":
+		return 1
+	case strings.HasSuffix(ar.mediaType, "/*"):
+		if strings.HasPrefix(c.ContentType(), strings.TrimSuffix(ar.mediaType, "*")) {
+			return 2
+		}
+	default:
+		if match, ok := codec.Lookup(ar.mediaType); ok && match == c {
+			return 3
+		}
+	}
+	return 0
+}
+
+func contentTypes() []string {
+	types := make([]string, 0, len(codec.Codecs()))
+	for _, c := range codec.Codecs() {
+		types = append(types, c.ContentType())
+	}
+	return types
+}
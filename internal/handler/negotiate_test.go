@@ -0,0 +1,281 @@
+package handler
+
+import (
+	json2 "encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"simple-tasks/internal/codec"
+	"simple-tasks/internal/model"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestNegotiateResponse(t *testing.T) {
+	handler := createTestHandler()
+	addTasks(handler)
+
+	tests := []struct {
+		name                string
+		accept              string
+		expectedStatus      int
+		expectedContentType string
+	}{
+		{
+			name:                "no accept header",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/json",
+		},
+		{
+			name:                "yaml",
+			accept:              "application/yaml",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/yaml",
+		},
+		{
+			name:                "xml alias",
+			accept:              "text/xml",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/xml",
+		},
+		{
+			name:                "msgpack alias",
+			accept:              "application/x-msgpack",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/msgpack",
+		},
+		{
+			name:                "highest quality",
+			accept:              "application/yaml;q=0.5, application/xml",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/xml",
+		},
+		{
+			name:                "specific range over wildcard",
+			accept:              "*/*, application/yaml",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/yaml",
+		},
+		{
+			name:                "wildcard prefers json",
+			accept:              "text/html, */*;q=0.8",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/json",
+		},
+		{
+			name:                "json excluded",
+			accept:              "application/json;q=0, application/*",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/yaml",
+		},
+		{
+			name:                "not acceptable",
+			accept:              "text/html",
+			expectedStatus:      http.StatusNotAcceptable,
+			expectedContentType: "application/json",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/tasks?sort=title", nil)
+			req.Header.Set("Accept", tt.accept)
+			w := httptest.NewRecorder()
+			handler.GetTasks(w, req)
+
+			resp := w.Result()
+			if resp.StatusCode != tt.expectedStatus {
+				t.Fatalf("expected status %v, got %v", tt.expectedStatus, resp.StatusCode)
+			}
+			contentType := resp.Header.Get("Content-Type")
+			if contentType != tt.expectedContentType {
+				t.Fatalf("expected content type %q, got %q", tt.expectedContentType, contentType)
+			}
+
+			c, _ := codec.Lookup(contentType)
+			if resp.StatusCode != http.StatusOK {
+				var errResp ErrorResponse
+				if err := c.Decode(resp.Body, &errResp); err != nil {
+					t.Fatalf("error reading response body: %v", err)
+				}
+				if errResp.Error.Code != "not_acceptable" {
+					t.Errorf("expected not_acceptable, got %q", errResp.Error.Code)
+				}
+				return
+			}
+
+			var response model.GetTasksResponse
+			if err := c.Decode(resp.Body, &response); err != nil {
+				t.Fatalf("error reading response body: %v", err)
+			}
+			if len(response.Tasks) != len(tasks) || response.Tasks[0].Title == "" || response.Tasks[0].CreatedAt.IsZero() {
+				t.Errorf("expected the tasks to round-trip, got %+v", response)
+			}
+		})
+	}
+}
+
+func TestNegotiateRequest(t *testing.T) {
+	handler := createTestHandler()
+
+	tests := []struct {
+		name           string
+		contentType    string
+		body           string
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name:           "yaml",
+			contentType:    "application/yaml",
+			body:           "title: 2025\npriority: high\ntags:\n  - Work\n  - 42\n",
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "xml",
+			contentType:    "application/xml; charset=utf-8",
+			body:           `<task><title>2025</title><priority>high</priority><tags><tag>Work</tag><tag>42</tag></tags></task>`,
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "msgpack",
+			contentType:    "application/msgpack",
+			body:           "\x83\xa5title\xcd\x07\xe9\xa8priority\xa4high\xa4tags\x92\xa4Work\x2a",
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "structured syntax suffix",
+			contentType:    "application/vnd.tasks+json",
+			body:           `{"title":"2025","priority":"high","tags":["Work","42"]}`,
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "unsupported media type",
+			contentType:    "application/x-www-form-urlencoded",
+			body:           "title=2025",
+			expectedStatus: http.StatusUnsupportedMediaType,
+			expectedCode:   "unsupported_media_type",
+		},
+		{
+			name:           "invalid yaml",
+			contentType:    "application/yaml",
+			body:           "title: [2025",
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "invalid_json",
+		},
+		{
+			name:           "yaml alias",
+			contentType:    "application/yaml",
+			body:           "title: &t 2025\ncontent: *t\n",
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "invalid_json",
+		},
+		{
+			name:           "truncated msgpack",
+			contentType:    "application/msgpack",
+			body:           "\x81\xa5title\xa4Wo",
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "invalid_json",
+		},
+		{
+			name:           "invalid yaml task",
+			contentType:    "application/yaml",
+			body:           "title: Test\nstatus: qwerty\n",
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedCode:   "validation_error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			req.Header.Set("Accept", "application/yaml")
+			w := httptest.NewRecorder()
+			handler.CreateTask(w, req)
+
+			resp := w.Result()
+			if resp.StatusCode != tt.expectedStatus {
+				t.Fatalf("expected status %v, got %v", tt.expectedStatus, resp.StatusCode)
+			}
+			if contentType := resp.Header.Get("Content-Type"); contentType != "application/yaml" {
+				t.Fatalf("expected a yaml response, got %q", contentType)
+			}
+
+			if tt.expectedCode != "" {
+				var errResp ErrorResponse
+				if err := codec.YAML.Decode(resp.Body, &errResp); err != nil {
+					t.Fatalf("error reading response body: %v", err)
+				}
+				if errResp.Error.Code != tt.expectedCode || errResp.Error.Message == "" {
+					t.Errorf("expected %s error, got %+v", tt.expectedCode, errResp)
+				}
+				return
+			}
+
+			var task model.Task
+			if err := codec.YAML.Decode(resp.Body, &task); err != nil {
+				t.Fatalf("error reading response body: %v", err)
+			}
+			if task.Title != "2025" || task.Priority != "high" || !slices.Equal(task.Tags, []string{"work", "42"}) {
+				t.Errorf("unexpected created task %+v", task)
+			}
+		})
+	}
+}
+
+func TestNegotiateErrorsOfFileEndpoints(t *testing.T) {
+	handler := createTestHandler()
+
+	req := httptest.NewRequest(http.MethodGet, "/tasks/export?format=pdf", nil)
+	req.Header.Set("Accept", "text/csv, application/xml;q=0.5")
+	w := httptest.NewRecorder()
+	handler.ExportTasks(w, req)
+
+	resp := w.Result()
+	if resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("expected status %v, got %v", http.StatusUnprocessableEntity, resp.StatusCode)
+	}
+	if contentType := resp.Header.Get("Content-Type"); contentType != "application/xml" {
+		t.Fatalf("expected the error in the accepted format, got %q", contentType)
+	}
+	var errResp ErrorResponse
+	if err := codec.XML.Decode(resp.Body, &errResp); err != nil {
+		t.Fatalf("error reading response body: %v", err)
+	}
+	if errResp.Error.Code != "validation_error" || len(errResp.Error.Details) != 1 {
+		t.Errorf("expected a validation error, got %+v", errResp)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/tasks/export?format=csv", nil)
+	req.Header.Set("Accept", "text/csv")
+	w = httptest.NewRecorder()
+	handler.ExportTasks(w, req)
+	if resp := w.Result(); resp.StatusCode != http.StatusOK {
+		t.Errorf("expected exports to ignore the Accept header, got %v", resp.StatusCode)
+	}
+}
+
+func TestImportTasksXml(t *testing.T) {
+	handler := createTestHandler()
+
+	body := `<?xml version="1.0"?>
+<tasks>
+	<task><externalId>A</externalId><title>One</title><tags><item>work</item></tags></task>
+	<task><title>Two</title><dueDate>soon</dueDate></task>
+</tasks>`
+
+	resp, report := importTasks(t, handler, "", "application/xml", body)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status %v, got %v", http.StatusOK, resp.StatusCode)
+	}
+	if statuses := rowStatuses(report); !slices.Equal(statuses, []string{"created", "failed"}) {
+		t.Fatalf("expected statuses [created failed], got %v", statuses)
+	}
+
+	_, response, _ := getTaskPage(t, handler, "/tasks")
+	if len(response.Tasks) != 1 || response.Tasks[0].ExternalId != "A" || !slices.Equal(response.Tasks[0].Tags, []string{"work"}) {
+		data, _ := json2.Marshal(response.Tasks)
+		t.Errorf("unexpected imported tasks %s", data)
+	}
+}
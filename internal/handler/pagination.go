@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"fmt"
+	"net/url"
+	"simple-tasks/internal/model"
+	"strconv"
+	"strings"
+)
+
+// paginationLinks builds an RFC 8288 Link header value with the next and
+// previous pages of a task list, keeping the other query parameters.
+func paginationLinks(requestUrl *url.URL, response *model.GetTasksResponse) string {
+	links := make([]string, 0, 2)
+	addLink := func(rel string, set map[string]string, del ...string) {
+		query := requestUrl.Query()
+		for _, key := range del {
+			query.Del(key)
+		}
+		for key, value := range set {
+			query.Set(key, value)
+		}
+		link := url.URL{Path: requestUrl.Path, RawQuery: query.Encode()}
+		links = append(links, fmt.Sprintf(`<%s>; rel="%s"`, link.String(), rel))
+	}
+
+	if response.Page != nil && response.TotalPages != nil {
+		page := *response.Page
+		if page < *response.TotalPages {
+			addLink("next", map[string]string{"page": strconv.Itoa(page + 1)})
+		}
+		if page > 1 && *response.TotalPages > 0 {
+			addLink("prev", map[string]string{"page": strconv.Itoa(min(page-1, *response.TotalPages))})
+		}
+	}
+
+	if response.NextCursor != "" {
+		addLink("next", map[string]string{"cursor": response.NextCursor}, "page")
+	}
+	if response.PrevCursor != "" {
+		addLink("prev", map[string]string{"cursor": response.PrevCursor}, "page")
+	}
+
+	return strings.Join(links, ", ")
+}
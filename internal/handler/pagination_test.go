@@ -0,0 +1,132 @@
+package handler
+
+import (
+	json2 "encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"simple-tasks/internal/model"
+	"slices"
+	"testing"
+)
+
+var linkPattern = regexp.MustCompile(`<([^>]*)>; rel="(\w+)"`)
+
+func getTaskPage(t *testing.T, handler *TaskHandler, target string) (*http.Response, model.GetTasksResponse, map[string]string) {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	w := httptest.NewRecorder()
+	handler.GetTasks(w, req)
+	resp := w.Result()
+
+	var response model.GetTasksResponse
+	if resp.StatusCode == http.StatusOK {
+		if err := json2.NewDecoder(resp.Body).Decode(&response); err != nil {
+			t.Fatalf("error reading response body: %v", err)
+		}
+	}
+
+	links := make(map[string]string)
+	for _, match := range linkPattern.FindAllStringSubmatch(resp.Header.Get("Link"), -1) {
+		links[match[2]] = match[1]
+	}
+	return resp, response, links
+}
+
+func taskIds(tasks []model.Task) []string {
+	ids := make([]string, 0, len(tasks))
+	for _, task := range tasks {
+		ids = append(ids, task.Id.String())
+	}
+	return ids
+}
+
+func TestGetTasksCursorPagination(t *testing.T) {
+	handler := createTestHandler()
+	addTasks(handler)
+
+	_, all, _ := getTaskPage(t, handler, "/tasks")
+	allIds := taskIds(all.Tasks)
+
+	forward := make([]string, 0, len(allIds))
+	target := "/tasks?pageSize=3&status=todo"
+	_, filtered, _ := getTaskPage(t, handler, "/tasks?status=todo")
+	pages := 0
+	var lastLinks map[string]string
+	for target != "" {
+		_, page, links := getTaskPage(t, handler, target)
+		forward = append(forward, taskIds(page.Tasks)...)
+		if page.NextCursor != "" {
+			next, _ := url.Parse(links["next"])
+			if next.Query().Get("cursor") != page.NextCursor || next.Query().Get("status") != "todo" {
+				t.Errorf("expected next link with cursor and filters, got %v", links["next"])
+			}
+		}
+		target = links["next"]
+		lastLinks = links
+		pages++
+	}
+
+	if !slices.Equal(forward, taskIds(filtered.Tasks)) {
+		t.Errorf("expected cursor pages to cover %v, got %v", taskIds(filtered.Tasks), forward)
+	}
+	if pages != 2 {
+		t.Errorf("expected 2 pages, got %d", pages)
+	}
+
+	_, prev, _ := getTaskPage(t, handler, lastLinks["prev"])
+	if !slices.Equal(taskIds(prev.Tasks), forward[:3]) {
+		t.Errorf("expected previous page %v, got %v", forward[:3], taskIds(prev.Tasks))
+	}
+}
+
+func TestGetTasksPagePagination(t *testing.T) {
+	handler := createTestHandler()
+	addTasks(handler)
+
+	_, all, _ := getTaskPage(t, handler, "/tasks")
+	allIds := taskIds(all.Tasks)
+
+	pagesIds := make([]string, 0, len(allIds))
+	for _, page := range []string{"1", "2", "3"} {
+		_, response, links := getTaskPage(t, handler, "/tasks?pageSize=3&page="+page)
+		pagesIds = append(pagesIds, taskIds(response.Tasks)...)
+
+		if page != "3" && links["next"] == "" {
+			t.Errorf("expected next link on page %s", page)
+		}
+		if page != "1" && links["prev"] == "" {
+			t.Errorf("expected prev link on page %s", page)
+		}
+	}
+
+	if !slices.Equal(pagesIds, allIds) {
+		t.Errorf("expected pages to cover %v in order, got %v", allIds, pagesIds)
+	}
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+	}{
+		{
+			name:           "invalid cursor",
+			query:          "?cursor=garbage",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "zero page",
+			query:          "?page=0&pageSize=3",
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, _, _ := getTaskPage(t, handler, "/tasks"+tt.query)
+			if resp.StatusCode != tt.expectedStatus {
+				t.Errorf("expected status %v, got %v", tt.expectedStatus, resp.StatusCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"net/url"
+	"simple-tasks/internal/filter"
+	"simple-tasks/internal/model"
+	"strings"
+)
+
+// parseTaskFilters reads the task filter parameters shared by the task list
+// and the event stream. Paging, sorting and the filter expression are left
+// to the caller.
+func parseTaskFilters(query url.Values) *model.GetTasksRequest {
+	return &model.GetTasksRequest{
+		Status:        multiValue(query["status"]),
+		Priority:      multiValue(query["priority"]),
+		Tags:          model.NormalizeTags(query["tag"]),
+		TagMode:       query.Get("tagMode"),
+		Q:             query.Get("q"),
+		DueBefore:     query.Get("dueBefore"),
+		DueAfter:      query.Get("dueAfter"),
+		CreatedBefore: query.Get("createdBefore"),
+		CreatedAfter:  query.Get("createdAfter"),
+		UpdatedSince:  query.Get("updatedSince"),
+		Overdue:       query.Get("overdue"),
+		HasDueDate:    query.Get("hasDueDate"),
+	}
+}
+
+// multiValue accepts both repeated parameters and comma-separated lists.
+func multiValue(values []string) []string {
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				result = append(result, part)
+			}
+		}
+	}
+	return result
+}
+
+// parseTasksRequest reads and validates a complete task list query: filters,
+// filter expression, sort and paging. Saved views store the same query
+// string, so they go through here as well.
+func parseTasksRequest(query url.Values) (*model.GetTasksRequest, error) {
+	req := parseTaskFilters(query)
+	req.Sort = query.Get("sort")
+	req.Cursor = query.Get("cursor")
+
+	var errs paramErrors
+	var pageErr, pageSizeErr *paramError
+	if req.Page, pageErr = intParam(query, "page"); pageErr != nil {
+		errs = append(errs, pageErr)
+	}
+	if req.PageSize, pageSizeErr = intParam(query, "pageSize"); pageSizeErr != nil {
+		errs = append(errs, pageSizeErr)
+	}
+	if errs != nil {
+		return nil, errs
+	}
+
+	if err := validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	if filterStr := query.Get("filter"); filterStr != "" {
+		expr, err := filter.Parse(filterStr)
+		if err != nil {
+			return nil, err
+		}
+		req.Filter = expr
+	}
+
+	return req, nil
+}
@@ -0,0 +1,125 @@
+package handler
+
+import (
+	json2 "encoding/json"
+	"net/url"
+	"simple-tasks/internal/model"
+	"strings"
+)
+
+// expansions holds the related data that can be embedded into task
+// responses with expand=, keyed by the name used in the parameter and in the
+// response.
+var expansions = map[string]func(task *model.Task) any{
+	// subtasks counts the Markdown checklist items of the content.
+	"subtasks": func(task *model.Task) any {
+		return countSubtasks(task.Content)
+	},
+}
+
+// subtaskCounts are the checklist items of a task, such as "- [ ] call" and
+// "- [x] write".
+type subtaskCounts struct {
+	Total int `json:"total"`
+	Done  int `json:"done"`
+}
+
+func countSubtasks(content string) subtaskCounts {
+	var counts subtaskCounts
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) < 2 || !strings.ContainsRune("-*+", rune(line[0])) || line[1] != ' ' {
+			continue
+		}
+		switch item := strings.TrimLeft(line[2:], " "); {
+		case strings.HasPrefix(item, "[ ]"):
+			counts.Total++
+		case strings.HasPrefix(item, "[x]"), strings.HasPrefix(item, "[X]"):
+			counts.Total++
+			counts.Done++
+		}
+	}
+	return counts
+}
+
+// taskShape selects the fields and expansions of task responses. The zero
+// value returns tasks as they are.
+type taskShape struct {
+	Fields []string `validate:"dive,oneof=id title content status priority tags dueDate createdAt updatedAt completedAt externalId"`
+	Expand []string `validate:"dive,expansion"`
+}
+
+type shapedTasksResponse struct {
+	*model.GetTasksResponse
+	Tasks []map[string]json2.RawMessage `json:"items,omitempty"`
+}
+
+func parseTaskShape(query url.Values) (*taskShape, error) {
+	shape := &taskShape{
+		Fields: multiValue(query["fields"]),
+		Expand: multiValue(query["expand"]),
+	}
+	if err := validate.Struct(shape); err != nil {
+		return nil, err
+	}
+	return shape, nil
+}
+
+func (s *taskShape) empty() bool {
+	return len(s.Fields) == 0 && len(s.Expand) == 0
+}
+
+func (s *taskShape) task(task *model.Task) (any, error) {
+	if s.empty() {
+		return task, nil
+	}
+
+	data, err := json2.Marshal(task)
+	if err != nil {
+		return nil, err
+	}
+	var all map[string]json2.RawMessage
+	if err := json2.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+
+	shaped := all
+	if len(s.Fields) > 0 {
+		shaped = make(map[string]json2.RawMessage, len(s.Fields)+len(s.Expand))
+		for _, field := range s.Fields {
+			if value, ok := all[field]; ok {
+				shaped[field] = value
+			}
+		}
+	}
+
+	for _, name := range s.Expand {
+		value, err := json2.Marshal(expansions[name](task))
+		if err != nil {
+			return nil, err
+		}
+		shaped[name] = value
+	}
+
+	return shaped, nil
+}
+
+func (s *taskShape) tasks(response *model.GetTasksResponse) (any, error) {
+	if s.empty() {
+		return response, nil
+	}
+
+	shaped := &shapedTasksResponse{
+		GetTasksResponse: response,
+		Tasks:            make([]map[string]json2.RawMessage, 0, len(response.Tasks)),
+	}
+	for i := range response.Tasks {
+		task, err := s.task(&response.Tasks[i])
+		if err != nil {
+			return nil, err
+		}
+		shaped.Tasks = append(shaped.Tasks, task.(map[string]json2.RawMessage))
+	}
+
+	return shaped, nil
+}
@@ -0,0 +1,162 @@
+package handler
+
+import (
+	json2 "encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestTaskFieldsAndExpand(t *testing.T) {
+	handler := createTestHandler()
+	addTasks(handler)
+	task := createTask(t, handler, `{"title":"Shaped","content":"long content","tags":["a"]}`)
+
+	tests := []struct {
+		name           string
+		target         string
+		byId           bool
+		expectedKeys   []string
+		expectedStatus int
+	}{
+		{
+			name:           "list with fields",
+			target:         "/tasks?fields=id,title,status&pageSize=3",
+			expectedKeys:   []string{"id", "status", "title"},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "repeated fields parameter",
+			target:         "/tasks?fields=id&fields=dueDate&q=Shaped",
+			expectedKeys:   []string{"id"},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "single task with fields and expand",
+			target:         "/tasks/" + task.Id.String() + "?fields=title&expand=subtasks",
+			byId:           true,
+			expectedKeys:   []string{"subtasks", "title"},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "expand keeps every field",
+			target: "/tasks/" + task.Id.String() + "?expand=subtasks",
+			byId:   true,
+			expectedKeys: []string{
+				"content", "createdAt", "id", "priority", "status",
+				"subtasks", "tags", "title", "updatedAt",
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "unknown field",
+			target:         "/tasks?fields=id,owner",
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "unknown expansion",
+			target:         "/tasks/" + task.Id.String() + "?expand=comments",
+			byId:           true,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "versions are internal",
+			target:         "/tasks/" + task.Id.String() + "?expand=versions",
+			byId:           true,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			w := httptest.NewRecorder()
+			if tt.byId {
+				req.SetPathValue("id", task.Id.String())
+				handler.GetTaskById(w, req)
+			} else {
+				handler.GetTasks(w, req)
+			}
+
+			resp := w.Result()
+			if resp.StatusCode != tt.expectedStatus {
+				t.Fatalf("expected status %v, got %v", tt.expectedStatus, resp.StatusCode)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			items := make([]map[string]any, 0)
+			if tt.byId {
+				var item map[string]any
+				if err := json2.NewDecoder(resp.Body).Decode(&item); err != nil {
+					t.Fatalf("error reading response body: %v", err)
+				}
+				items = append(items, item)
+			} else {
+				var response struct {
+					Items []map[string]any `json:"items"`
+					Total int              `json:"total"`
+				}
+				if err := json2.NewDecoder(resp.Body).Decode(&response); err != nil {
+					t.Fatalf("error reading response body: %v", err)
+				}
+				if response.Total == 0 {
+					t.Errorf("expected list metadata to be kept")
+				}
+				items = response.Items
+			}
+
+			if len(items) == 0 {
+				t.Fatalf("expected tasks in the response")
+			}
+			for _, item := range items {
+				keys := slices.Sorted(maps.Keys(item))
+				if !slices.Equal(keys, tt.expectedKeys) {
+					t.Errorf("expected keys %v, got %v", tt.expectedKeys, keys)
+				}
+			}
+		})
+	}
+}
+
+func TestExpandSubtasks(t *testing.T) {
+	handler := createTestHandler()
+	task := createTask(t, handler, `{"title":"Release","content":"Steps:\n- [x] tag\n- [ ] build\n  * [X] notes\n- not a subtask\n[ ] neither"}`)
+	createTask(t, handler, `{"title":"Plain"}`)
+
+	type expanded struct {
+		Title    string        `json:"title"`
+		Subtasks subtaskCounts `json:"subtasks"`
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/tasks/"+task.Id.String()+"?expand=subtasks", nil)
+	req.SetPathValue("id", task.Id.String())
+	w := httptest.NewRecorder()
+	handler.GetTaskById(w, req)
+
+	var single expanded
+	if err := json2.NewDecoder(w.Result().Body).Decode(&single); err != nil {
+		t.Fatalf("error reading response body: %v", err)
+	}
+	if single.Subtasks != (subtaskCounts{Total: 3, Done: 2}) {
+		t.Errorf("expected 2 of 3 subtasks done, got %+v", single.Subtasks)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/tasks?sort=title&expand=subtasks", nil)
+	w = httptest.NewRecorder()
+	handler.GetTasks(w, req)
+
+	var list struct {
+		Items []expanded `json:"items"`
+	}
+	if err := json2.NewDecoder(w.Result().Body).Decode(&list); err != nil {
+		t.Fatalf("error reading response body: %v", err)
+	}
+	expected := []expanded{{Title: "Plain"}, {Title: "Release", Subtasks: subtaskCounts{Total: 3, Done: 2}}}
+	if !slices.Equal(list.Items, expected) {
+		t.Errorf("expected %+v, got %+v", expected, list.Items)
+	}
+}
@@ -0,0 +1,105 @@
+package handler
+
+import (
+	json2 "encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"simple-tasks/internal/model"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func createTask(t *testing.T, handler *TaskHandler, body string) model.Task {
+	req := httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	handler.CreateTask(w, req)
+
+	resp := w.Result()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected status %v, got %v", http.StatusCreated, resp.StatusCode)
+	}
+
+	var task model.Task
+	if err := json2.NewDecoder(resp.Body).Decode(&task); err != nil {
+		t.Fatalf("error reading response body: %v", err)
+	}
+	return task
+}
+
+func TestGetTasksSort(t *testing.T) {
+	handler := createTestHandler()
+	addTasks(handler)
+
+	for _, body := range []string{
+		`{"title":"apple","priority":"normal","dueDate":"2025-10-01T00:00:00Z"}`,
+		`{"title":"Banana","priority":"normal","dueDate":"2025-09-01T00:00:00Z"}`,
+	} {
+		createTask(t, handler, body)
+	}
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedTitles []string
+		expectedStatus int
+	}{
+		{
+			name:  "priority by rank then title",
+			query: "?sort=priority:desc,title:asc&pageSize=5",
+			expectedTitles: []string{
+				"Купить машину", "Подготовить отчет",
+				"apple", "Banana", "Погулять с друзьями",
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "due date with nulls last",
+			query:          "?sort=dueDate:desc&pageSize=3",
+			expectedTitles: []string{"apple", "Banana", "Купить молоко"},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "legacy priority desc",
+			query:          "?sort=priority,desc&status=in_progress",
+			expectedTitles: []string{"Купить машину", "Подготовить отчет"},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "sort applies before pagination",
+			query:          "?sort=title&page=2&pageSize=2",
+			expectedTitles: []string{"Купить машину", "Купить молоко"},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "unknown field",
+			query:          "?sort=owner:asc",
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "unknown direction",
+			query:          "?sort=title:up",
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, response, _ := getTaskPage(t, handler, "/tasks"+tt.query)
+			if resp.StatusCode != tt.expectedStatus {
+				t.Fatalf("expected status %v, got %v", tt.expectedStatus, resp.StatusCode)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			actualTitles := make([]string, 0, len(response.Tasks))
+			for _, task := range response.Tasks {
+				actualTitles = append(actualTitles, task.Title)
+			}
+			if !slices.Equal(actualTitles, tt.expectedTitles) {
+				t.Errorf("expected titles %v, got %v", tt.expectedTitles, actualTitles)
+			}
+		})
+	}
+}
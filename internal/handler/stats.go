@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+	"simple-tasks/internal/model"
+	"strconv"
+	"time"
+)
+
+func (h *TaskHandler) GetStats(w http.ResponseWriter, r *http.Request) {
+	if !negotiate(w, r) {
+		return
+	}
+
+	if err := checkQuery(r, taskListParams, statsParams); err != nil {
+		h.log.ErrorContext(r.Context(), "invalid query", slog.String("error", err.Error()))
+
+		writeError(w, r, http.StatusUnprocessableEntity, errorValidation, err)
+		return
+	}
+
+	query := r.URL.Query()
+	req, err := parseTasksRequest(query)
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "invalid request", slog.String("error", err.Error()))
+
+		writeError(w, r, http.StatusUnprocessableEntity, errorValidation, err)
+		return
+	}
+
+	statsReq := &model.StatsRequest{
+		GroupBy: multiValue(query["groupBy"]),
+		From:    query.Get("from"),
+		To:      query.Get("to"),
+	}
+	if err := validate.Struct(statsReq); err != nil {
+		h.log.ErrorContext(r.Context(), "invalid request", slog.String("error", err.Error()))
+
+		writeError(w, r, http.StatusUnprocessableEntity, errorValidation, err)
+		return
+	}
+
+	from, to := statsReq.Range(time.Now())
+	if days := to.Sub(from).Hours() / 24; days < 1 || days > model.MaxStatsDays {
+		err := newRequestError("stats range must cover 1 to {0}", count(translations.GetFallback(), "day", strconv.Itoa(model.MaxStatsDays)))
+		h.log.ErrorContext(r.Context(), "invalid request", slog.String("error", err.Error()))
+
+		writeError(w, r, http.StatusUnprocessableEntity, errorValidation, err)
+		return
+	}
+
+	stats, err := h.service.GetStats(r.Context(), req, statsReq)
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "internal error", slog.String("error", err.Error()))
+
+		writeError(w, r, http.StatusInternalServerError, errorInternal, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = encode(w, stats)
+}
@@ -0,0 +1,125 @@
+package handler
+
+import (
+	json2 "encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"simple-tasks/internal/model"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetStats(t *testing.T) {
+	handler := createTestHandler()
+	addTasks(handler)
+	createTask(t, handler, `{"title":"Late","dueDate":"2025-09-15T10:00:00Z"}`)
+	future := createTask(t, handler, `{"title":"Future","dueDate":"2099-01-05T10:00:00Z"}`)
+
+	req := httptest.NewRequest(http.MethodPatch, "/tasks/", strings.NewReader(`{"status":"done"}`))
+	req.SetPathValue("id", future.Id.String())
+	handler.UpdateTask(httptest.NewRecorder(), req)
+
+	today := time.Now().UTC().Format(time.DateOnly)
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		check          func(t *testing.T, stats model.TaskStats)
+	}{
+		{
+			name:           "grouped counts",
+			query:          "?groupBy=status,priority&groupBy=tag,dueWeek",
+			expectedStatus: http.StatusOK,
+			check: func(t *testing.T, stats model.TaskStats) {
+				if stats.Total != 9 || stats.Overdue != 1 {
+					t.Errorf("expected 9 tasks with 1 overdue, got %d with %d", stats.Total, stats.Overdue)
+				}
+				expected := map[string]map[string]int{
+					"status":   {"todo": 5, "in_progress": 2, "done": 2},
+					"priority": {"low": 4, "normal": 3, "high": 2},
+					"dueWeek":  {"2025-W38": 1, "2099-W02": 1, model.NoDueWeek: 7},
+				}
+				for groupBy, counts := range expected {
+					if !maps.Equal(stats.Groups[groupBy], counts) {
+						t.Errorf("expected %s counts %v, got %v", groupBy, counts, stats.Groups[groupBy])
+					}
+				}
+				if stats.Groups["tag"]["покупки"] != 2 || stats.Groups["tag"]["todo_tag"] != 2 {
+					t.Errorf("unexpected tag counts %v", stats.Groups["tag"])
+				}
+			},
+		},
+		{
+			name:           "daily counts for the last week",
+			query:          "",
+			expectedStatus: http.StatusOK,
+			check: func(t *testing.T, stats model.TaskStats) {
+				if len(stats.Daily) != 7 {
+					t.Fatalf("expected 7 days, got %d", len(stats.Daily))
+				}
+				last := stats.Daily[6]
+				if last.Date != today || last.Created != 9 || last.Completed != 2 {
+					t.Errorf("expected 9 created and 2 completed on %s, got %+v", today, last)
+				}
+				if stats.Groups != nil {
+					t.Errorf("expected no groups, got %v", stats.Groups)
+				}
+			},
+		},
+		{
+			name:           "filters and explicit range",
+			query:          "?status=done&from=" + today + "&to=" + today,
+			expectedStatus: http.StatusOK,
+			check: func(t *testing.T, stats model.TaskStats) {
+				if stats.Total != 2 || len(stats.Daily) != 1 || stats.Daily[0].Completed != 2 {
+					t.Errorf("expected 2 done tasks completed today, got %+v", stats)
+				}
+			},
+		},
+		{
+			name:           "unknown group",
+			query:          "?groupBy=owner",
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "invalid date",
+			query:          "?from=2025-13-01",
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "range too long",
+			query:          "?from=2024-01-01&to=2025-12-31",
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "reversed range",
+			query:          "?from=2025-10-02&to=2025-10-01",
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/tasks/stats"+tt.query, nil)
+			w := httptest.NewRecorder()
+			handler.GetStats(w, req)
+
+			resp := w.Result()
+			if resp.StatusCode != tt.expectedStatus {
+				t.Fatalf("expected status %v, got %v", tt.expectedStatus, resp.StatusCode)
+			}
+			if tt.check == nil {
+				return
+			}
+
+			var stats model.TaskStats
+			if err := json2.NewDecoder(resp.Body).Decode(&stats); err != nil {
+				t.Fatalf("error reading response body: %v", err)
+			}
+			tt.check(t, stats)
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"errors"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/google/uuid"
+	"net/http"
+	"net/url"
+	"simple-tasks/internal/middleware"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// maxBodyBytes limits the request bodies of the API; imports have their own
+// limit.
+const maxBodyBytes = 1 << 20
+
+var errTrailingData = errors.New("request body has data after the first value")
+
+// unknownFieldError is a body field that the request type doesn't have.
+type unknownFieldError struct {
+	Field string
+}
+
+func (e *unknownFieldError) Error() string {
+	return "unknown field " + strconv.Quote(e.Field)
+}
+
+// paramError is an invalid or unknown query parameter.
+type paramError struct {
+	Name string
+	// Rule is "unknown" for parameters the endpoint doesn't take, or the
+	// type the value doesn't parse as.
+	Rule  string
+	Value string
+}
+
+// paramErrors are the invalid query parameters of a request, reported with
+// a detail entry each.
+type paramErrors []*paramError
+
+func (e paramErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, paramMessage(translations.GetFallback(), err))
+	}
+	return strings.Join(messages, "; ")
+}
+
+func paramMessage(trans ut.Translator, err *paramError) string {
+	if err.Rule == "unknown" {
+		return localize(trans, "{0} is not a known query parameter", err.Name)
+	}
+	return localize(trans, "{0} must be an integer", err.Name)
+}
+
+// lenient reports whether the request is handled in compatibility mode,
+// which ignores unknown body fields, trailing body data and unknown query
+// parameters.
+func lenient(r *http.Request) bool {
+	lenient, _ := r.Context().Value(middleware.LenientHandling).(bool)
+	return lenient
+}
+
+// Query parameters of the endpoints, by their use.
+var (
+	taskFilterParams = []string{"status", "priority", "tag", "tagMode", "q", "dueBefore", "dueAfter",
+		"createdBefore", "createdAfter", "updatedSince", "overdue", "hasDueDate"}
+	taskListParams  = slices.Concat(taskFilterParams, []string{"sort", "cursor", "page", "pageSize", "filter"})
+	taskShapeParams = []string{"fields", "expand"}
+	statsParams     = []string{"groupBy", "from", "to"}
+	syncParams      = []string{"since", "limit"}
+	tagListParams   = []string{"prefix", "limit"}
+	importParams    = []string{"dryRun", "mapping"}
+	importJobParams = []string{"source", "lists"}
+)
+
+// checkQuery rejects the query parameters of a request that are not among
+// params, unless the request is lenient.
+func checkQuery(r *http.Request, params ...[]string) error {
+	if lenient(r) {
+		return nil
+	}
+	return checkParams(r.URL.Query(), params...)
+}
+
+// checkParams rejects the parameters of query that are not among params.
+func checkParams(query url.Values, params ...[]string) error {
+	var errs paramErrors
+	for name, values := range query {
+		if !slices.ContainsFunc(params, func(known []string) bool { return slices.Contains(known, name) }) {
+			errs = append(errs, &paramError{Name: name, Rule: "unknown", Value: strings.Join(values, ",")})
+		}
+	}
+	if errs == nil {
+		return nil
+	}
+	slices.SortFunc(errs, func(a, b *paramError) int { return strings.Compare(a.Name, b.Name) })
+	return errs
+}
+
+// intParam reads an optional integer query parameter; it is nil when the
+// parameter is missing.
+func intParam(query url.Values, name string) (*int, *paramError) {
+	value := query.Get(name)
+	if value == "" {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, &paramError{Name: name, Rule: "integer", Value: value}
+	}
+	return &n, nil
+}
+
+// pathId reads the id of the resource in the path.
+func pathId(r *http.Request) (uuid.UUID, error) {
+	value := r.PathValue("id")
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.Nil, newRequestError("{0} is not a valid id", strconv.Quote(value))
+	}
+	return id, nil
+}
+
+// writeDecodeError responds with an error returned by decode: 415 for an
+// unsupported format, 413 for a body over the limit and 400 otherwise.
+func writeDecodeError(w http.ResponseWriter, r *http.Request, err error) {
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.Is(err, errUnsupportedMediaType):
+		writeError(w, r, http.StatusUnsupportedMediaType, errorUnsupportedMediaType, err)
+	case errors.As(err, &maxBytesErr):
+		writeError(w, r, http.StatusRequestEntityTooLarge, errorPayloadTooLarge, err)
+	default:
+		writeError(w, r, http.StatusBadRequest, errorInvalidJson, err)
+	}
+}
@@ -0,0 +1,234 @@
+package handler
+
+import (
+	json2 "encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"simple-tasks/internal/middleware"
+	"strings"
+	"testing"
+)
+
+func TestStrictBodies(t *testing.T) {
+	handler := createTestHandler()
+
+	tests := []struct {
+		name            string
+		requestBody     string
+		prefer          string
+		expectedStatus  int
+		expectedCode    string
+		expectedMessage string
+		expectedDetails []ErrorDetail
+	}{
+		{
+			name:           "known fields",
+			requestBody:    `{"title":"Test","priority":"high"}` + "\n",
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:            "unknown field",
+			requestBody:     `{"title":"Test","colour":"red"}`,
+			expectedStatus:  http.StatusBadRequest,
+			expectedCode:    "invalid_json",
+			expectedMessage: "colour is not a known field",
+			expectedDetails: []ErrorDetail{{Field: "colour", Rule: "unknown", Message: "colour is not a known field"}},
+		},
+		{
+			name:            "several objects",
+			requestBody:     `{"title":"One"}{"title":"Two"}`,
+			expectedStatus:  http.StatusBadRequest,
+			expectedCode:    "invalid_json",
+			expectedMessage: "request body has data after the first value",
+		},
+		{
+			name:            "trailing garbage",
+			requestBody:     `{"title":"Test"} ]`,
+			expectedStatus:  http.StatusBadRequest,
+			expectedCode:    "invalid_json",
+			expectedMessage: "request body has data after the first value",
+		},
+		{
+			name:            "array",
+			requestBody:     `[{"title":"Test"}]`,
+			expectedStatus:  http.StatusBadRequest,
+			expectedCode:    "invalid_json",
+			expectedMessage: "body must be an object",
+		},
+		{
+			name:            "too large",
+			requestBody:     `{"title":"Test","content":"` + strings.Repeat("a", maxBodyBytes) + `"}`,
+			expectedStatus:  http.StatusRequestEntityTooLarge,
+			expectedCode:    "payload_too_large",
+			expectedMessage: "request body is larger than 1048576 bytes",
+		},
+		{
+			name:           "lenient unknown field",
+			requestBody:    `{"title":"Test","colour":"red"}`,
+			prefer:         "handling=lenient",
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "lenient trailing garbage",
+			requestBody:    `{"title":"Test"} ]`,
+			prefer:         "respond-async, handling=lenient",
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "lenient too large",
+			requestBody:    `{"title":"Test","content":"` + strings.Repeat("a", maxBodyBytes) + `"}`,
+			prefer:         "handling=lenient",
+			expectedStatus: http.StatusRequestEntityTooLarge,
+			expectedCode:   "payload_too_large",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(tt.requestBody))
+			req.Header.Set("Prefer", tt.prefer)
+			w := httptest.NewRecorder()
+			middleware.HandlingMiddleware(false, http.HandlerFunc(handler.CreateTask)).ServeHTTP(w, req)
+
+			resp := w.Result()
+			if resp.StatusCode != tt.expectedStatus {
+				t.Fatalf("expected status %v, got %v", tt.expectedStatus, resp.StatusCode)
+			}
+			if tt.prefer != "" && resp.Header.Get("Preference-Applied") != "handling=lenient" {
+				t.Errorf("expected the lenient preference to apply, got %q", resp.Header.Get("Preference-Applied"))
+			}
+			if tt.expectedCode == "" {
+				return
+			}
+
+			var errResp ErrorResponse
+			if err := json2.NewDecoder(resp.Body).Decode(&errResp); err != nil {
+				t.Fatalf("error reading response body: %v", err)
+			}
+			if errResp.Error.Code != tt.expectedCode {
+				t.Errorf("expected code %q, got %q", tt.expectedCode, errResp.Error.Code)
+			}
+			if tt.expectedMessage != "" && errResp.Error.Message != tt.expectedMessage {
+				t.Errorf("expected message %q, got %q", tt.expectedMessage, errResp.Error.Message)
+			}
+			if tt.expectedDetails == nil {
+				return
+			}
+			if len(errResp.Error.Details) != len(tt.expectedDetails) {
+				t.Fatalf("expected details %+v, got %+v", tt.expectedDetails, errResp.Error.Details)
+			}
+			for i, detail := range tt.expectedDetails {
+				if got := errResp.Error.Details[i]; got.Field != detail.Field || got.Rule != detail.Rule || got.Message != detail.Message {
+					t.Errorf("expected detail %+v, got %+v", detail, got)
+				}
+			}
+		})
+	}
+}
+
+func TestStrictQueries(t *testing.T) {
+	handler := createTestHandler()
+	addTasks(handler)
+
+	tests := []struct {
+		name            string
+		target          string
+		handle          http.HandlerFunc
+		lenient         bool
+		expectedStatus  int
+		expectedDetails []ErrorDetail
+	}{
+		{
+			name:           "known params",
+			target:         "/tasks?status=todo&sort=title&page=1&pageSize=2&fields=id",
+			handle:         handler.GetTasks,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "unknown params",
+			target:         "/tasks?stauts=todo&pagesize=2",
+			handle:         handler.GetTasks,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedDetails: []ErrorDetail{
+				{Field: "pagesize", Rule: "unknown", Message: "pagesize is not a known query parameter"},
+				{Field: "stauts", Rule: "unknown", Message: "stauts is not a known query parameter"},
+			},
+		},
+		{
+			name:           "non-numeric paging",
+			target:         "/tasks?page=first&pageSize=ten",
+			handle:         handler.GetTasks,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedDetails: []ErrorDetail{
+				{Field: "page", Rule: "integer", Message: "page must be an integer"},
+				{Field: "pageSize", Rule: "integer", Message: "pageSize must be an integer"},
+			},
+		},
+		{
+			name:            "list params of a single task",
+			target:          "/tasks/00000000-0000-0000-0000-000000000000?sort=title",
+			handle:          handler.GetTaskById,
+			expectedStatus:  http.StatusUnprocessableEntity,
+			expectedDetails: []ErrorDetail{{Field: "sort", Rule: "unknown", Message: "sort is not a known query parameter"}},
+		},
+		{
+			name:            "non-numeric sync limit",
+			target:          "/sync?limit=all",
+			handle:          handler.Sync,
+			expectedStatus:  http.StatusUnprocessableEntity,
+			expectedDetails: []ErrorDetail{{Field: "limit", Rule: "integer", Message: "limit must be an integer"}},
+		},
+		{
+			name:            "export format",
+			target:          "/tasks/export?format=json&fromat=csv",
+			handle:          handler.ExportTasks,
+			expectedStatus:  http.StatusUnprocessableEntity,
+			expectedDetails: []ErrorDetail{{Field: "fromat", Rule: "unknown", Message: "fromat is not a known query parameter"}},
+		},
+		{
+			name:           "lenient unknown params",
+			target:         "/tasks?stauts=todo&utm_source=mail",
+			handle:         handler.GetTasks,
+			lenient:        true,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:            "lenient non-numeric paging",
+			target:          "/tasks?page=first",
+			handle:          handler.GetTasks,
+			lenient:         true,
+			expectedStatus:  http.StatusUnprocessableEntity,
+			expectedDetails: []ErrorDetail{{Field: "page", Rule: "integer", Message: "page must be an integer"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			req.SetPathValue("id", "00000000-0000-0000-0000-000000000000")
+			w := httptest.NewRecorder()
+			middleware.HandlingMiddleware(tt.lenient, tt.handle).ServeHTTP(w, req)
+
+			resp := w.Result()
+			if resp.StatusCode != tt.expectedStatus {
+				t.Fatalf("expected status %v, got %v", tt.expectedStatus, resp.StatusCode)
+			}
+			if tt.expectedDetails == nil {
+				return
+			}
+
+			var errResp ErrorResponse
+			if err := json2.NewDecoder(resp.Body).Decode(&errResp); err != nil {
+				t.Fatalf("error reading response body: %v", err)
+			}
+			if errResp.Error.Code != "validation_error" || len(errResp.Error.Details) != len(tt.expectedDetails) {
+				t.Fatalf("expected details %+v, got %+v", tt.expectedDetails, errResp.Error)
+			}
+			for i, detail := range tt.expectedDetails {
+				if got := errResp.Error.Details[i]; got.Field != detail.Field || got.Rule != detail.Rule || got.Message != detail.Message {
+					t.Errorf("expected detail %+v, got %+v", detail, got)
+				}
+			}
+		})
+	}
+}
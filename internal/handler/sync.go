@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+	"simple-tasks/internal/model"
+)
+
+func (h *TaskHandler) Sync(w http.ResponseWriter, r *http.Request) {
+	if !negotiate(w, r) {
+		return
+	}
+
+	if err := checkQuery(r, syncParams); err != nil {
+		h.log.ErrorContext(r.Context(), "invalid query", slog.String("error", err.Error()))
+
+		writeError(w, r, http.StatusUnprocessableEntity, errorValidation, err)
+		return
+	}
+
+	query := r.URL.Query()
+	req := &model.SyncRequest{
+		Since: query.Get("since"),
+	}
+
+	limit, paramErr := intParam(query, "limit")
+	if paramErr != nil {
+		err := paramErrors{paramErr}
+		h.log.ErrorContext(r.Context(), "invalid limit", slog.String("error", err.Error()))
+
+		writeError(w, r, http.StatusUnprocessableEntity, errorValidation, err)
+		return
+	}
+	req.Limit = limit
+
+	if err := validate.Struct(req); err != nil {
+		h.log.ErrorContext(r.Context(), "invalid request", slog.String("error", err.Error()))
+
+		writeError(w, r, http.StatusUnprocessableEntity, errorValidation, err)
+		return
+	}
+
+	changes, err := h.service.Sync(r.Context(), req)
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "sync failed", slog.String("error", err.Error()))
+
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = encode(w, changes)
+}
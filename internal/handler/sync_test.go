@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"encoding/base64"
+	json2 "encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"simple-tasks/internal/model"
+	"strings"
+	"testing"
+)
+
+func syncTasks(t *testing.T, handler *TaskHandler, query string) (*http.Response, model.SyncResponse) {
+	req := httptest.NewRequest(http.MethodGet, "/sync"+query, nil)
+	w := httptest.NewRecorder()
+	handler.Sync(w, req)
+	resp := w.Result()
+
+	var response model.SyncResponse
+	if resp.StatusCode == http.StatusOK {
+		if err := json2.NewDecoder(resp.Body).Decode(&response); err != nil {
+			t.Fatalf("error reading response body: %v", err)
+		}
+	}
+	return resp, response
+}
+
+func TestSync(t *testing.T) {
+	handler := createTestHandler()
+	allTasks := addTasks(handler)
+
+	_, initial := syncTasks(t, handler, "")
+	if len(initial.Tasks) != len(allTasks) {
+		t.Errorf("expected %d tasks on initial sync, got %d", len(allTasks), len(initial.Tasks))
+	}
+	if initial.NextToken == "" {
+		t.Fatalf("expected next token")
+	}
+
+	req := httptest.NewRequest(http.MethodPatch, "/tasks/", strings.NewReader(`{"status":"done"}`))
+	req.SetPathValue("id", allTasks[0].Id.String())
+	handler.UpdateTask(httptest.NewRecorder(), req)
+
+	req = httptest.NewRequest(http.MethodDelete, "/tasks/", nil)
+	req.SetPathValue("id", allTasks[1].Id.String())
+	handler.DeleteTask(httptest.NewRecorder(), req)
+
+	_, changes := syncTasks(t, handler, "?since="+url.QueryEscape(initial.NextToken))
+	if len(changes.Tasks) != 1 || changes.Tasks[0].Id != allTasks[0].Id {
+		t.Errorf("expected updated task %v, got %+v", allTasks[0].Id, changes.Tasks)
+	}
+	if len(changes.Deleted) != 1 || changes.Deleted[0].Id != allTasks[1].Id {
+		t.Errorf("expected tombstone for %v, got %+v", allTasks[1].Id, changes.Deleted)
+	}
+	if changes.FullResync {
+		t.Errorf("expected incremental sync")
+	}
+
+	_, empty := syncTasks(t, handler, "?since="+url.QueryEscape(changes.NextToken))
+	if len(empty.Tasks) != 0 || len(empty.Deleted) != 0 {
+		t.Errorf("expected no changes, got %+v", empty)
+	}
+
+	_, page := syncTasks(t, handler, "?limit=2")
+	if len(page.Tasks) != 2 || !page.HasMore {
+		t.Errorf("expected first page of 2 tasks with more, got %d tasks", len(page.Tasks))
+	}
+
+	foreignToken := base64.RawURLEncoding.EncodeToString([]byte("deadbeef.3"))
+	_, resync := syncTasks(t, handler, "?since="+foreignToken)
+	if !resync.FullResync {
+		t.Errorf("expected full resync for token from another epoch")
+	}
+	if len(resync.Tasks) != len(allTasks)-1 {
+		t.Errorf("expected %d tasks on full resync, got %d", len(allTasks)-1, len(resync.Tasks))
+	}
+
+	resp, _ := syncTasks(t, handler, "?since=not-a-token")
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected status %v, got %v", http.StatusBadRequest, resp.StatusCode)
+	}
+}
@@ -0,0 +1,180 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+	"simple-tasks/internal/model"
+	"simple-tasks/internal/service"
+	"strconv"
+)
+
+const maxTagLimit = 100
+
+type TagHandler struct {
+	log     *slog.Logger
+	service *service.TagService
+}
+
+func NewTagHandler(log *slog.Logger, service *service.TagService) *TagHandler {
+	return &TagHandler{
+		log:     log,
+		service: service,
+	}
+}
+
+// GetTags lists the tag catalog. With prefix and limit it serves
+// autocomplete, suggesting the most used matching tags first.
+func (h *TagHandler) GetTags(w http.ResponseWriter, r *http.Request) {
+	if !negotiate(w, r) {
+		return
+	}
+
+	if err := checkQuery(r, tagListParams); err != nil {
+		h.log.ErrorContext(r.Context(), "invalid query", slog.String("error", err.Error()))
+
+		writeError(w, r, http.StatusUnprocessableEntity, errorValidation, err)
+		return
+	}
+
+	query := r.URL.Query()
+	limit := 0
+	if limitParam, paramErr := intParam(query, "limit"); paramErr != nil || limitParam != nil {
+		var err error
+		if paramErr != nil {
+			err = paramErrors{paramErr}
+		} else {
+			limit = *limitParam
+			err = validate.Var(limit, "gte=1,lte="+strconv.Itoa(maxTagLimit))
+		}
+		if err != nil {
+			h.log.ErrorContext(r.Context(), "invalid limit", slog.String("error", err.Error()))
+
+			writeError(w, r, http.StatusUnprocessableEntity, errorValidation, err)
+			return
+		}
+	}
+
+	tags := h.service.GetTags(r.Context(), query.Get("prefix"), limit)
+
+	w.WriteHeader(http.StatusOK)
+	_ = encode(w, tags)
+}
+
+func (h *TagHandler) GetTag(w http.ResponseWriter, r *http.Request) {
+	if !negotiate(w, r) {
+		return
+	}
+
+	tag, err := h.service.GetTag(r.Context(), r.PathValue("name"))
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "tag lookup failed", slog.String("error", err.Error()))
+
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = encode(w, tag)
+}
+
+func (h *TagHandler) UpdateTag(w http.ResponseWriter, r *http.Request) {
+	if !negotiate(w, r) {
+		return
+	}
+
+	name := r.PathValue("name")
+	if err := validate.Var(model.NormalizeTag(name), "gte=1,lte=32"); err != nil {
+		h.log.ErrorContext(r.Context(), "invalid tag", slog.String("error", err.Error()))
+
+		writeError(w, r, http.StatusUnprocessableEntity, errorValidation, err)
+		return
+	}
+
+	var req model.UpdateTagRequest
+	if err := decode(w, r, &req); err != nil {
+		h.log.ErrorContext(r.Context(), "invalid request body", slog.String("error", err.Error()))
+
+		writeDecodeError(w, r, err)
+		return
+	}
+
+	if err := validate.Struct(req); err != nil {
+		h.log.ErrorContext(r.Context(), "invalid tag", slog.String("error", err.Error()))
+
+		writeError(w, r, http.StatusUnprocessableEntity, errorValidation, err)
+		return
+	}
+
+	tag, err := h.service.UpdateTag(r.Context(), name, &req)
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "tag update failed", slog.String("error", err.Error()))
+
+		writeError(w, r, http.StatusInternalServerError, errorInternal, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = encode(w, tag)
+}
+
+func (h *TagHandler) RenameTag(w http.ResponseWriter, r *http.Request) {
+	if !negotiate(w, r) {
+		return
+	}
+
+	var req model.RenameTagRequest
+	if err := decode(w, r, &req); err != nil {
+		h.log.ErrorContext(r.Context(), "invalid request body", slog.String("error", err.Error()))
+
+		writeDecodeError(w, r, err)
+		return
+	}
+
+	req.Name = model.NormalizeTag(req.Name)
+	if err := validate.Struct(req); err != nil {
+		h.log.ErrorContext(r.Context(), "invalid tag", slog.String("error", err.Error()))
+
+		writeError(w, r, http.StatusUnprocessableEntity, errorValidation, err)
+		return
+	}
+
+	response, err := h.service.RenameTag(r.Context(), r.PathValue("name"), &req)
+	h.writeTagChange(w, r, response, err)
+}
+
+func (h *TagHandler) MergeTag(w http.ResponseWriter, r *http.Request) {
+	if !negotiate(w, r) {
+		return
+	}
+
+	var req model.MergeTagRequest
+	if err := decode(w, r, &req); err != nil {
+		h.log.ErrorContext(r.Context(), "invalid request body", slog.String("error", err.Error()))
+
+		writeDecodeError(w, r, err)
+		return
+	}
+
+	req.Into = model.NormalizeTag(req.Into)
+	if err := validate.Struct(req); err != nil {
+		h.log.ErrorContext(r.Context(), "invalid tag", slog.String("error", err.Error()))
+
+		writeError(w, r, http.StatusUnprocessableEntity, errorValidation, err)
+		return
+	}
+
+	response, err := h.service.MergeTag(r.Context(), r.PathValue("name"), &req)
+	h.writeTagChange(w, r, response, err)
+}
+
+func (h *TagHandler) writeTagChange(w http.ResponseWriter, r *http.Request, response *model.TagChangeResponse, err error) {
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "tag change failed", slog.String("error", err.Error()))
+
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = encode(w, response)
+}
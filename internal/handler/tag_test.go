@@ -0,0 +1,248 @@
+package handler
+
+import (
+	"context"
+	json2 "encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"simple-tasks/internal/model"
+	"simple-tasks/internal/service"
+	"simple-tasks/internal/store"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func createTestTagHandler() (*TaskHandler, *TagHandler) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	taskService := service.NewTaskService(log, store.NewInMemoryTaskRepository())
+	tagService := service.NewTagService(log, store.NewInMemoryTagRepository(), taskService)
+
+	return NewTaskHandler(log, taskService), NewTagHandler(log, tagService)
+}
+
+func serveTag(handler http.HandlerFunc, method string, target string, name string, body string) *http.Response {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.SetPathValue("name", name)
+	w := httptest.NewRecorder()
+	handler(w, req)
+	return w.Result()
+}
+
+func getTagNames(t *testing.T, handler *TagHandler, target string) ([]string, []model.Tag) {
+	resp := serveTag(handler.GetTags, http.MethodGet, target, "", "")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status %v, got %v", http.StatusOK, resp.StatusCode)
+	}
+
+	var tags []model.Tag
+	if err := json2.NewDecoder(resp.Body).Decode(&tags); err != nil {
+		t.Fatalf("error reading response body: %v", err)
+	}
+	names := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		names = append(names, tag.Name)
+	}
+	return names, tags
+}
+
+func TestTagNormalization(t *testing.T) {
+	taskHandler, _ := createTestTagHandler()
+
+	task := createTask(t, taskHandler, `{"title":"Normalize","tags":[" Work ","work","URGENT"]}`)
+	if expected := []string{"work", "urgent"}; !slices.Equal(task.Tags, expected) {
+		t.Errorf("expected tags %v, got %v", expected, task.Tags)
+	}
+
+	_, response, _ := getTaskPage(t, taskHandler, "/tasks?tag=Urgent")
+	if len(response.Tasks) != 1 {
+		t.Errorf("expected tag filter to be normalized, got %d tasks", len(response.Tasks))
+	}
+}
+
+func TestTagCatalog(t *testing.T) {
+	taskHandler, handler := createTestTagHandler()
+	addTasks(taskHandler)
+
+	names, tags := getTagNames(t, handler, "/tags")
+	if len(tags) != 9 || !slices.Equal(names[:3], []string{"todo_tag", "покупки", "прогулка"}) {
+		t.Errorf("expected most used tags first, got %v", names)
+	}
+	if tags[0].Count != 2 {
+		t.Errorf("expected count 2, got %d", tags[0].Count)
+	}
+
+	names, _ = getTagNames(t, handler, "/tags?prefix=П&limit=2")
+	if !slices.Equal(names, []string{"покупки", "прогулка"}) {
+		t.Errorf("expected autocomplete suggestions, got %v", names)
+	}
+
+	if resp := serveTag(handler.GetTags, http.MethodGet, "/tags?limit=0", "", ""); resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("expected status %v, got %v", http.StatusUnprocessableEntity, resp.StatusCode)
+	}
+	if resp := serveTag(handler.GetTag, http.MethodGet, "/tags/", "missing", ""); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected status %v, got %v", http.StatusNotFound, resp.StatusCode)
+	}
+
+	tests := []struct {
+		name           string
+		tag            string
+		body           string
+		expectedStatus int
+	}{
+		{
+			name:           "color and description",
+			tag:            "покупки",
+			body:           `{"color":"#ff8800","description":"Что купить"}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "metadata for an unused tag",
+			tag:            "Later",
+			body:           `{"description":"Not used yet"}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "invalid color",
+			tag:            "покупки",
+			body:           `{"color":"orange"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := serveTag(handler.UpdateTag, http.MethodPatch, "/tags/", tt.tag, tt.body)
+			if resp.StatusCode != tt.expectedStatus {
+				t.Fatalf("expected status %v, got %v", tt.expectedStatus, resp.StatusCode)
+			}
+		})
+	}
+
+	_, tags = getTagNames(t, handler, "/tags?prefix=later")
+	if len(tags) != 1 || tags[0].Count != 0 || tags[0].Description != "Not used yet" {
+		t.Errorf("expected unused tag with metadata, got %+v", tags)
+	}
+}
+
+func TestRenameAndMergeTags(t *testing.T) {
+	taskHandler, handler := createTestTagHandler()
+	addTasks(taskHandler)
+	serveTag(handler.UpdateTag, http.MethodPatch, "/tags/", "покупки", `{"color":"#ff8800"}`)
+
+	tests := []struct {
+		name           string
+		handler        http.HandlerFunc
+		tag            string
+		body           string
+		expectedStatus int
+		expectedTag    string
+		expectedCount  int
+		expectedTasks  int
+	}{
+		{
+			name:           "rename",
+			handler:        handler.RenameTag,
+			tag:            "покупки",
+			body:           `{"name":" Shopping "}`,
+			expectedStatus: http.StatusOK,
+			expectedTag:    "shopping",
+			expectedCount:  2,
+			expectedTasks:  2,
+		},
+		{
+			name:           "rename to an existing tag",
+			handler:        handler.RenameTag,
+			tag:            "прогулка",
+			body:           `{"name":"todo_tag"}`,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "merge into an existing tag",
+			handler:        handler.MergeTag,
+			tag:            "прогулка",
+			body:           `{"into":"todo_tag"}`,
+			expectedStatus: http.StatusOK,
+			expectedTag:    "todo_tag",
+			expectedCount:  3,
+			expectedTasks:  2,
+		},
+		{
+			name:           "merge a missing tag",
+			handler:        handler.MergeTag,
+			tag:            "прогулка",
+			body:           `{"into":"todo_tag"}`,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "invalid target",
+			handler:        handler.MergeTag,
+			tag:            "спорт",
+			body:           `{"into":"   "}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := serveTag(tt.handler, http.MethodPost, "/tags/", tt.tag, tt.body)
+			if resp.StatusCode != tt.expectedStatus {
+				t.Fatalf("expected status %v, got %v", tt.expectedStatus, resp.StatusCode)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var response model.TagChangeResponse
+			if err := json2.NewDecoder(resp.Body).Decode(&response); err != nil {
+				t.Fatalf("error reading response body: %v", err)
+			}
+			if response.Tag.Name != tt.expectedTag || response.Tag.Count != tt.expectedCount || response.UpdatedTasks != tt.expectedTasks {
+				t.Errorf("expected %s used by %d tasks after updating %d, got %+v",
+					tt.expectedTag, tt.expectedCount, tt.expectedTasks, response)
+			}
+		})
+	}
+
+	_, shopping, _ := getTaskPage(t, taskHandler, "/tasks?tag=shopping")
+	if len(shopping.Tasks) != 2 {
+		t.Errorf("expected renamed tag on 2 tasks, got %d", len(shopping.Tasks))
+	}
+	_, tags := getTagNames(t, handler, "/tags?prefix=shopping")
+	if len(tags) != 1 || tags[0].Color != "#ff8800" {
+		t.Errorf("expected metadata to follow the rename, got %+v", tags)
+	}
+
+	_, merged, _ := getTaskPage(t, taskHandler, "/tasks?q=друзьями")
+	if len(merged.Tasks) != 1 || !slices.Equal(merged.Tasks[0].Tags, []string{"todo_tag"}) {
+		t.Errorf("expected merged tags without duplicates, got %+v", merged.Tasks)
+	}
+}
+
+func TestRenameTagEmitsUpdates(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	taskService := service.NewTaskService(log, store.NewInMemoryTaskRepository())
+	handler := NewTagHandler(log, service.NewTagService(log, store.NewInMemoryTagRepository(), taskService))
+	addTasks(NewTaskHandler(log, taskService))
+
+	var events []model.TaskEvent
+	taskService.AddListener(func(ctx context.Context, event model.TaskEvent) {
+		events = append(events, event)
+	})
+
+	resp := serveTag(handler.RenameTag, http.MethodPost, "/tags/", "покупки", `{"name":"shopping"}`)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status %v, got %v", http.StatusOK, resp.StatusCode)
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	for _, event := range events {
+		if event.Type != model.EventTaskUpdated || !slices.Contains(event.Task.Tags, "shopping") {
+			t.Errorf("expected an update of a retagged task, got %+v", event)
+		}
+	}
+}
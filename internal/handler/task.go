@@ -1,16 +1,12 @@
 package handler
 
 import (
-	json2 "encoding/json"
-	"errors"
 	"fmt"
 	"github.com/go-playground/validator/v10"
-	"github.com/google/uuid"
 	"log/slog"
 	"net/http"
 	"simple-tasks/internal/model"
 	"simple-tasks/internal/service"
-	"strconv"
 )
 
 var validate *validator.Validate = validator.New()
@@ -28,22 +24,22 @@ func NewTaskHandler(log *slog.Logger, service *service.TaskService) *TaskHandler
 }
 
 func (h *TaskHandler) CreateTask(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
+	if !negotiate(w, r) {
+		return
+	}
 
 	var newTask model.Task
-	if err := json2.NewDecoder(r.Body).Decode(&newTask); err != nil {
-		h.log.ErrorContext(r.Context(), "invalid json", slog.String("error", err.Error()))
+	if err := decode(w, r, &newTask); err != nil {
+		h.log.ErrorContext(r.Context(), "invalid request body", slog.String("error", err.Error()))
 
-		w.WriteHeader(http.StatusBadRequest)
-		_ = json2.NewEncoder(w).Encode(newError(r.Context(), errorInvalidJson, err))
+		writeDecodeError(w, r, err)
 		return
 	}
 
 	if err := validate.Struct(newTask); err != nil {
 		h.log.ErrorContext(r.Context(), "invalid task", slog.String("error", err.Error()))
 
-		w.WriteHeader(http.StatusUnprocessableEntity)
-		_ = json2.NewEncoder(w).Encode(newError(r.Context(), errorValidation, err))
+		writeError(w, r, http.StatusUnprocessableEntity, errorValidation, err)
 		return
 	}
 
@@ -51,157 +47,165 @@ func (h *TaskHandler) CreateTask(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Location", fmt.Sprintf("/tasks/%s", createdTask.Id))
 	w.WriteHeader(http.StatusCreated)
-	_ = json2.NewEncoder(w).Encode(createdTask)
+	_ = encode(w, createdTask)
 }
 
 func (h *TaskHandler) GetTasks(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-
-	query := r.URL.Query()
-	req := &model.GetTasksRequest{
-		Status: query.Get("status"),
-		Tags:   query["tag"],
-		Q:      query.Get("q"),
-		Sort:   query.Get("sort"),
+	if !negotiate(w, r) {
+		return
 	}
 
-	pageStr := r.URL.Query().Get("page")
-	if pageStr != "" {
-		if page, err := strconv.Atoi(pageStr); err == nil {
-			req.Page = &page
-		} else {
-			h.log.ErrorContext(r.Context(), "invalid page", slog.String("error", err.Error()))
-		}
+	if err := checkQuery(r, taskListParams, taskShapeParams); err != nil {
+		h.log.ErrorContext(r.Context(), "invalid query", slog.String("error", err.Error()))
+
+		writeError(w, r, http.StatusUnprocessableEntity, errorValidation, err)
+		return
 	}
 
-	pageSizeStr := r.URL.Query().Get("pageSize")
-	if pageSizeStr != "" {
-		if pageSize, err := strconv.Atoi(pageSizeStr); err == nil {
-			req.PageSize = &pageSize
-		} else {
-			h.log.ErrorContext(r.Context(), "invalid pageSize", slog.String("error", err.Error()))
-		}
+	req, err := parseTasksRequest(r.URL.Query())
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "invalid request", slog.String("error", err.Error()))
+
+		writeError(w, r, http.StatusUnprocessableEntity, errorValidation, err)
+		return
 	}
 
-	if err := validate.Struct(req); err != nil {
+	shape, err := parseTaskShape(r.URL.Query())
+	if err != nil {
 		h.log.ErrorContext(r.Context(), "invalid request", slog.String("error", err.Error()))
 
-		w.WriteHeader(http.StatusUnprocessableEntity)
-		_ = json2.NewEncoder(w).Encode(newError(r.Context(), errorValidation, err))
+		writeError(w, r, http.StatusUnprocessableEntity, errorValidation, err)
+		return
+	}
+
+	tasks, err := h.service.GetTasks(r.Context(), req)
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "task list failed", slog.String("error", err.Error()))
+
+		writeServiceError(w, r, err)
 		return
 	}
 
-	tasks := h.service.GetTasks(r.Context(), req)
+	body, err := shape.tasks(tasks)
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "internal error", slog.String("error", err.Error()))
 
+		writeError(w, r, http.StatusInternalServerError, errorInternal, err)
+		return
+	}
+
+	if link := paginationLinks(r.URL, tasks); link != "" {
+		w.Header().Set("Link", link)
+	}
 	w.WriteHeader(http.StatusOK)
-	_ = json2.NewEncoder(w).Encode(tasks)
+	_ = encode(w, body)
 }
 
 func (h *TaskHandler) GetTaskById(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
+	if !negotiate(w, r) {
+		return
+	}
+
+	if err := checkQuery(r, taskShapeParams); err != nil {
+		h.log.ErrorContext(r.Context(), "invalid query", slog.String("error", err.Error()))
 
-	id, err := uuid.Parse(r.PathValue("id"))
+		writeError(w, r, http.StatusUnprocessableEntity, errorValidation, err)
+		return
+	}
+
+	id, err := pathId(r)
 	if err != nil {
 		h.log.ErrorContext(r.Context(), "invalid id", slog.String("error", err.Error()))
 
-		w.WriteHeader(http.StatusUnprocessableEntity)
-		_ = json2.NewEncoder(w).Encode(newError(r.Context(), errorValidation, err))
+		writeError(w, r, http.StatusUnprocessableEntity, errorValidation, err)
+		return
+	}
+
+	shape, err := parseTaskShape(r.URL.Query())
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "invalid request", slog.String("error", err.Error()))
+
+		writeError(w, r, http.StatusUnprocessableEntity, errorValidation, err)
 		return
 	}
 
 	task, err := h.service.GetTaskById(r.Context(), id)
-	if errors.Is(err, service.NotFoundError) {
-		h.log.ErrorContext(r.Context(), "task not found", slog.String("error", err.Error()))
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "task lookup failed", slog.String("error", err.Error()))
 
-		w.WriteHeader(http.StatusNotFound)
-		_ = json2.NewEncoder(w).Encode(newError(r.Context(), errorNotFound, err))
+		writeServiceError(w, r, err)
 		return
 	}
+
+	body, err := shape.task(task)
 	if err != nil {
 		h.log.ErrorContext(r.Context(), "internal error", slog.String("error", err.Error()))
 
-		w.WriteHeader(http.StatusInternalServerError)
-		_ = json2.NewEncoder(w).Encode(newError(r.Context(), errorInternal, err))
+		writeError(w, r, http.StatusInternalServerError, errorInternal, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
-	_ = json2.NewEncoder(w).Encode(task)
+	_ = encode(w, body)
 }
 
 func (h *TaskHandler) UpdateTask(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
+	if !negotiate(w, r) {
+		return
+	}
 
-	id, err := uuid.Parse(r.PathValue("id"))
+	id, err := pathId(r)
 	if err != nil {
 		h.log.ErrorContext(r.Context(), "invalid id", slog.String("error", err.Error()))
 
-		w.WriteHeader(http.StatusUnprocessableEntity)
-		_ = json2.NewEncoder(w).Encode(newError(r.Context(), errorValidation, err))
+		writeError(w, r, http.StatusUnprocessableEntity, errorValidation, err)
 		return
 	}
 
 	var req model.UpdateTaskRequest
-	if err := json2.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.log.ErrorContext(r.Context(), "invalid json", slog.String("error", err.Error()))
+	if err := decode(w, r, &req); err != nil {
+		h.log.ErrorContext(r.Context(), "invalid request body", slog.String("error", err.Error()))
 
-		w.WriteHeader(http.StatusBadRequest)
-		_ = json2.NewEncoder(w).Encode(newError(r.Context(), errorInvalidJson, err))
+		writeDecodeError(w, r, err)
 		return
 	}
 
 	if err := validate.Struct(req); err != nil {
 		h.log.ErrorContext(r.Context(), "invalid task", slog.String("error", err.Error()))
 
-		w.WriteHeader(http.StatusUnprocessableEntity)
-		_ = json2.NewEncoder(w).Encode(newError(r.Context(), errorValidation, err))
+		writeError(w, r, http.StatusUnprocessableEntity, errorValidation, err)
 		return
 	}
 
 	newTask, err := h.service.UpdateTask(r.Context(), id, &req)
-	if errors.Is(err, service.NotFoundError) {
-		h.log.ErrorContext(r.Context(), "task update failed", slog.String("error", err.Error()))
-
-		w.WriteHeader(http.StatusNotFound)
-		_ = json2.NewEncoder(w).Encode(newError(r.Context(), errorNotFound, err))
-		return
-	}
 	if err != nil {
 		h.log.ErrorContext(r.Context(), "task update failed", slog.String("error", err.Error()))
 
-		w.WriteHeader(http.StatusInternalServerError)
-		_ = json2.NewEncoder(w).Encode(newError(r.Context(), errorInternal, err))
+		writeServiceError(w, r, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
-	_ = json2.NewEncoder(w).Encode(newTask)
+	_ = encode(w, newTask)
 }
 
 func (h *TaskHandler) DeleteTask(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
+	if !negotiate(w, r) {
+		return
+	}
 
-	id, err := uuid.Parse(r.PathValue("id"))
+	id, err := pathId(r)
 	if err != nil {
 		h.log.ErrorContext(r.Context(), "invalid id", slog.String("error", err.Error()))
-		w.WriteHeader(http.StatusUnprocessableEntity)
-		_ = json2.NewEncoder(w).Encode(newError(r.Context(), errorValidation, err))
+		writeError(w, r, http.StatusUnprocessableEntity, errorValidation, err)
 		return
 	}
 
 	err = h.service.DeleteTask(r.Context(), id)
-	if errors.Is(err, service.NotFoundError) {
-		h.log.ErrorContext(r.Context(), "task delete failed", slog.String("error", err.Error()))
-
-		w.WriteHeader(http.StatusNotFound)
-		_ = json2.NewEncoder(w).Encode(newError(r.Context(), errorNotFound, err))
-		return
-	}
 	if err != nil {
-		h.log.ErrorContext(r.Context(), "internal error", slog.String("error", err.Error()))
+		h.log.ErrorContext(r.Context(), "task delete failed", slog.String("error", err.Error()))
 
-		w.WriteHeader(http.StatusInternalServerError)
-		_ = json2.NewEncoder(w).Encode(newError(r.Context(), errorInternal, err))
+		writeServiceError(w, r, err)
 		return
 	}
 
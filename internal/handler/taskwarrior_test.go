@@ -0,0 +1,176 @@
+package handler
+
+import (
+	json2 "encoding/json"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+const taskwarriorExport = `[
+{"id":1,"description":"Call mom","entry":"20251001T080000Z","modified":"20251002T090000Z","priority":"H","project":"family","status":"pending","tags":["phone"],"due":"20251003T150000Z","uuid":"7d1c4e4a-5b7e-4a4f-9a4c-0c1d2e3f4a5b","urgency":8.2},
+{"id":0,"description":"Pay rent","end":"20251002T100000Z","entry":"20251001T080000Z","modified":"20251002T100000Z","priority":"M","status":"completed","uuid":"1a2b3c4d-1111-4222-8333-444455556666","annotations":[{"entry":"20251001T090000Z","description":"landlord account"},{"entry":"20251002T090000Z","description":"paid by card"}]},
+{"id":2,"description":"Write report","entry":"20251001T080000Z","modified":"20251001T080000Z","start":"20251001T090000Z","status":"pending","uuid":"2b3c4d5e-2222-4333-8444-555566667777"},
+{"id":0,"description":"Old idea","entry":"20251001T080000Z","status":"deleted","uuid":"3c4d5e6f-3333-4444-8555-666677778888"},
+{"id":3,"description":"Broken","entry":"20251001T080000Z","priority":"X","status":"pending","uuid":"4d5e6f70-4444-4555-8666-777788889999"}
+]`
+
+func TestImportTaskwarrior(t *testing.T) {
+	handler := createTestHandler()
+
+	resp, report := importTasks(t, handler, "?format=taskwarrior", "application/json", taskwarriorExport)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status %v, got %v", http.StatusOK, resp.StatusCode)
+	}
+	expected := []string{"created", "created", "created", "skipped", "failed"}
+	if statuses := rowStatuses(report); !slices.Equal(statuses, expected) {
+		t.Fatalf("expected statuses %v, got %v", expected, statuses)
+	}
+	if row := report.Rows[4]; row.Errors[0].Field != "priority" {
+		t.Errorf("expected priority error, got %+v", row)
+	}
+
+	_, response, _ := getTaskPage(t, handler, "/tasks?sort=title")
+	tasks := response.Tasks
+	if len(tasks) != 3 {
+		t.Fatalf("expected 3 tasks, got %d", len(tasks))
+	}
+	if task := tasks[0]; task.Priority != "high" || task.Status != "todo" || !slices.Equal(task.Tags, []string{"phone", "project:family"}) ||
+		task.ExternalId != "7d1c4e4a-5b7e-4a4f-9a4c-0c1d2e3f4a5b" || task.DueDate == nil || !task.DueDate.Equal(time.Date(2025, 10, 3, 15, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected task %+v", task)
+	}
+	if task := tasks[0]; !task.CreatedAt.Equal(time.Date(2025, 10, 1, 8, 0, 0, 0, time.UTC)) || !task.UpdatedAt.Equal(time.Date(2025, 10, 2, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected entry and modified to be kept, got %v and %v", task.CreatedAt, task.UpdatedAt)
+	}
+	if task := tasks[1]; task.Status != "done" || task.Content != "landlord account\npaid by card" ||
+		task.CompletedAt == nil || !task.CompletedAt.Equal(time.Date(2025, 10, 2, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected a completed task with annotations, got %+v", task)
+	}
+	if task := tasks[2]; task.Status != "in_progress" || task.Priority != "low" {
+		t.Errorf("expected a started task, got %+v", task)
+	}
+}
+
+func TestImportTaskwarriorLosses(t *testing.T) {
+	handler := createTestHandler()
+
+	body := `[{"description":"Plan trip","entry":"20251001T080000Z","modified":"20251002T090000Z","start":"20251001T090000Z","priority":"L",` +
+		`"project":"Holidays","tags":["Travel"],"status":"pending","uuid":"5e6f7081-5555-4666-8777-88889999aaaa",` +
+		`"annotations":[{"entry":"20251001T090000Z","description":"book flights\nbook hotel"}]},` +
+		`{"description":"Long project","entry":"20251001T080000Z","modified":"20251001T080000Z","start":"20251001T080000Z",` +
+		`"project":"a.project.name.longer.than.a.tag","status":"pending","uuid":"6f708192-6666-4777-8888-9999aaaabbbb"}]`
+
+	resp, report := importTasks(t, handler, "?format=taskwarrior", "application/json", body)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status %v, got %v", http.StatusOK, resp.StatusCode)
+	}
+	if statuses := rowStatuses(report); !slices.Equal(statuses, []string{"created", "created"}) {
+		t.Fatalf("expected both tasks to be created, got %v", statuses)
+	}
+
+	fields := func(warnings []ErrorDetail) []string {
+		var fields []string
+		for _, warning := range warnings {
+			fields = append(fields, warning.Field)
+		}
+		return fields
+	}
+	if got := fields(report.Rows[0].Warnings); !slices.Equal(got, []string{"start", "priority", "project", "tags", "annotations", "annotations"}) {
+		t.Errorf("expected every lost attribute to be reported, got %+v", report.Rows[0].Warnings)
+	}
+	if got := fields(report.Rows[1].Warnings); !slices.Equal(got, []string{"project"}) {
+		t.Errorf("expected the long project to be reported, got %+v", report.Rows[1].Warnings)
+	}
+
+	task, err := handler.service.GetTaskByExternalId(t.Context(), "6f708192-6666-4777-8888-9999aaaabbbb")
+	if err != nil || task.Status != "in_progress" || len(task.Tags) != 0 {
+		t.Errorf("expected the task without its project, got %+v", task)
+	}
+}
+
+func TestImportTaskwarriorLines(t *testing.T) {
+	handler := createTestHandler()
+
+	body := `{"description":"First","status":"pending","uuid":"7d1c4e4a-5b7e-4a4f-9a4c-0c1d2e3f4a5b"},` + "\n" +
+		`{"description":"Second","status":"waiting","due":"2025-10-03T15:00:00Z","uuid":"1a2b3c4d-1111-4222-8333-444455556666"}` + "\n"
+
+	resp, report := importTasks(t, handler, "?format=taskwarrior", "application/json", body)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status %v, got %v", http.StatusOK, resp.StatusCode)
+	}
+	if statuses := rowStatuses(report); !slices.Equal(statuses, []string{"created", "created"}) {
+		t.Errorf("expected both lines to be created, got %v", statuses)
+	}
+
+	resp, _ = importTasks(t, handler, "?format=taskwarrior", "application/json", `[{"description":"Broken"`)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected status %v for a broken array, got %v", http.StatusBadRequest, resp.StatusCode)
+	}
+}
+
+func TestExportTaskwarriorRoundTrip(t *testing.T) {
+	handler := createTestHandler()
+	if _, report := importTasks(t, handler, "?format=taskwarrior", "application/json", taskwarriorExport); report.Created != 3 {
+		t.Fatalf("expected 3 created tasks, got %+v", report)
+	}
+	own := createTask(t, handler, `{"title":"Local task","priority":"low","tags":["home"]}`)
+
+	resp := exportTasks(t, handler, "?format=taskwarrior&sort=title")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status %v, got %v", http.StatusOK, resp.StatusCode)
+	}
+	if disposition := resp.Header.Get("Content-Disposition"); !strings.HasSuffix(disposition, `.json"`) {
+		t.Errorf("expected a .json attachment, got %q", disposition)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("error reading response body: %v", err)
+	}
+
+	var exported []map[string]any
+	if err := json2.Unmarshal(body, &exported); err != nil {
+		t.Fatalf("error decoding export: %v", err)
+	}
+	if len(exported) != 4 {
+		t.Fatalf("expected 4 tasks, got %d", len(exported))
+	}
+
+	var original []map[string]any
+	_ = json2.Unmarshal([]byte(taskwarriorExport), &original)
+	for _, attribute := range []string{"uuid", "description", "status", "priority", "project", "tags", "due", "entry", "modified"} {
+		if got, want := exported[0][attribute], original[0][attribute]; !equalJson(got, want) {
+			t.Errorf("expected %s %v, got %v", attribute, want, got)
+		}
+	}
+	for _, attribute := range []string{"uuid", "status", "end", "entry", "modified"} {
+		if got, want := exported[2][attribute], original[1][attribute]; !equalJson(got, want) {
+			t.Errorf("expected %s %v, got %v", attribute, want, got)
+		}
+	}
+	if annotations, _ := exported[2]["annotations"].([]any); len(annotations) != 2 {
+		t.Errorf("expected 2 annotations, got %v", exported[2]["annotations"])
+	}
+	if exported[3]["start"] == nil {
+		t.Errorf("expected the task in progress to be started, got %v", exported[3])
+	}
+	if local := exported[1]; local["uuid"] != own.Id.String() || local["priority"] != nil {
+		t.Errorf("expected the local task under its own id without priority, got %v", local)
+	}
+
+	resp, report := importTasks(t, handler, "?format=taskwarrior", "application/json", string(body))
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status %v, got %v", http.StatusOK, resp.StatusCode)
+	}
+	if report.Skipped != 4 {
+		t.Errorf("expected every task to be skipped, got %+v", report)
+	}
+}
+
+func equalJson(a any, b any) bool {
+	left, _ := json2.Marshal(a)
+	right, _ := json2.Marshal(b)
+	return string(left) == string(right)
+}
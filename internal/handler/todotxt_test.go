@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"simple-tasks/internal/model"
+	"simple-tasks/internal/todotxt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func getTasksTodotxt(t *testing.T, handler *TaskHandler, query string) (*http.Response, []string) {
+	req := httptest.NewRequest(http.MethodGet, "/tasks.txt"+query, nil)
+	w := httptest.NewRecorder()
+	handler.GetTasksTodotxt(w, req)
+
+	resp := w.Result()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("error reading response body: %v", err)
+	}
+	return resp, strings.Split(strings.TrimSuffix(string(body), "\n"), "\n")
+}
+
+func TestGetTasksTodotxt(t *testing.T) {
+	handler := createTestHandler()
+
+	high := createTask(t, handler, `{"title":"Call  mom","priority":"high","tags":["family","@phone"],"dueDate":"2025-10-03T15:00:00Z"}`)
+	done := createTask(t, handler, `{"title":"Pay rent","priority":"normal","status":"done"}`)
+	progress := createTask(t, handler, `{"title":"Write report","status":"in_progress","content":"draft"}`)
+	created := high.CreatedAt.UTC().Format("2006-01-02")
+
+	resp, lines := getTasksTodotxt(t, handler, "?sort=title")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status %v, got %v", http.StatusOK, resp.StatusCode)
+	}
+	expected := []string{
+		"(A) " + created + " Call mom +family @phone due:2025-10-03 id:" + high.Id.String(),
+		"x " + created + " " + created + " Pay rent pri:B id:" + done.Id.String(),
+		created + " Write report status:in_progress id:" + progress.Id.String(),
+	}
+	if !slices.Equal(lines, expected) {
+		t.Errorf("expected lines\n%s\ngot\n%s", strings.Join(expected, "\n"), strings.Join(lines, "\n"))
+	}
+
+	_, lines = getTasksTodotxt(t, handler, "?status=done")
+	if len(lines) != 1 || !strings.HasPrefix(lines[0], "x ") {
+		t.Errorf("expected only the done task, got %v", lines)
+	}
+}
+
+func TestImportTodotxt(t *testing.T) {
+	handler := createTestHandler()
+
+	body := "(A) Call mom +Family @phone due:2025-10-03\n" +
+		"\n" +
+		"x 2025-10-02 2025-10-01 Pay rent pri:B\n" +
+		"(C) 2025-10-01 Read https://example.com/article t:2025-10-10\n" +
+		"Write report status:in_progress id:report-1\n" +
+		"Broken due:tomorrow\n"
+
+	resp, report := importTasks(t, handler, "?format=todotxt", "text/plain", body)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status %v, got %v", http.StatusOK, resp.StatusCode)
+	}
+	expected := []string{"created", "created", "created", "created", "failed"}
+	if statuses := rowStatuses(report); !slices.Equal(statuses, expected) {
+		t.Fatalf("expected statuses %v, got %v", expected, statuses)
+	}
+	if row := report.Rows[4]; row.Row != 6 || row.Errors[0].Field != "due" {
+		t.Errorf("expected due error on line 6, got %+v", row)
+	}
+
+	_, response, _ := getTaskPage(t, handler, "/tasks?sort=title")
+	tasks := response.Tasks
+	if len(tasks) != 4 {
+		t.Fatalf("expected 4 tasks, got %d", len(tasks))
+	}
+	if task := tasks[0]; task.Title != "Call mom" || task.Priority != "high" || !slices.Equal(task.Tags, []string{"family", "@phone"}) ||
+		task.DueDate == nil || task.DueDate.Format("2006-01-02") != "2025-10-03" {
+		t.Errorf("unexpected task %+v", task)
+	}
+	if task := tasks[1]; task.Status != "done" || task.Priority != "normal" || task.CompletedAt == nil {
+		t.Errorf("expected a completed task, got %+v", task)
+	}
+	if task := tasks[2]; task.Title != "Read https://example.com/article t:2025-10-10" || task.Priority != "low" {
+		t.Errorf("expected unknown keys to stay in the title, got %+v", task)
+	}
+	if task := tasks[3]; task.Status != "in_progress" || task.ExternalId != "report-1" {
+		t.Errorf("expected task in progress with external id, got %+v", task)
+	}
+}
+
+func TestImportTodotxtRoundTrip(t *testing.T) {
+	handler := createTestHandler()
+	addTasks(handler)
+	task := createTask(t, handler, `{"title":"Write report","content":"with charts","priority":"normal","tags":["work"]}`)
+
+	_, lines := getTasksTodotxt(t, handler, "")
+	resp, report := importTasks(t, handler, "?format=todotxt", "text/plain", strings.Join(lines, "\n"))
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status %v, got %v", http.StatusOK, resp.StatusCode)
+	}
+	if report.Skipped != len(lines) {
+		t.Errorf("expected every line to be skipped, got %+v", report)
+	}
+
+	for i, line := range lines {
+		if strings.Contains(line, "Write report") {
+			lines[i] = strings.Replace(line, "+work", "+work +charts", 1)
+		}
+	}
+	_, report = importTasks(t, handler, "?format=todotxt", "text/plain", strings.Join(lines, "\n"))
+	if report.Updated != 1 || report.Created != 0 {
+		t.Fatalf("expected a single update, got %+v", report)
+	}
+
+	_, response, _ := getTaskPage(t, handler, "/tasks?q=Write")
+	if len(response.Tasks) != 1 || response.Tasks[0].Id != task.Id {
+		t.Fatalf("expected the task to be updated in place, got %+v", response.Tasks)
+	}
+	if updated := response.Tasks[0]; updated.Content != "with charts" || !slices.Equal(updated.Tags, []string{"work", "charts"}) {
+		t.Errorf("expected content to be kept and tags updated, got %+v", updated)
+	}
+}
+
+func TestTodotxtFormatParseRoundTrip(t *testing.T) {
+	due := time.Date(2025, 10, 3, 0, 0, 0, 0, time.UTC)
+	created := time.Date(2025, 10, 1, 9, 0, 0, 0, time.UTC)
+
+	tests := []model.Task{
+		{Title: "Reply to +1 votes", Status: model.StatusTodo, Priority: model.PriorityHigh},
+		{Title: "Ask @anna about due:tomorrow and id:7", Status: model.StatusTodo, DueDate: &due},
+		{Title: "Keep pri:A status:done in the title", Status: model.StatusInProgress, Priority: model.PriorityNormal},
+		{Title: "x marks the spot", Status: model.StatusTodo},
+		{Title: "(A) is not a priority", Status: model.StatusTodo, CreatedAt: created},
+		{Title: "2025-10-01 retro", Status: model.StatusDone, CreatedAt: created},
+		{Title: `C:\temp \+ \\server`, Status: model.StatusTodo},
+		{Title: "Plan trip", Status: model.StatusTodo, Tags: []string{"my tag", "50%", "@at home", "a%20b"}},
+	}
+
+	for _, task := range tests {
+		t.Run(task.Title, func(t *testing.T) {
+			line := todotxt.Format(&task)
+			parsed, err := todotxt.Parse(line)
+			if err != nil {
+				t.Fatalf("error parsing %q: %v", line, err)
+			}
+			if parsed.Title != task.Title || parsed.Status != task.Status || !slices.Equal(parsed.Tags, task.Tags) {
+				t.Errorf("expected %q %s %v from %q, got %q %s %v", task.Title, task.Status, task.Tags, line, parsed.Title, parsed.Status, parsed.Tags)
+			}
+			if task.Priority != "" && parsed.Priority != task.Priority {
+				t.Errorf("expected priority %s from %q, got %s", task.Priority, line, parsed.Priority)
+			}
+			if (parsed.DueDate == nil) != (task.DueDate == nil) {
+				t.Errorf("expected due date %v from %q, got %v", task.DueDate, line, parsed.DueDate)
+			}
+		})
+	}
+}
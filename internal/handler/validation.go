@@ -0,0 +1,155 @@
+package handler
+
+import (
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	entranslations "github.com/go-playground/validator/v10/translations/en"
+	rutranslations "github.com/go-playground/validator/v10/translations/ru"
+	"maps"
+	"reflect"
+	"simple-tasks/internal/model"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+func init() {
+	// Errors name fields as clients know them: by their JSON names, and by
+	// the query parameter names for the request structs without JSON tags.
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		switch name {
+		case "-":
+			return ""
+		case "":
+			r, size := utf8.DecodeRuneInString(field.Name)
+			return string(unicode.ToLower(r)) + field.Name[size:]
+		}
+		return name
+	})
+
+	_ = validate.RegisterValidation("sort", func(fl validator.FieldLevel) bool {
+		_, err := model.ParseSort(fl.Field().String())
+		return err == nil
+	})
+	_ = validate.RegisterValidation("expansion", func(fl validator.FieldLevel) bool {
+		_, ok := expansions[fl.Field().String()]
+		return ok
+	})
+	_ = validate.RegisterValidation("date", func(fl validator.FieldLevel) bool {
+		_, err := model.ParseDate(fl.Field().String())
+		return err == nil
+	})
+}
+
+// fieldPath is the path of an invalid field without the name of the
+// validated struct, such as "tags[1]".
+func fieldPath(err validator.FieldError) string {
+	_, path, ok := strings.Cut(err.Namespace(), ".")
+	if !ok {
+		return err.Field()
+	}
+	return path
+}
+
+// ruleMessage describes a failed validation rule in words, in the language
+// of trans.
+func ruleMessage(trans ut.Translator, err validator.FieldError) string {
+	return err.Translate(trans)
+}
+
+// ruleTranslations describe the rules of the API in the words of the message
+// catalog; other rules get the default translations of the validator.
+var ruleTranslations = map[string]validator.TranslationFunc{
+	"required": func(trans ut.Translator, err validator.FieldError) string {
+		return localize(trans, "{0} is required", ruleField(err))
+	},
+	"gte": func(trans ut.Translator, err validator.FieldError) string {
+		return sizeMessage(trans, err.Kind(), sizeMessages["at least"], ruleField(err), err.Param())
+	},
+	"min": func(trans ut.Translator, err validator.FieldError) string {
+		return sizeMessage(trans, err.Kind(), sizeMessages["at least"], ruleField(err), err.Param())
+	},
+	"lte": func(trans ut.Translator, err validator.FieldError) string {
+		return sizeMessage(trans, err.Kind(), sizeMessages["at most"], ruleField(err), err.Param())
+	},
+	"max": func(trans ut.Translator, err validator.FieldError) string {
+		return sizeMessage(trans, err.Kind(), sizeMessages["at most"], ruleField(err), err.Param())
+	},
+	"len": func(trans ut.Translator, err validator.FieldError) string {
+		return sizeMessage(trans, err.Kind(), sizeMessages["exactly"], ruleField(err), err.Param())
+	},
+	"oneof": func(trans ut.Translator, err validator.FieldError) string {
+		return localize(trans, "{0} must be one of: {1}", ruleField(err), strings.Join(strings.Fields(err.Param()), ", "))
+	},
+	"url": func(trans ut.Translator, err validator.FieldError) string {
+		return localize(trans, "{0} must be an absolute URL", ruleField(err))
+	},
+	"boolean": func(trans ut.Translator, err validator.FieldError) string {
+		return localize(trans, "{0} must be true or false", ruleField(err))
+	},
+	"date": func(trans ut.Translator, err validator.FieldError) string {
+		return localize(trans, "{0} must be a date such as 2025-10-01 or an RFC 3339 date-time", ruleField(err))
+	},
+	"datetime": func(trans ut.Translator, err validator.FieldError) string {
+		return localize(trans, "{0} must be a date such as 2025-10-01", ruleField(err))
+	},
+	"hexcolor": func(trans ut.Translator, err validator.FieldError) string {
+		return localize(trans, "{0} must be a hex color such as #1e90ff", ruleField(err))
+	},
+	"sort": func(trans ut.Translator, err validator.FieldError) string {
+		return localize(trans, "{0} must list sort keys such as priority:desc,dueDate:asc", ruleField(err))
+	},
+	"expansion": func(trans ut.Translator, err validator.FieldError) string {
+		return localize(trans, "{0} must be one of: {1}", ruleField(err), strings.Join(slices.Sorted(maps.Keys(expansions)), ", "))
+	},
+}
+
+// defaultTranslations register the validator's own messages by locale.
+var defaultTranslations = map[string]func(v *validator.Validate, trans ut.Translator) error{
+	"en": entranslations.RegisterDefaultTranslations,
+	"ru": rutranslations.RegisterDefaultTranslations,
+}
+
+// registerRuleTranslations makes the validator describe failed rules in the
+// language of trans.
+func registerRuleTranslations(trans ut.Translator) error {
+	if err := defaultTranslations[trans.Locale()](validate, trans); err != nil {
+		return err
+	}
+	for tag, translate := range ruleTranslations {
+		// The messages are in the catalog already.
+		registered := func(ut.Translator) error { return nil }
+		if err := validate.RegisterTranslation(tag, trans, registered, translate); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ruleField names the invalid field in a rule message.
+func ruleField(err validator.FieldError) string {
+	if name := fieldPath(err); name != "" {
+		return name
+	}
+	return "value"
+}
+
+// sizeMessages are the messages of size limits by bound: for the length of
+// strings, the count of lists and the value of numbers.
+var sizeMessages = map[string][3]string{
+	"at least": {"{0} must be at least {1} long", "{0} must contain at least {1}", "{0} must be at least {1}"},
+	"at most":  {"{0} must be at most {1} long", "{0} must contain at most {1}", "{0} must be at most {1}"},
+	"exactly":  {"{0} must be exactly {1} long", "{0} must contain exactly {1}", "{0} must be {1}"},
+}
+
+func sizeMessage(trans ut.Translator, kind reflect.Kind, texts [3]string, name string, param string) string {
+	switch kind {
+	case reflect.String:
+		return localize(trans, texts[0], name, count(trans, "character", param))
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return localize(trans, texts[1], name, count(trans, "item", param))
+	}
+	return localize(trans, texts[2], name, param)
+}
@@ -0,0 +1,296 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"simple-tasks/internal/model"
+	"simple-tasks/internal/service"
+	"strconv"
+)
+
+// userHeader identifies the caller for view ownership and sharing.
+const userHeader = "X-User"
+
+type ViewHandler struct {
+	log     *slog.Logger
+	service *service.ViewService
+	tasks   *service.TaskService
+}
+
+func NewViewHandler(log *slog.Logger, service *service.ViewService, tasks *service.TaskService) *ViewHandler {
+	return &ViewHandler{
+		log:     log,
+		service: service,
+		tasks:   tasks,
+	}
+}
+
+func (h *ViewHandler) CreateView(w http.ResponseWriter, r *http.Request) {
+	if !negotiate(w, r) {
+		return
+	}
+
+	var newView model.View
+	if err := decode(w, r, &newView); err != nil {
+		h.log.ErrorContext(r.Context(), "invalid request body", slog.String("error", err.Error()))
+
+		writeDecodeError(w, r, err)
+		return
+	}
+
+	if err := validate.Struct(newView); err != nil {
+		h.log.ErrorContext(r.Context(), "invalid view", slog.String("error", err.Error()))
+
+		writeError(w, r, http.StatusUnprocessableEntity, errorValidation, err)
+		return
+	}
+
+	if err := checkViewQuery(r, newView.Query); err != nil {
+		h.log.ErrorContext(r.Context(), "invalid view query", slog.String("error", err.Error()))
+
+		writeError(w, r, http.StatusUnprocessableEntity, errorValidation, err)
+		return
+	}
+
+	createdView := h.service.CreateView(r.Context(), r.Header.Get(userHeader), &newView)
+	h.countTasks(r.Context(), createdView)
+
+	w.Header().Set("Location", fmt.Sprintf("/views/%s", createdView.Id))
+	w.WriteHeader(http.StatusCreated)
+	_ = encode(w, createdView)
+}
+
+func (h *ViewHandler) GetViews(w http.ResponseWriter, r *http.Request) {
+	if !negotiate(w, r) {
+		return
+	}
+
+	views := h.service.GetViews(r.Context(), r.Header.Get(userHeader))
+	for i := range views {
+		h.countTasks(r.Context(), &views[i])
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = encode(w, views)
+}
+
+func (h *ViewHandler) GetViewById(w http.ResponseWriter, r *http.Request) {
+	if !negotiate(w, r) {
+		return
+	}
+
+	id, err := pathId(r)
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "invalid id", slog.String("error", err.Error()))
+
+		writeError(w, r, http.StatusUnprocessableEntity, errorValidation, err)
+		return
+	}
+
+	view, err := h.service.GetViewById(r.Context(), r.Header.Get(userHeader), id)
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "view lookup failed", slog.String("error", err.Error()))
+
+		writeServiceError(w, r, err)
+		return
+	}
+
+	h.countTasks(r.Context(), view)
+
+	w.WriteHeader(http.StatusOK)
+	_ = encode(w, view)
+}
+
+func (h *ViewHandler) UpdateView(w http.ResponseWriter, r *http.Request) {
+	if !negotiate(w, r) {
+		return
+	}
+
+	id, err := pathId(r)
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "invalid id", slog.String("error", err.Error()))
+
+		writeError(w, r, http.StatusUnprocessableEntity, errorValidation, err)
+		return
+	}
+
+	var req model.UpdateViewRequest
+	if err := decode(w, r, &req); err != nil {
+		h.log.ErrorContext(r.Context(), "invalid request body", slog.String("error", err.Error()))
+
+		writeDecodeError(w, r, err)
+		return
+	}
+
+	if err := validate.Struct(req); err != nil {
+		h.log.ErrorContext(r.Context(), "invalid view", slog.String("error", err.Error()))
+
+		writeError(w, r, http.StatusUnprocessableEntity, errorValidation, err)
+		return
+	}
+
+	if req.Query != nil {
+		if err := checkViewQuery(r, *req.Query); err != nil {
+			h.log.ErrorContext(r.Context(), "invalid view query", slog.String("error", err.Error()))
+
+			writeError(w, r, http.StatusUnprocessableEntity, errorValidation, err)
+			return
+		}
+	}
+
+	view, err := h.service.UpdateView(r.Context(), r.Header.Get(userHeader), id, &req)
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "view update failed", slog.String("error", err.Error()))
+
+		writeServiceError(w, r, err)
+		return
+	}
+
+	h.countTasks(r.Context(), view)
+
+	w.WriteHeader(http.StatusOK)
+	_ = encode(w, view)
+}
+
+func (h *ViewHandler) DeleteView(w http.ResponseWriter, r *http.Request) {
+	if !negotiate(w, r) {
+		return
+	}
+
+	id, err := pathId(r)
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "invalid id", slog.String("error", err.Error()))
+
+		writeError(w, r, http.StatusUnprocessableEntity, errorValidation, err)
+		return
+	}
+
+	err = h.service.DeleteView(r.Context(), r.Header.Get(userHeader), id)
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "view delete failed", slog.String("error", err.Error()))
+
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetViewTasks runs the query of a view. Parameters of the request itself,
+// typically page, pageSize and cursor, override those stored in the view.
+func (h *ViewHandler) GetViewTasks(w http.ResponseWriter, r *http.Request) {
+	if !negotiate(w, r) {
+		return
+	}
+
+	if err := checkQuery(r, taskListParams, taskShapeParams); err != nil {
+		h.log.ErrorContext(r.Context(), "invalid query", slog.String("error", err.Error()))
+
+		writeError(w, r, http.StatusUnprocessableEntity, errorValidation, err)
+		return
+	}
+
+	id, err := pathId(r)
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "invalid id", slog.String("error", err.Error()))
+
+		writeError(w, r, http.StatusUnprocessableEntity, errorValidation, err)
+		return
+	}
+
+	view, err := h.service.GetViewById(r.Context(), r.Header.Get(userHeader), id)
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "view lookup failed", slog.String("error", err.Error()))
+
+		writeServiceError(w, r, err)
+		return
+	}
+
+	query, _ := url.ParseQuery(view.Query)
+	for key, values := range r.URL.Query() {
+		query[key] = values
+	}
+
+	req, err := parseTasksRequest(query)
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "invalid request", slog.String("error", err.Error()))
+
+		writeError(w, r, http.StatusUnprocessableEntity, errorValidation, err)
+		return
+	}
+
+	shape, err := parseTaskShape(r.URL.Query())
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "invalid request", slog.String("error", err.Error()))
+
+		writeError(w, r, http.StatusUnprocessableEntity, errorValidation, err)
+		return
+	}
+
+	tasks, err := h.tasks.GetTasks(r.Context(), req)
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "view tasks failed", slog.String("error", err.Error()))
+
+		writeServiceError(w, r, err)
+		return
+	}
+
+	body, err := shape.tasks(tasks)
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "internal error", slog.String("error", err.Error()))
+
+		writeError(w, r, http.StatusInternalServerError, errorInternal, err)
+		return
+	}
+
+	if link := paginationLinks(r.URL, tasks); link != "" {
+		w.Header().Set("Link", link)
+	}
+	w.WriteHeader(http.StatusOK)
+	_ = encode(w, body)
+}
+
+// countTasks sets the live count of tasks matching the view.
+func (h *ViewHandler) countTasks(ctx context.Context, view *model.View) {
+	req, err := parseViewQuery(view.Query)
+	if err != nil {
+		h.log.ErrorContext(ctx, "invalid view query", slog.String("error", err.Error()))
+		return
+	}
+	req.Page, req.PageSize, req.Cursor = nil, nil, ""
+
+	tasks, err := h.tasks.GetTasks(ctx, req)
+	if err != nil {
+		h.log.ErrorContext(ctx, "view count failed", slog.String("error", err.Error()))
+		return
+	}
+	view.Count = &tasks.Total
+}
+
+func parseViewQuery(rawQuery string) (*model.GetTasksRequest, error) {
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return nil, err
+	}
+	return parseTasksRequest(query)
+}
+
+// checkViewQuery validates the query of a view like the query of GET /tasks,
+// which views are applied as. Parameters that applying a view ignores, such
+// as fields, are rejected unless the request is lenient.
+func checkViewQuery(r *http.Request, rawQuery string) error {
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return newRequestError("{0} is not a valid query string", strconv.Quote(rawQuery))
+	}
+	if !lenient(r) {
+		if err := checkParams(query, taskListParams); err != nil {
+			return err
+		}
+	}
+	_, err = parseTasksRequest(query)
+	return err
+}
@@ -0,0 +1,240 @@
+package handler
+
+import (
+	json2 "encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"simple-tasks/internal/model"
+	"simple-tasks/internal/service"
+	"simple-tasks/internal/store"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func createTestViewHandler() (*TaskHandler, *ViewHandler) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	taskService := service.NewTaskService(log, store.NewInMemoryTaskRepository())
+	viewService := service.NewViewService(log, store.NewInMemoryViewRepository())
+
+	return NewTaskHandler(log, taskService), NewViewHandler(log, viewService, taskService)
+}
+
+func serveView(handler http.HandlerFunc, method string, target string, id string, user string, body string) *http.Response {
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req := httptest.NewRequest(method, target, reader)
+	req.SetPathValue("id", id)
+	if user != "" {
+		req.Header.Set(userHeader, user)
+	}
+	w := httptest.NewRecorder()
+	handler(w, req)
+	return w.Result()
+}
+
+func decodeView(t *testing.T, resp *http.Response) model.View {
+	var view model.View
+	if err := json2.NewDecoder(resp.Body).Decode(&view); err != nil {
+		t.Fatalf("error reading response body: %v", err)
+	}
+	return view
+}
+
+func TestCreateView(t *testing.T) {
+	taskHandler, handler := createTestViewHandler()
+	addTasks(taskHandler)
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		expectedCount  int
+	}{
+		{
+			name:           "valid view",
+			body:           `{"name":"Todo","query":"status=todo&sort=title&pageSize=2"}`,
+			expectedStatus: http.StatusCreated,
+			expectedCount:  4,
+		},
+		{
+			name:           "view with filter expression",
+			body:           `{"name":"Urgent","query":"filter=priority%20%3E%3D%20normal%20and%20status%20!%3D%20done"}`,
+			expectedStatus: http.StatusCreated,
+			expectedCount:  4,
+		},
+		{
+			name:           "missing name",
+			body:           `{"query":"status=todo"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "invalid status in query",
+			body:           `{"name":"Archived","query":"status=archived"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "invalid filter in query",
+			body:           `{"name":"Broken","query":"filter=status%20%3D"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "unknown parameter in query",
+			body:           `{"name":"Red","query":"status=todo&colour=red"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "fields in query",
+			body:           `{"name":"Titles","query":"status=todo&fields=title"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "invalid json",
+			body:           `{"name":`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := serveView(handler.CreateView, http.MethodPost, "/views", "", "alice", tt.body)
+			if resp.StatusCode != tt.expectedStatus {
+				t.Fatalf("expected status %v, got %v", tt.expectedStatus, resp.StatusCode)
+			}
+			if tt.expectedStatus != http.StatusCreated {
+				return
+			}
+
+			view := decodeView(t, resp)
+			if view.Owner != "alice" {
+				t.Errorf("expected owner alice, got %q", view.Owner)
+			}
+			if view.Count == nil || *view.Count != tt.expectedCount {
+				t.Errorf("expected count %v, got %v", tt.expectedCount, view.Count)
+			}
+		})
+	}
+}
+
+func TestUpdateViewQuery(t *testing.T) {
+	_, handler := createTestViewHandler()
+
+	view := decodeView(t, serveView(handler.CreateView, http.MethodPost, "/views", "", "alice",
+		`{"name":"Mine","query":"status=todo"}`))
+	id := view.Id.String()
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+	}{
+		{"valid query", `{"query":"status=done&sort=title"}`, http.StatusOK},
+		{"unknown parameter", `{"query":"stauts=done"}`, http.StatusUnprocessableEntity},
+		{"fields", `{"query":"fields=title"}`, http.StatusUnprocessableEntity},
+		{"invalid value", `{"query":"pageSize=many"}`, http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := serveView(handler.UpdateView, http.MethodPatch, "/views/"+id, id, "alice", tt.body)
+			if resp.StatusCode != tt.expectedStatus {
+				t.Errorf("expected status %v, got %v", tt.expectedStatus, resp.StatusCode)
+			}
+		})
+	}
+
+	view = decodeView(t, serveView(handler.GetViewById, http.MethodGet, "/views/"+id, id, "alice", ""))
+	if view.Query != "status=done&sort=title" {
+		t.Errorf("expected the valid query to be kept, got %q", view.Query)
+	}
+}
+
+func TestGetViewTasks(t *testing.T) {
+	taskHandler, handler := createTestViewHandler()
+	addTasks(taskHandler)
+
+	view := decodeView(t, serveView(handler.CreateView, http.MethodPost, "/views", "", "alice",
+		`{"name":"Todo","query":"status=todo&sort=title&pageSize=2"}`))
+	id := view.Id.String()
+
+	resp := serveView(handler.GetViewTasks, http.MethodGet, "/views/"+id+"/tasks", id, "alice", "")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status %v, got %v", http.StatusOK, resp.StatusCode)
+	}
+	var page model.GetTasksResponse
+	_ = json2.NewDecoder(resp.Body).Decode(&page)
+
+	titles := make([]string, 0, len(page.Tasks))
+	for _, task := range page.Tasks {
+		titles = append(titles, task.Title)
+	}
+	if expected := []string{"Купить молоко", "Погулять с друзьями"}; !slices.Equal(titles, expected) {
+		t.Errorf("expected titles %v, got %v", expected, titles)
+	}
+	if !strings.Contains(resp.Header.Get("Link"), `/views/`+id+`/tasks?cursor=`) {
+		t.Errorf("expected next link to the view, got %q", resp.Header.Get("Link"))
+	}
+
+	resp = serveView(handler.GetViewTasks, http.MethodGet, "/views/"+id+"/tasks?pageSize=10", id, "alice", "")
+	page = model.GetTasksResponse{}
+	_ = json2.NewDecoder(resp.Body).Decode(&page)
+	if len(page.Tasks) != 4 {
+		t.Errorf("expected request page size to override the view, got %d tasks", len(page.Tasks))
+	}
+
+	createTask(t, taskHandler, `{"title":"Новая задача"}`)
+	view = decodeView(t, serveView(handler.GetViewById, http.MethodGet, "/views/"+id, id, "alice", ""))
+	if view.Count == nil || *view.Count != 5 {
+		t.Errorf("expected live count 5, got %v", view.Count)
+	}
+}
+
+func TestViewSharing(t *testing.T) {
+	_, handler := createTestViewHandler()
+
+	view := decodeView(t, serveView(handler.CreateView, http.MethodPost, "/views", "", "alice",
+		`{"name":"Mine","query":"status=todo"}`))
+	id := view.Id.String()
+
+	listViews := func(user string) []model.View {
+		var views []model.View
+		_ = json2.NewDecoder(serveView(handler.GetViews, http.MethodGet, "/views", "", user, "").Body).Decode(&views)
+		return views
+	}
+
+	if views := listViews("bob"); len(views) != 0 {
+		t.Errorf("expected private view to be hidden from bob, got %v", views)
+	}
+	if resp := serveView(handler.GetViewById, http.MethodGet, "/views/"+id, id, "bob", ""); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected status %v, got %v", http.StatusNotFound, resp.StatusCode)
+	}
+
+	resp := serveView(handler.UpdateView, http.MethodPatch, "/views/"+id, id, "alice", `{"shared":true}`)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status %v, got %v", http.StatusOK, resp.StatusCode)
+	}
+
+	if views := listViews("bob"); len(views) != 1 || views[0].Count == nil {
+		t.Errorf("expected shared view with count for bob, got %v", views)
+	}
+	if resp := serveView(handler.GetViewTasks, http.MethodGet, "/views/"+id+"/tasks", id, "bob", ""); resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status %v, got %v", http.StatusOK, resp.StatusCode)
+	}
+	if resp := serveView(handler.UpdateView, http.MethodPatch, "/views/"+id, id, "bob", `{"name":"Ours"}`); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected status %v, got %v", http.StatusForbidden, resp.StatusCode)
+	}
+	if resp := serveView(handler.DeleteView, http.MethodDelete, "/views/"+id, id, "bob", ""); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected status %v, got %v", http.StatusForbidden, resp.StatusCode)
+	}
+	if resp := serveView(handler.DeleteView, http.MethodDelete, "/views/"+id, id, "alice", ""); resp.StatusCode != http.StatusNoContent {
+		t.Errorf("expected status %v, got %v", http.StatusNoContent, resp.StatusCode)
+	}
+	if views := listViews("alice"); len(views) != 0 {
+		t.Errorf("expected no views after delete, got %v", views)
+	}
+}
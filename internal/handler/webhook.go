@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"simple-tasks/internal/model"
+	"simple-tasks/internal/service"
+)
+
+type WebhookHandler struct {
+	log     *slog.Logger
+	service *service.WebhookService
+}
+
+func NewWebhookHandler(log *slog.Logger, service *service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		log:     log,
+		service: service,
+	}
+}
+
+func (h *WebhookHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	if !negotiate(w, r) {
+		return
+	}
+
+	var newWebhook model.Webhook
+	if err := decode(w, r, &newWebhook); err != nil {
+		h.log.ErrorContext(r.Context(), "invalid request body", slog.String("error", err.Error()))
+
+		writeDecodeError(w, r, err)
+		return
+	}
+
+	if err := validate.Struct(newWebhook); err != nil {
+		h.log.ErrorContext(r.Context(), "invalid webhook", slog.String("error", err.Error()))
+
+		writeError(w, r, http.StatusUnprocessableEntity, errorValidation, err)
+		return
+	}
+
+	createdWebhook, err := h.service.CreateWebhook(r.Context(), &newWebhook)
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "internal error", slog.String("error", err.Error()))
+
+		writeError(w, r, http.StatusInternalServerError, errorInternal, err)
+		return
+	}
+
+	// The secret is only revealed once, on creation.
+	w.Header().Set("Location", fmt.Sprintf("/webhooks/%s", createdWebhook.Id))
+	w.WriteHeader(http.StatusCreated)
+	_ = encode(w, createdWebhook)
+}
+
+func (h *WebhookHandler) GetWebhooks(w http.ResponseWriter, r *http.Request) {
+	if !negotiate(w, r) {
+		return
+	}
+
+	webhooks := h.service.GetWebhooks(r.Context())
+	for i := range webhooks {
+		webhooks[i].Secret = ""
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = encode(w, webhooks)
+}
+
+func (h *WebhookHandler) GetWebhookById(w http.ResponseWriter, r *http.Request) {
+	if !negotiate(w, r) {
+		return
+	}
+
+	id, err := pathId(r)
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "invalid id", slog.String("error", err.Error()))
+
+		writeError(w, r, http.StatusUnprocessableEntity, errorValidation, err)
+		return
+	}
+
+	webhook, err := h.service.GetWebhookById(r.Context(), id)
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "webhook lookup failed", slog.String("error", err.Error()))
+
+		writeServiceError(w, r, err)
+		return
+	}
+
+	webhook.Secret = ""
+	w.WriteHeader(http.StatusOK)
+	_ = encode(w, webhook)
+}
+
+func (h *WebhookHandler) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	if !negotiate(w, r) {
+		return
+	}
+
+	id, err := pathId(r)
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "invalid id", slog.String("error", err.Error()))
+
+		writeError(w, r, http.StatusUnprocessableEntity, errorValidation, err)
+		return
+	}
+
+	var req model.UpdateWebhookRequest
+	if err := decode(w, r, &req); err != nil {
+		h.log.ErrorContext(r.Context(), "invalid request body", slog.String("error", err.Error()))
+
+		writeDecodeError(w, r, err)
+		return
+	}
+
+	if err := validate.Struct(req); err != nil {
+		h.log.ErrorContext(r.Context(), "invalid webhook", slog.String("error", err.Error()))
+
+		writeError(w, r, http.StatusUnprocessableEntity, errorValidation, err)
+		return
+	}
+
+	webhook, err := h.service.UpdateWebhook(r.Context(), id, &req)
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "webhook update failed", slog.String("error", err.Error()))
+
+		writeServiceError(w, r, err)
+		return
+	}
+
+	webhook.Secret = ""
+	w.WriteHeader(http.StatusOK)
+	_ = encode(w, webhook)
+}
+
+func (h *WebhookHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	if !negotiate(w, r) {
+		return
+	}
+
+	id, err := pathId(r)
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "invalid id", slog.String("error", err.Error()))
+		writeError(w, r, http.StatusUnprocessableEntity, errorValidation, err)
+		return
+	}
+
+	err = h.service.DeleteWebhook(r.Context(), id)
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "webhook delete failed", slog.String("error", err.Error()))
+
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *WebhookHandler) GetDeliveries(w http.ResponseWriter, r *http.Request) {
+	if !negotiate(w, r) {
+		return
+	}
+
+	id, err := pathId(r)
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "invalid id", slog.String("error", err.Error()))
+
+		writeError(w, r, http.StatusUnprocessableEntity, errorValidation, err)
+		return
+	}
+
+	deliveries, err := h.service.GetDeliveries(r.Context(), id)
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "delivery lookup failed", slog.String("error", err.Error()))
+
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = encode(w, deliveries)
+}
@@ -0,0 +1,217 @@
+package handler
+
+import (
+	"context"
+	json2 "encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"simple-tasks/internal/model"
+	"simple-tasks/internal/service"
+	"simple-tasks/internal/store"
+	"strings"
+	"testing"
+	"time"
+)
+
+func createTestWebhookHandler() (*TaskHandler, *WebhookHandler, *service.WebhookService) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	taskService := service.NewTaskService(log, store.NewInMemoryTaskRepository())
+	webhookService := service.NewWebhookService(log, store.NewInMemoryWebhookRepository(), service.WebhookOptions{
+		MaxAttempts:    2,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     time.Millisecond,
+		DisableAfter:   2,
+		Timeout:        time.Second,
+	})
+	taskService.AddListener(webhookService.HandleEvent)
+
+	return NewTaskHandler(log, taskService), NewWebhookHandler(log, webhookService), webhookService
+}
+
+func createWebhook(t *testing.T, handler *WebhookHandler, body string) model.Webhook {
+	req := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	handler.CreateWebhook(w, req)
+
+	resp := w.Result()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected status %v, got %v", http.StatusCreated, resp.StatusCode)
+	}
+
+	var webhook model.Webhook
+	if err := json2.NewDecoder(resp.Body).Decode(&webhook); err != nil {
+		t.Fatalf("error reading response body: %v", err)
+	}
+	return webhook
+}
+
+// getDeliveries polls the delivery log until it has at least count entries,
+// since deliveries happen in the background.
+func getDeliveries(t *testing.T, handler *WebhookHandler, id string, count int) []model.WebhookDelivery {
+	var deliveries []model.WebhookDelivery
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		req := httptest.NewRequest(http.MethodGet, "/webhooks/", nil)
+		req.SetPathValue("id", id)
+		w := httptest.NewRecorder()
+		handler.GetDeliveries(w, req)
+
+		deliveries = nil
+		if err := json2.NewDecoder(w.Result().Body).Decode(&deliveries); err != nil {
+			t.Fatalf("error reading response body: %v", err)
+		}
+		if len(deliveries) >= count {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return deliveries
+}
+
+func TestCreateWebhook(t *testing.T) {
+	_, handler, _ := createTestWebhookHandler()
+
+	tests := []struct {
+		name           string
+		requestBody    string
+		expectedStatus int
+	}{
+		{
+			name:           "valid webhook",
+			requestBody:    `{"url":"http://example.com/hook","events":["task.created"]}`,
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "missing url",
+			requestBody:    `{"events":["task.created"]}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "unknown event",
+			requestBody:    `{"url":"http://example.com/hook","events":["task.archived"]}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "short secret",
+			requestBody:    `{"url":"http://example.com/hook","secret":"abc"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "invalid json",
+			requestBody:    `{"url":`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(tt.requestBody))
+			w := httptest.NewRecorder()
+			handler.CreateWebhook(w, req)
+
+			resp := w.Result()
+			if resp.StatusCode != tt.expectedStatus {
+				t.Errorf("expected status %v, got %v", tt.expectedStatus, resp.StatusCode)
+			}
+			if resp.Header.Get("Content-Type") != "application/json" {
+				t.Errorf("expected Content-Type application/json, got %s", resp.Header.Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestWebhookDelivery(t *testing.T) {
+	taskHandler, webhookHandler, webhookService := createTestWebhookHandler()
+
+	received := make(chan *http.Request, 10)
+	bodies := make(chan []byte, 10)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- r
+		bodies <- body
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	secret := "0123456789abcdef0123"
+	webhook := createWebhook(t, webhookHandler,
+		fmt.Sprintf(`{"url":%q,"events":["task.created"],"secret":%q}`, receiver.URL, secret))
+
+	req := httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(`{"title":"Test task"}`))
+	taskHandler.CreateTask(httptest.NewRecorder(), req)
+
+	var hookReq *http.Request
+	var body []byte
+	select {
+	case hookReq = <-received:
+		body = <-bodies
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook was not delivered")
+	}
+
+	if hookReq.Header.Get(service.HeaderWebhookEvent) != model.EventTaskCreated {
+		t.Errorf("expected event %v, got %v", model.EventTaskCreated, hookReq.Header.Get(service.HeaderWebhookEvent))
+	}
+	expectedSignature := "sha256=" + service.SignWebhookPayload(secret, hookReq.Header.Get(service.HeaderWebhookTimestamp), body)
+	if hookReq.Header.Get(service.HeaderWebhookSignature) != expectedSignature {
+		t.Errorf("expected signature %v, got %v", expectedSignature, hookReq.Header.Get(service.HeaderWebhookSignature))
+	}
+
+	var event model.TaskEvent
+	if err := json2.Unmarshal(body, &event); err != nil {
+		t.Fatalf("error decoding payload: %v", err)
+	}
+	if event.Task.Title != "Test task" {
+		t.Errorf("expected task title %v, got %v", "Test task", event.Task.Title)
+	}
+
+	webhookService.Close(context.Background())
+
+	deliveries := getDeliveries(t, webhookHandler, webhook.Id.String(), 1)
+	if len(deliveries) != 1 || !deliveries[0].Success {
+		t.Errorf("expected one successful delivery, got %+v", deliveries)
+	}
+}
+
+func TestWebhookDisabledAfterFailures(t *testing.T) {
+	taskHandler, webhookHandler, webhookService := createTestWebhookHandler()
+
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer receiver.Close()
+
+	webhook := createWebhook(t, webhookHandler, fmt.Sprintf(`{"url":%q}`, receiver.URL))
+
+	req := httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(`{"title":"Test task"}`))
+	taskHandler.CreateTask(httptest.NewRecorder(), req)
+	req = httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(`{"title":"Another task"}`))
+	taskHandler.CreateTask(httptest.NewRecorder(), req)
+
+	deliveries := getDeliveries(t, webhookHandler, webhook.Id.String(), 4)
+	if len(deliveries) != 4 {
+		t.Errorf("expected 4 delivery attempts, got %d", len(deliveries))
+	}
+	webhookService.Close(context.Background())
+
+	req = httptest.NewRequest(http.MethodGet, "/webhooks/", nil)
+	req.SetPathValue("id", webhook.Id.String())
+	w := httptest.NewRecorder()
+	webhookHandler.GetWebhookById(w, req)
+
+	var actual model.Webhook
+	_ = json2.NewDecoder(w.Result().Body).Decode(&actual)
+	if actual.Active {
+		t.Errorf("expected webhook to be disabled")
+	}
+	if actual.DisabledAt == nil {
+		t.Errorf("expected disabledAt to be set")
+	}
+	if actual.Secret != "" {
+		t.Errorf("expected secret to be hidden")
+	}
+}
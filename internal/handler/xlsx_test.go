@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+)
+
+type xlsxSheet struct {
+	Rows []struct {
+		Cells []struct {
+			Ref    string `xml:"r,attr"`
+			Style  string `xml:"s,attr"`
+			Type   string `xml:"t,attr"`
+			Value  string `xml:"v"`
+			Inline string `xml:"is>t"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+	AutoFilter struct {
+		Ref string `xml:"ref,attr"`
+	} `xml:"autoFilter"`
+}
+
+// readXlsx opens an exported workbook, checks every part is well-formed
+// XML and returns the sheet names with the parsed sheets.
+func readXlsx(t *testing.T, body []byte) ([]string, []xlsxSheet) {
+	archive, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		t.Fatalf("error opening workbook: %v", err)
+	}
+
+	parts := make(map[string][]byte)
+	for _, file := range archive.File {
+		rc, err := file.Open()
+		if err != nil {
+			t.Fatalf("error opening %s: %v", file.Name, err)
+		}
+		data, _ := io.ReadAll(rc)
+		_ = rc.Close()
+
+		decoder := xml.NewDecoder(bytes.NewReader(data))
+		for {
+			if _, err := decoder.Token(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("%s is not well-formed: %v", file.Name, err)
+			}
+		}
+		parts[file.Name] = data
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml"} {
+		if _, ok := parts[name]; !ok {
+			t.Fatalf("workbook has no %s", name)
+		}
+	}
+
+	var workbook struct {
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := xml.Unmarshal(parts["xl/workbook.xml"], &workbook); err != nil {
+		t.Fatalf("error reading workbook: %v", err)
+	}
+
+	names := make([]string, 0, len(workbook.Sheets))
+	sheets := make([]xlsxSheet, 0, len(workbook.Sheets))
+	for i, sheet := range workbook.Sheets {
+		names = append(names, sheet.Name)
+
+		var parsed xlsxSheet
+		data, ok := parts["xl/worksheets/sheet"+string(rune('1'+i))+".xml"]
+		if !ok {
+			t.Fatalf("workbook has no part for sheet %s", sheet.Name)
+		}
+		if err := xml.Unmarshal(data, &parsed); err != nil {
+			t.Fatalf("error reading sheet %s: %v", sheet.Name, err)
+		}
+		sheets = append(sheets, parsed)
+	}
+	return names, sheets
+}
+
+func TestExportTasksXlsx(t *testing.T) {
+	handler := createTestHandler()
+	addTasks(handler)
+	createTask(t, handler, `{"title":"Отчет <Q3> & итоги","content":"строка 1\nстрока 2","tags":["финансы","q3"],"dueDate":"2025-10-01T12:00:00Z"}`)
+	createTask(t, handler, `{"title":"Сдать отчет","status":"done","tags":["финансы"]}`)
+
+	resp := exportTasks(t, handler, "?format=xlsx&tag=финансы&sort=title")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status %v, got %v", http.StatusOK, resp.StatusCode)
+	}
+	if contentType := resp.Header.Get("Content-Type"); contentType != "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet" {
+		t.Errorf("expected xlsx content type, got %q", contentType)
+	}
+	if disposition := resp.Header.Get("Content-Disposition"); !strings.HasSuffix(disposition, `.xlsx"`) {
+		t.Errorf("unexpected Content-Disposition %q", disposition)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("error reading response body: %v", err)
+	}
+
+	names, sheets := readXlsx(t, body)
+	if !slices.Equal(names, []string{"todo", "in_progress", "done"}) {
+		t.Fatalf("expected a sheet per status, got %v", names)
+	}
+
+	counts := []int{len(sheets[0].Rows), len(sheets[1].Rows), len(sheets[2].Rows)}
+	if !slices.Equal(counts, []int{2, 2, 2}) {
+		t.Fatalf("expected header and filtered rows per sheet, got %v", counts)
+	}
+	if ref := sheets[0].AutoFilter.Ref; ref != "A1:K2" {
+		t.Errorf("expected autofilter over the header and row, got %q", ref)
+	}
+
+	header := sheets[0].Rows[0].Cells
+	if len(header) != len(csvHeader) || header[0].Inline != "id" || header[0].Style != "1" {
+		t.Errorf("expected a styled header row, got %+v", header)
+	}
+
+	row := sheets[0].Rows[1].Cells
+	if row[1].Inline != "Отчет <Q3> & итоги" || row[2].Inline != "строка 1\nстрока 2" || row[5].Inline != "финансы;q3" {
+		t.Errorf("expected text cells to round-trip, got %+v", row)
+	}
+	// 2025-10-01 12:00 UTC is day 45931.5 of the 1900 date system.
+	if due := row[6]; due.Ref != "G2" || due.Type != "" || due.Style != "2" || due.Value != "45931.5" {
+		t.Errorf("expected a typed date cell, got %+v", due)
+	}
+	if title := sheets[1].Rows[1].Cells[1].Inline; title != "Подготовить отчет" {
+		t.Errorf("expected the task in progress on its own sheet, got %q", title)
+	}
+	// Empty cells are left out, so the completion date is found by reference.
+	completed := false
+	for _, cell := range sheets[2].Rows[1].Cells {
+		completed = completed || (cell.Ref == "J2" && cell.Style == "2" && cell.Value != "")
+	}
+	if !completed {
+		t.Errorf("expected the completion date of the done task, got %+v", sheets[2].Rows[1].Cells)
+	}
+}
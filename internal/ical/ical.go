@@ -0,0 +1,354 @@
+// Package ical reads and writes the iCalendar format of RFC 5545: content
+// lines with folding and text escaping, and nested BEGIN/END components.
+// task.go maps tasks to VTODO components.
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// maxLineOctets is the longest content line RFC 5545 allows, without the
+	// line break.
+	maxLineOctets = 75
+
+	dateTimeFormat = "20060102T150405Z"
+	localFormat    = "20060102T150405"
+	dateFormat     = "20060102"
+)
+
+type Property struct {
+	Name   string
+	Params map[string]string
+	// Value is the value as it appears in the file, still escaped.
+	Value string
+}
+
+type Component struct {
+	Name       string
+	Properties []Property
+	Components []*Component
+	// Line is the line of the BEGIN of a parsed component.
+	Line int
+}
+
+type ParseError struct {
+	Line int
+	Msg  string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("ical: line %d: %s", e.Line, e.Msg)
+}
+
+// Get returns the first property with the name, or nil.
+func (c *Component) Get(name string) *Property {
+	for i := range c.Properties {
+		if c.Properties[i].Name == name {
+			return &c.Properties[i]
+		}
+	}
+	return nil
+}
+
+func (c *Component) GetAll(name string) []Property {
+	properties := make([]Property, 0)
+	for _, p := range c.Properties {
+		if p.Name == name {
+			properties = append(properties, p)
+		}
+	}
+	return properties
+}
+
+func (c *Component) Add(name string, value string) {
+	c.Properties = append(c.Properties, Property{Name: name, Value: value})
+}
+
+func (c *Component) AddText(name string, text string) {
+	c.Add(name, EscapeText(text))
+}
+
+func (c *Component) AddDateTime(name string, t time.Time) {
+	c.Add(name, t.UTC().Format(dateTimeFormat))
+}
+
+// Text returns the unescaped text value.
+func (p *Property) Text() string {
+	return UnescapeText(p.Value)
+}
+
+// TextList splits a multi-valued text property, such as CATEGORIES, on its
+// unescaped commas.
+func (p *Property) TextList() []string {
+	values := make([]string, 0)
+	start := 0
+	for i := 0; i < len(p.Value); i++ {
+		switch p.Value[i] {
+		case '\\':
+			i++
+		case ',':
+			values = append(values, UnescapeText(p.Value[start:i]))
+			start = i + 1
+		}
+	}
+	return append(values, UnescapeText(p.Value[start:]))
+}
+
+// DateTime parses a DATE or DATE-TIME value. Dates are midnight UTC; local
+// times use the TZID parameter when the zone is known and UTC otherwise.
+func (p *Property) DateTime() (time.Time, error) {
+	value := strings.TrimSpace(p.Value)
+	if p.Params["VALUE"] == "DATE" || len(value) == len(dateFormat) {
+		return time.Parse(dateFormat, value)
+	}
+	if strings.HasSuffix(value, "Z") {
+		return time.Parse(dateTimeFormat, value)
+	}
+
+	location := time.UTC
+	if tzid := p.Params["TZID"]; tzid != "" {
+		if loaded, err := time.LoadLocation(tzid); err == nil {
+			location = loaded
+		}
+	}
+	return time.ParseInLocation(localFormat, value, location)
+}
+
+func EscapeText(text string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(text)
+}
+
+func UnescapeText(value string) string {
+	var sb strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\\' || i+1 == len(value) {
+			sb.WriteByte(value[i])
+			continue
+		}
+		i++
+		switch value[i] {
+		case 'n', 'N':
+			sb.WriteByte('\n')
+		default:
+			sb.WriteByte(value[i])
+		}
+	}
+	return sb.String()
+}
+
+// Encoder writes components as folded CRLF content lines. Begin and End let
+// callers stream a calendar one child component at a time.
+type Encoder struct {
+	w   *bufio.Writer
+	err error
+}
+
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: bufio.NewWriter(w)}
+}
+
+func (e *Encoder) Begin(name string) {
+	e.line("BEGIN:" + name)
+}
+
+func (e *Encoder) End(name string) {
+	e.line("END:" + name)
+}
+
+func (e *Encoder) Property(p Property) {
+	var sb strings.Builder
+	sb.WriteString(p.Name)
+	for _, name := range slices.Sorted(maps.Keys(p.Params)) {
+		value := p.Params[name]
+		sb.WriteString(";" + name + "=")
+		if strings.ContainsAny(value, ":;,") {
+			value = `"` + value + `"`
+		}
+		sb.WriteString(value)
+	}
+	sb.WriteString(":" + p.Value)
+	e.line(sb.String())
+}
+
+func (e *Encoder) Component(c *Component) {
+	e.Begin(c.Name)
+	for _, p := range c.Properties {
+		e.Property(p)
+	}
+	for _, child := range c.Components {
+		e.Component(child)
+	}
+	e.End(c.Name)
+}
+
+// Flush writes buffered lines and returns the first error encountered.
+func (e *Encoder) Flush() error {
+	if e.err == nil {
+		e.err = e.w.Flush()
+	}
+	return e.err
+}
+
+// line writes a content line, folding it after at most 75 octets without
+// splitting a UTF-8 sequence.
+func (e *Encoder) line(line string) {
+	if e.err != nil {
+		return
+	}
+
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		_, e.err = e.w.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+		// The leading space of a continuation line counts towards its length.
+		limit = maxLineOctets - 1
+	}
+	if e.err == nil {
+		_, e.err = e.w.WriteString(line + "\r\n")
+	}
+}
+
+// Parse reads a single top-level component, usually a VCALENDAR.
+func Parse(r io.Reader) (*Component, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	type contentLine struct {
+		number int
+		text   string
+	}
+	lines := make([]contentLine, 0)
+	number := 0
+	for scanner.Scan() {
+		number++
+		text := strings.TrimSuffix(scanner.Text(), "\r")
+		if number == 1 {
+			text = strings.TrimPrefix(text, "\ufeff")
+		}
+		if (strings.HasPrefix(text, " ") || strings.HasPrefix(text, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1].text += text[1:]
+			continue
+		}
+		if text != "" {
+			lines = append(lines, contentLine{number: number, text: text})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	var root *Component
+	stack := make([]*Component, 0)
+	for _, line := range lines {
+		p, err := parseProperty(line.text)
+		if err != nil {
+			return nil, &ParseError{Line: line.number, Msg: err.Error()}
+		}
+
+		switch p.Name {
+		case "BEGIN":
+			c := &Component{Name: strings.ToUpper(p.Value), Line: line.number}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.Components = append(parent.Components, c)
+			} else if root != nil {
+				return nil, &ParseError{Line: line.number, Msg: "more than one top-level component"}
+			} else {
+				root = c
+			}
+			stack = append(stack, c)
+		case "END":
+			if len(stack) == 0 || stack[len(stack)-1].Name != strings.ToUpper(p.Value) {
+				return nil, &ParseError{Line: line.number, Msg: fmt.Sprintf("unexpected END:%s", p.Value)}
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			if len(stack) == 0 {
+				return nil, &ParseError{Line: line.number, Msg: "property outside of a component"}
+			}
+			c := stack[len(stack)-1]
+			c.Properties = append(c.Properties, p)
+		}
+	}
+
+	if root == nil {
+		return nil, &ParseError{Line: number, Msg: "no component found"}
+	}
+	if len(stack) > 0 {
+		return nil, &ParseError{Line: number, Msg: fmt.Sprintf("missing END:%s", stack[len(stack)-1].Name)}
+	}
+
+	return root, nil
+}
+
+// parseProperty splits a content line into its name, parameters and value.
+func parseProperty(line string) (Property, error) {
+	p := Property{}
+
+	i := strings.IndexAny(line, ";:")
+	if i <= 0 {
+		return p, fmt.Errorf("invalid content line %q", line)
+	}
+	p.Name = strings.ToUpper(line[:i])
+
+	for line[i] == ';' {
+		rest := line[i+1:]
+		eq := strings.IndexByte(rest, '=')
+		if eq <= 0 {
+			return p, fmt.Errorf("invalid parameter in %q", line)
+		}
+		name := strings.ToUpper(rest[:eq])
+
+		j := eq + 1
+		var value string
+		if j < len(rest) && rest[j] == '"' {
+			end := strings.IndexByte(rest[j+1:], '"')
+			if end < 0 {
+				return p, fmt.Errorf("unterminated parameter value in %q", line)
+			}
+			value = rest[j+1 : j+1+end]
+			j += end + 2
+		} else {
+			end := strings.IndexAny(rest[j:], ";:")
+			if end < 0 {
+				return p, fmt.Errorf("missing value in %q", line)
+			}
+			value = rest[j : j+end]
+			j += end
+		}
+
+		if p.Params == nil {
+			p.Params = make(map[string]string)
+		}
+		p.Params[name] = value
+
+		i += 1 + j
+		if i >= len(line) {
+			return p, fmt.Errorf("missing value in %q", line)
+		}
+	}
+
+	if line[i] != ':' {
+		return p, fmt.Errorf("invalid content line %q", line)
+	}
+	p.Value = line[i+1:]
+	return p, nil
+}
@@ -0,0 +1,145 @@
+package ical
+
+import (
+	"fmt"
+	"simple-tasks/internal/model"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const ProductId = "-//simple-tasks//tasks//EN"
+
+var todoStatuses = map[string]string{
+	model.StatusTodo:       "NEEDS-ACTION",
+	model.StatusInProgress: "IN-PROCESS",
+	model.StatusDone:       "COMPLETED",
+}
+
+// todoPriorities use the top of each RFC 5545 range: 1-4 is high, 5 normal
+// and 6-9 low.
+var todoPriorities = map[string]int{
+	model.PriorityHigh:   1,
+	model.PriorityNormal: 5,
+	model.PriorityLow:    9,
+}
+
+// PropertyError is a VTODO property that can't be mapped to a task field.
+type PropertyError struct {
+	Property string
+	Msg      string
+}
+
+func (e *PropertyError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Property, e.Msg)
+}
+
+// TodoFromTask maps a task to a VTODO stamped with the given time.
+func TodoFromTask(task *model.Task, stamp time.Time) *Component {
+	c := &Component{Name: "VTODO"}
+	c.AddText("UID", task.ExportId())
+	c.AddDateTime("DTSTAMP", stamp)
+	c.AddDateTime("CREATED", task.CreatedAt)
+	c.AddDateTime("LAST-MODIFIED", task.UpdatedAt)
+	c.AddText("SUMMARY", task.Title)
+	if task.Content != "" {
+		c.AddText("DESCRIPTION", task.Content)
+	}
+	if task.DueDate != nil {
+		c.AddDateTime("DUE", *task.DueDate)
+	}
+	if status, ok := todoStatuses[task.Status]; ok {
+		c.Add("STATUS", status)
+	}
+	if task.CompletedAt != nil {
+		c.AddDateTime("COMPLETED", *task.CompletedAt)
+	}
+	if priority, ok := todoPriorities[task.Priority]; ok {
+		c.Add("PRIORITY", strconv.Itoa(priority))
+	}
+	if len(task.Tags) > 0 {
+		categories := make([]string, 0, len(task.Tags))
+		for _, tag := range task.Tags {
+			categories = append(categories, EscapeText(tag))
+		}
+		c.Add("CATEGORIES", strings.Join(categories, ","))
+	}
+	return c
+}
+
+// TaskFromTodo maps a VTODO to a task. The UID becomes the external id;
+// server-managed fields such as CREATED and COMPLETED are ignored.
+func TaskFromTodo(c *Component) (model.Task, error) {
+	task := model.Task{}
+
+	if p := c.Get("UID"); p != nil {
+		task.ExternalId = strings.TrimSpace(p.Text())
+	}
+	if p := c.Get("SUMMARY"); p != nil {
+		task.Title = p.Text()
+	}
+	if p := c.Get("DESCRIPTION"); p != nil {
+		task.Content = p.Text()
+	}
+
+	if p := c.Get("DUE"); p != nil {
+		due, err := p.DateTime()
+		if err != nil {
+			return task, &PropertyError{Property: "DUE", Msg: fmt.Sprintf("invalid date %q", p.Value)}
+		}
+		due = due.UTC()
+		task.DueDate = &due
+	}
+
+	if p := c.Get("STATUS"); p != nil {
+		switch strings.ToUpper(strings.TrimSpace(p.Value)) {
+		case "NEEDS-ACTION":
+			task.Status = model.StatusTodo
+		case "IN-PROCESS":
+			task.Status = model.StatusInProgress
+		case "COMPLETED", "CANCELLED":
+			task.Status = model.StatusDone
+		default:
+			return task, &PropertyError{Property: "STATUS", Msg: fmt.Sprintf("unknown status %q", p.Value)}
+		}
+	}
+
+	if p := c.Get("PRIORITY"); p != nil {
+		priority, err := strconv.Atoi(strings.TrimSpace(p.Value))
+		switch {
+		case err != nil || priority < 0 || priority > 9:
+			return task, &PropertyError{Property: "PRIORITY", Msg: fmt.Sprintf("invalid priority %q, expected 0-9", p.Value)}
+		case priority == 0:
+			// 0 means undefined; the task gets the default priority.
+		case priority <= 4:
+			task.Priority = model.PriorityHigh
+		case priority == 5:
+			task.Priority = model.PriorityNormal
+		default:
+			task.Priority = model.PriorityLow
+		}
+	}
+
+	for _, p := range c.GetAll("CATEGORIES") {
+		for _, category := range p.TextList() {
+			if category = strings.TrimSpace(category); category != "" {
+				task.Tags = append(task.Tags, category)
+			}
+		}
+	}
+
+	return task, nil
+}
+
+// TaskCalendar wraps the VTODO of a single task in a VCALENDAR, the shape of
+// a calendar object resource.
+func TaskCalendar(task *model.Task, stamp time.Time) *Component {
+	return &Component{
+		Name: "VCALENDAR",
+		Properties: []Property{
+			{Name: "VERSION", Value: "2.0"},
+			{Name: "PRODID", Value: ProductId},
+		},
+		Components: []*Component{TodoFromTask(task, stamp)},
+	}
+}
@@ -0,0 +1,163 @@
+// Package importer reads the exports of other task tools, such as Trello
+// boards and Todoist projects, into tasks.
+//
+// Lists and sections become statuses, labels tags and checklists (or
+// subtasks) checklist lines in the content. Data without a task field is
+// counted in Document.Unmapped instead of being dropped silently.
+package importer
+
+import (
+	"fmt"
+	"io"
+	"simple-tasks/internal/model"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Importer reads an export into tasks. Task ids and timestamps are left to
+// the task service.
+type Importer interface {
+	Read(r io.Reader) (*Document, error)
+}
+
+// Item is a task read from an export.
+type Item struct {
+	// Ref locates the task in the export, such as a card name or a line.
+	Ref  string
+	Task model.Task
+}
+
+type Document struct {
+	Items []Item
+	// Unmapped counts the data without a task field, by kind.
+	Unmapped map[string]int
+}
+
+func newDocument() *Document {
+	return &Document{
+		Items:    make([]Item, 0),
+		Unmapped: make(map[string]int),
+	}
+}
+
+func (d *Document) unmapped(kind string, n int) {
+	if n > 0 {
+		d.Unmapped[kind] += n
+	}
+}
+
+type Options struct {
+	// Statuses maps list or section names, compared case-insensitively, to
+	// task statuses. Other lists get a status guessed from their name.
+	Statuses map[string]string
+}
+
+// FormatError is an export that doesn't have the expected structure.
+type FormatError struct {
+	Msg string
+}
+
+func (e *FormatError) Error() string {
+	return e.Msg
+}
+
+var (
+	importersMu sync.RWMutex
+	importers   = map[string]func(Options) Importer{
+		"trello":  func(options Options) Importer { return &Trello{options: options} },
+		"todoist": func(options Options) Importer { return &Todoist{options: options} },
+	}
+)
+
+// Register makes an importer available under the source name, replacing any
+// importer registered before.
+func Register(source string, create func(Options) Importer) {
+	importersMu.Lock()
+	importers[source] = create
+	importersMu.Unlock()
+}
+
+// New returns the importer for the source, or false if there is none.
+func New(source string, options Options) (Importer, bool) {
+	importersMu.RLock()
+	create, ok := importers[source]
+	importersMu.RUnlock()
+	if !ok {
+		return nil, false
+	}
+	return create(options), true
+}
+
+// Sources returns the names of the registered importers, sorted.
+func Sources() []string {
+	importersMu.RLock()
+	defer importersMu.RUnlock()
+
+	sources := make([]string, 0, len(importers))
+	for source := range importers {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+	return sources
+}
+
+var (
+	doneWords       = []string{"done", "complete", "finished", "closed", "shipped", "готово", "сделано", "выполнено"}
+	inProgressWords = []string{"doing", "progress", "review", "wip", "в работе", "в процессе"}
+)
+
+// status maps a list name to a task status: the configured one if there is
+// one, otherwise done or in progress when the name says so, and todo.
+func (o *Options) status(list string) string {
+	for name, status := range o.Statuses {
+		if strings.EqualFold(strings.TrimSpace(name), strings.TrimSpace(list)) {
+			return status
+		}
+	}
+
+	list = strings.ToLower(list)
+	contains := func(word string) bool { return strings.Contains(list, word) }
+	switch {
+	case slices.ContainsFunc(doneWords, contains):
+		return model.StatusDone
+	case slices.ContainsFunc(inProgressWords, contains):
+		return model.StatusInProgress
+	}
+	return model.StatusTodo
+}
+
+type checkItem struct {
+	name     string
+	complete bool
+}
+
+// formatChecklist renders a checklist as Markdown task list items under its
+// name.
+func formatChecklist(name string, items []checkItem) string {
+	lines := make([]string, 0, len(items)+1)
+	if name != "" {
+		lines = append(lines, name)
+	}
+	for _, item := range items {
+		mark := " "
+		if item.complete {
+			mark = "x"
+		}
+		lines = append(lines, fmt.Sprintf("- [%s] %s", mark, item.name))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// joinContent joins the non-empty parts of a task's content with blank
+// lines.
+func joinContent(parts ...string) string {
+	nonEmpty := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			nonEmpty = append(nonEmpty, part)
+		}
+	}
+	return strings.Join(nonEmpty, "\n\n")
+}
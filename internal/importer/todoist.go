@@ -0,0 +1,394 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	json2 "encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"simple-tasks/internal/model"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Todoist reads a project exported as CSV, or tasks as returned by the
+// Todoist API: a JSON array of tasks, or an object with "items" (or "tasks"),
+// "sections" and "notes" (or "comments"). Subtasks become checklist lines of
+// their parent, and notes are appended to its content.
+type Todoist struct {
+	options Options
+}
+
+func (t *Todoist) Read(r io.Reader) (*Document, error) {
+	reader := bufio.NewReader(r)
+	for {
+		b, err := reader.Peek(1)
+		if err != nil || (b[0] != ' ' && b[0] != '\t' && b[0] != '\r' && b[0] != '\n') {
+			break
+		}
+		_, _ = reader.ReadByte()
+	}
+
+	if b, _ := reader.Peek(1); len(b) > 0 && (b[0] == '[' || b[0] == '{') {
+		return t.readJson(reader)
+	}
+	return t.readCsv(reader)
+}
+
+// readCsv reads the CSV export of a project. Only the columns TYPE and
+// CONTENT are required; labels are the "@label" words of the content.
+func (t *Todoist) readCsv(r io.Reader) (*Document, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, &FormatError{Msg: "csv has no header row"}
+	} else if err != nil {
+		return nil, err
+	}
+	columns := make(map[string]int, len(header))
+	for i, column := range header {
+		columns[strings.ToUpper(strings.TrimSpace(strings.TrimPrefix(column, "\ufeff")))] = i
+	}
+	for _, column := range []string{"TYPE", "CONTENT"} {
+		if _, ok := columns[column]; !ok {
+			return nil, &FormatError{Msg: fmt.Sprintf("not a Todoist export: csv has no %s column", column)}
+		}
+	}
+
+	doc := newDocument()
+	section := ""
+	// parent is the index of the last top-level task, which subtasks and
+	// notes belong to.
+	parent := -1
+	var checklist []checkItem
+	var notes []string
+	finish := func() {
+		if parent >= 0 {
+			task := &doc.Items[parent].Task
+			task.Content = joinContent(task.Content, formatChecklist("", checklist), strings.Join(notes, "\n\n"))
+		}
+		checklist, notes = nil, nil
+	}
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		value := func(column string) string {
+			if i, ok := columns[column]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		switch strings.ToLower(value("TYPE")) {
+		case "section":
+			finish()
+			parent = -1
+			section = value("CONTENT")
+		case "note":
+			if parent < 0 {
+				doc.unmapped("projectNotes", 1)
+				continue
+			}
+			notes = append(notes, value("CONTENT"))
+		case "task":
+			title, labels := todoistLabels(value("CONTENT"))
+			if indent, _ := strconv.Atoi(value("INDENT")); indent > 1 && parent >= 0 {
+				checklist = append(checklist, checkItem{name: title})
+				doc.unmapped("subtaskDetails", len(labels))
+				continue
+			}
+			finish()
+
+			task := model.Task{
+				Title:    title,
+				Content:  value("DESCRIPTION"),
+				Status:   t.options.status(section),
+				Priority: todoistPriority(value("PRIORITY"), false),
+				Tags:     labels,
+			}
+			if date := value("DATE"); date != "" {
+				if due, ok := todoistDate(date); ok {
+					task.DueDate = &due
+				} else {
+					doc.unmapped("dueDates", 1)
+				}
+			}
+			if value("RESPONSIBLE") != "" {
+				doc.unmapped("assignees", 1)
+			}
+			if value("DURATION") != "" {
+				doc.unmapped("durations", 1)
+			}
+			if value("DEADLINE") != "" {
+				doc.unmapped("deadlines", 1)
+			}
+
+			doc.Items = append(doc.Items, Item{Ref: fmt.Sprintf("line %d", line), Task: task})
+			parent = len(doc.Items) - 1
+		}
+	}
+	finish()
+
+	return doc, nil
+}
+
+// todoistId is an id, which older API versions returned as a number.
+type todoistId string
+
+func (id *todoistId) UnmarshalJSON(data []byte) error {
+	var value any
+	if err := json2.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	switch value := value.(type) {
+	case string:
+		*id = todoistId(value)
+	case float64:
+		*id = todoistId(strconv.FormatFloat(value, 'f', -1, 64))
+	}
+	return nil
+}
+
+type todoistExport struct {
+	Items    []todoistTask      `json:"items"`
+	Tasks    []todoistTask      `json:"tasks"`
+	Sections []todoistSection   `json:"sections"`
+	Notes    []todoistNote      `json:"notes"`
+	Comments []todoistNote      `json:"comments"`
+	Projects []json2.RawMessage `json:"projects"`
+}
+
+type todoistTask struct {
+	Id          todoistId   `json:"id"`
+	Content     string      `json:"content"`
+	Description string      `json:"description"`
+	Priority    int         `json:"priority"`
+	Labels      []string    `json:"labels"`
+	Due         *todoistDue `json:"due"`
+	SectionId   todoistId   `json:"section_id"`
+	ParentId    todoistId   `json:"parent_id"`
+	IsCompleted bool        `json:"is_completed"`
+	Checked     bool        `json:"checked"`
+	AssigneeId  todoistId   `json:"assignee_id"`
+	Responsible todoistId   `json:"responsible_uid"`
+	Duration    any         `json:"duration"`
+	Deadline    any         `json:"deadline"`
+}
+
+type todoistDue struct {
+	Date        string `json:"date"`
+	Datetime    string `json:"datetime"`
+	IsRecurring bool   `json:"is_recurring"`
+}
+
+type todoistSection struct {
+	Id   todoistId `json:"id"`
+	Name string    `json:"name"`
+}
+
+type todoistNote struct {
+	ItemId         todoistId `json:"item_id"`
+	TaskId         todoistId `json:"task_id"`
+	Content        string    `json:"content"`
+	FileAttachment any       `json:"file_attachment"`
+	Attachment     any       `json:"attachment"`
+}
+
+func (t *Todoist) readJson(r io.Reader) (*Document, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var export todoistExport
+	if bytes.HasPrefix(data, []byte("[")) {
+		err = json2.Unmarshal(data, &export.Tasks)
+	} else if err = json2.Unmarshal(data, &export); err == nil && export.Items == nil && export.Tasks == nil {
+		return nil, &FormatError{Msg: "not a Todoist export: it has no items or tasks"}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sections := make(map[todoistId]string, len(export.Sections))
+	for _, section := range export.Sections {
+		sections[section.Id] = section.Name
+	}
+
+	doc := newDocument()
+	// The tasks of all projects are imported together.
+	if len(export.Projects) > 1 {
+		doc.unmapped("projects", len(export.Projects))
+	}
+	tasks := append(export.Items, export.Tasks...)
+	notes := append(export.Notes, export.Comments...)
+	return t.readTasks(doc, tasks, sections, notes), nil
+}
+
+func (t *Todoist) readTasks(doc *Document, tasks []todoistTask, sections map[todoistId]string, notes []todoistNote) *Document {
+	ids := make(map[todoistId]bool, len(tasks))
+	for _, task := range tasks {
+		ids[task.Id] = true
+	}
+	parents := make(map[todoistId]todoistId, len(tasks))
+	for _, task := range tasks {
+		if task.ParentId != "" && ids[task.ParentId] {
+			parents[task.Id] = task.ParentId
+		}
+	}
+	// root finds the top-level task a subtask belongs to; the bound guards
+	// against cycles.
+	root := func(id todoistId) todoistId {
+		for range len(parents) {
+			parent, ok := parents[id]
+			if !ok {
+				break
+			}
+			id = parent
+		}
+		return id
+	}
+
+	checklists := make(map[todoistId][]checkItem)
+	for _, task := range tasks {
+		if _, ok := parents[task.Id]; ok {
+			parent := root(task.Id)
+			checklists[parent] = append(checklists[parent], checkItem{name: task.Content, complete: task.IsCompleted || task.Checked})
+			doc.unmapped("subtaskDetails", len(task.Labels))
+			if task.Due != nil {
+				doc.unmapped("subtaskDetails", 1)
+			}
+		}
+	}
+
+	comments := make(map[todoistId][]string)
+	for _, note := range notes {
+		id := note.ItemId
+		if id == "" {
+			id = note.TaskId
+		}
+		if !ids[id] {
+			doc.unmapped("projectNotes", 1)
+			continue
+		}
+		id = root(id)
+		if note.Content != "" {
+			comments[id] = append(comments[id], note.Content)
+		}
+		if note.FileAttachment != nil || note.Attachment != nil {
+			doc.unmapped("attachments", 1)
+		}
+	}
+
+	for _, tt := range tasks {
+		if _, ok := parents[tt.Id]; ok {
+			continue
+		}
+
+		task := model.Task{
+			Title:    tt.Content,
+			Status:   t.options.status(sections[tt.SectionId]),
+			Priority: todoistPriority(strconv.Itoa(tt.Priority), true),
+			Tags:     tt.Labels,
+			Content:  joinContent(tt.Description, formatChecklist("", checklists[tt.Id]), strings.Join(comments[tt.Id], "\n\n")),
+		}
+		if tt.Id != "" {
+			task.ExternalId = "todoist:" + string(tt.Id)
+		}
+		if tt.IsCompleted || tt.Checked {
+			task.Status = model.StatusDone
+		}
+
+		if tt.Due != nil {
+			date := tt.Due.Datetime
+			if date == "" {
+				date = tt.Due.Date
+			}
+			if due, ok := todoistDate(date); ok {
+				task.DueDate = &due
+			} else {
+				doc.unmapped("dueDates", 1)
+			}
+			if tt.Due.IsRecurring {
+				doc.unmapped("recurrence", 1)
+			}
+		}
+		if tt.AssigneeId != "" || tt.Responsible != "" {
+			doc.unmapped("assignees", 1)
+		}
+		if tt.Duration != nil {
+			doc.unmapped("durations", 1)
+		}
+		if tt.Deadline != nil {
+			doc.unmapped("deadlines", 1)
+		}
+
+		ref := "task " + string(tt.Id)
+		if tt.Id == "" {
+			ref = fmt.Sprintf("task %d", len(doc.Items)+1)
+		}
+		doc.Items = append(doc.Items, Item{Ref: ref, Task: task})
+	}
+
+	return doc
+}
+
+// todoistLabels takes the "@label" words out of a task's content.
+func todoistLabels(content string) (string, []string) {
+	var labels []string
+	words := make([]string, 0)
+	for _, word := range strings.Fields(content) {
+		if len(word) > 1 && word[0] == '@' {
+			labels = append(labels, word[1:])
+		} else {
+			words = append(words, word)
+		}
+	}
+	return strings.Join(words, " "), labels
+}
+
+// todoistPriority maps a priority to a task priority. In CSV exports 1 is the
+// highest priority (p1) and 4 the lowest, while the API numbers them the
+// other way round.
+func todoistPriority(value string, api bool) string {
+	priority, err := strconv.Atoi(value)
+	if err != nil || priority < 1 || priority > 4 {
+		return model.PriorityLow
+	}
+	if api {
+		priority = 5 - priority
+	}
+
+	switch priority {
+	case 1:
+		return model.PriorityHigh
+	case 2, 3:
+		return model.PriorityNormal
+	}
+	return model.PriorityLow
+}
+
+// todoistDate parses an absolute date. Natural language dates such as
+// "every monday" can't be resolved.
+func todoistDate(value string) (time.Time, bool) {
+	if t, err := model.ParseDate(value); err == nil {
+		return t, true
+	}
+	for _, layout := range []string{"2006-01-02T15:04:05", "2006-01-02 15:04", "2006-01-02T15:04:05.000000Z"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
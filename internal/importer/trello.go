@@ -0,0 +1,153 @@
+package importer
+
+import (
+	json2 "encoding/json"
+	"io"
+	"simple-tasks/internal/model"
+	"sort"
+	"time"
+)
+
+// Trello reads the JSON export of a board. Cards become tasks with the status
+// of their list; archived cards and cards of archived lists are left out.
+type Trello struct {
+	options Options
+}
+
+type trelloBoard struct {
+	Lists      []trelloList      `json:"lists"`
+	Cards      []trelloCard      `json:"cards"`
+	Checklists []trelloChecklist `json:"checklists"`
+	Actions    []trelloAction    `json:"actions"`
+}
+
+type trelloList struct {
+	Id     string `json:"id"`
+	Name   string `json:"name"`
+	Closed bool   `json:"closed"`
+}
+
+type trelloLabel struct {
+	Name  string `json:"name"`
+	Color string `json:"color"`
+}
+
+type trelloCard struct {
+	Id               string             `json:"id"`
+	Name             string             `json:"name"`
+	Desc             string             `json:"desc"`
+	IdList           string             `json:"idList"`
+	Closed           bool               `json:"closed"`
+	Due              *time.Time         `json:"due"`
+	DueComplete      bool               `json:"dueComplete"`
+	Start            *time.Time         `json:"start"`
+	Labels           []trelloLabel      `json:"labels"`
+	IdMembers        []string           `json:"idMembers"`
+	Attachments      []json2.RawMessage `json:"attachments"`
+	CustomFieldItems []json2.RawMessage `json:"customFieldItems"`
+}
+
+type trelloChecklist struct {
+	IdCard     string            `json:"idCard"`
+	Name       string            `json:"name"`
+	Pos        float64           `json:"pos"`
+	CheckItems []trelloCheckItem `json:"checkItems"`
+}
+
+type trelloCheckItem struct {
+	Name  string  `json:"name"`
+	State string  `json:"state"`
+	Pos   float64 `json:"pos"`
+}
+
+type trelloAction struct {
+	Type string `json:"type"`
+	Data struct {
+		Card struct {
+			Id string `json:"id"`
+		} `json:"card"`
+	} `json:"data"`
+}
+
+func (t *Trello) Read(r io.Reader) (*Document, error) {
+	var board trelloBoard
+	if err := json2.NewDecoder(r).Decode(&board); err != nil {
+		return nil, err
+	}
+	if board.Lists == nil && board.Cards == nil {
+		return nil, &FormatError{Msg: "not a Trello board export: it has no lists or cards"}
+	}
+
+	lists := make(map[string]trelloList, len(board.Lists))
+	for _, list := range board.Lists {
+		lists[list.Id] = list
+	}
+
+	checklists := make(map[string][]trelloChecklist)
+	for _, checklist := range board.Checklists {
+		checklists[checklist.IdCard] = append(checklists[checklist.IdCard], checklist)
+	}
+
+	comments := make(map[string]int)
+	for _, action := range board.Actions {
+		if action.Type == "commentCard" {
+			comments[action.Data.Card.Id]++
+		}
+	}
+
+	doc := newDocument()
+	for _, card := range board.Cards {
+		list := lists[card.IdList]
+		if card.Closed || list.Closed {
+			doc.unmapped("archivedCards", 1)
+			continue
+		}
+
+		task := model.Task{
+			ExternalId: "trello:" + card.Id,
+			Title:      card.Name,
+			Status:     t.options.status(list.Name),
+			DueDate:    card.Due,
+		}
+		if card.DueComplete {
+			task.Status = model.StatusDone
+		}
+
+		for _, label := range card.Labels {
+			if label.Name != "" {
+				task.Tags = append(task.Tags, label.Name)
+			} else if label.Color != "" {
+				task.Tags = append(task.Tags, label.Color)
+			}
+		}
+
+		cardChecklists := checklists[card.Id]
+		sort.SliceStable(cardChecklists, func(i, j int) bool {
+			return cardChecklists[i].Pos < cardChecklists[j].Pos
+		})
+		parts := []string{card.Desc}
+		for _, checklist := range cardChecklists {
+			items := checklist.CheckItems
+			sort.SliceStable(items, func(i, j int) bool { return items[i].Pos < items[j].Pos })
+
+			checkItems := make([]checkItem, 0, len(items))
+			for _, item := range items {
+				checkItems = append(checkItems, checkItem{name: item.Name, complete: item.State == "complete"})
+			}
+			parts = append(parts, formatChecklist(checklist.Name, checkItems))
+		}
+		task.Content = joinContent(parts...)
+
+		if card.Start != nil {
+			doc.unmapped("startDates", 1)
+		}
+		doc.unmapped("members", len(card.IdMembers))
+		doc.unmapped("attachments", len(card.Attachments))
+		doc.unmapped("comments", comments[card.Id])
+		doc.unmapped("customFields", len(card.CustomFieldItems))
+
+		doc.Items = append(doc.Items, Item{Ref: card.Name, Task: task})
+	}
+
+	return doc, nil
+}
@@ -5,11 +5,15 @@ import (
 	"github.com/google/uuid"
 	"log/slog"
 	"net/http"
+	"strings"
 	"time"
 )
 
 const RequestId = "requestId"
 
+// LenientHandling is the context key of the compatibility mode of a request.
+const LenientHandling = "lenientHandling"
+
 func RequestIdMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx := WithLogRequestId(r.Context(), uuid.New().String())
@@ -31,6 +35,12 @@ func (lrw *loggingResponseWriter) WriteHeader(code int) {
 	lrw.ResponseWriter.WriteHeader(code)
 }
 
+// Unwrap lets http.ResponseController reach the underlying writer, e.g. to
+// flush streamed responses.
+func (lrw *loggingResponseWriter) Unwrap() http.ResponseWriter {
+	return lrw.ResponseWriter
+}
+
 func LogMiddleware(log *slog.Logger, h http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		startTime := time.Now()
@@ -75,3 +85,26 @@ func (h *HandlerMiddleware) WithGroup(name string) slog.Handler {
 func WithLogRequestId(ctx context.Context, requestId string) context.Context {
 	return context.WithValue(ctx, RequestId, requestId)
 }
+
+// HandlingMiddleware puts the compatibility mode of requests in their context:
+// lenient when the server is configured to be, strict otherwise, or as asked
+// for with the RFC 7240 Prefer header values handling=lenient and
+// handling=strict.
+func HandlingMiddleware(lenient bool, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handling := lenient
+		for _, value := range r.Header.Values("Prefer") {
+			for _, preference := range strings.Split(value, ",") {
+				switch strings.ToLower(strings.TrimSpace(preference)) {
+				case "handling=lenient":
+					handling = true
+					w.Header().Set("Preference-Applied", "handling=lenient")
+				case "handling=strict":
+					handling = false
+					w.Header().Set("Preference-Applied", "handling=strict")
+				}
+			}
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), LenientHandling, handling)))
+	})
+}
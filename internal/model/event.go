@@ -0,0 +1,29 @@
+package model
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type EventType = string
+
+const (
+	EventTaskCreated   = "task.created"
+	EventTaskUpdated   = "task.updated"
+	EventTaskCompleted = "task.completed"
+	EventTaskDeleted   = "task.deleted"
+)
+
+var EventTypes = []EventType{
+	EventTaskCreated,
+	EventTaskUpdated,
+	EventTaskCompleted,
+	EventTaskDeleted,
+}
+
+type TaskEvent struct {
+	Id         uuid.UUID `json:"id"`
+	Type       EventType `json:"type"`
+	OccurredAt time.Time `json:"occurredAt"`
+	Task       Task      `json:"task"`
+}
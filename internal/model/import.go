@@ -0,0 +1,19 @@
+package model
+
+type ImportStatus = string
+
+const (
+	ImportCreated = "created"
+	ImportUpdated = "updated"
+	ImportSkipped = "skipped"
+	ImportFailed  = "failed"
+)
+
+const (
+	ImportFormatCsv         = "csv"
+	ImportFormatJson        = "json"
+	ImportFormatNdjson      = "ndjson"
+	ImportFormatIcs         = "ics"
+	ImportFormatTodotxt     = "todotxt"
+	ImportFormatTaskwarrior = "taskwarrior"
+)
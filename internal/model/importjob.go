@@ -0,0 +1,48 @@
+package model
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type ImportJobStatus = string
+
+const (
+	ImportJobQueued    = "queued"
+	ImportJobRunning   = "running"
+	ImportJobCompleted = "completed"
+	ImportJobFailed    = "failed"
+)
+
+// ImportJob is an import of another tool's export that runs in the
+// background. Counters are updated while it runs, so polling the job reports
+// its progress.
+type ImportJob struct {
+	Id     uuid.UUID       `json:"id"`
+	Source string          `json:"source"`
+	Status ImportJobStatus `json:"status"`
+	// Total is the number of tasks read from the export and Processed the
+	// number handled so far.
+	Total     int `json:"total"`
+	Processed int `json:"processed"`
+	Created   int `json:"created"`
+	Updated   int `json:"updated"`
+	Skipped   int `json:"skipped"`
+	Failed    int `json:"failed"`
+	// Errors describes every task that failed.
+	Errors []ImportJobError `json:"errors"`
+	// Unmapped counts the data of the export that has no task field, by
+	// kind, such as "attachments" or "comments".
+	Unmapped map[string]int `json:"unmapped"`
+	// Error is set when the job as a whole failed.
+	Error      string     `json:"error,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	StartedAt  *time.Time `json:"startedAt,omitempty"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+}
+
+type ImportJobError struct {
+	// Item locates the task in the export, such as a card name or a line.
+	Item    string `json:"item"`
+	Message string `json:"message"`
+}
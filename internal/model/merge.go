@@ -0,0 +1,198 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"time"
+)
+
+const (
+	FieldTitle    = "title"
+	FieldContent  = "content"
+	FieldStatus   = "status"
+	FieldPriority = "priority"
+	FieldTags     = "tags"
+	FieldDueDate  = "dueDate"
+)
+
+type ChangeOp = string
+
+const (
+	OpSet    = "set"
+	OpAdd    = "add"
+	OpRemove = "remove"
+)
+
+type VectorClock map[string]uint64
+
+// Compare returns -1 if c happened before other, 1 if it happened after and
+// 0 if the clocks are equal or concurrent.
+func (c VectorClock) Compare(other VectorClock) int {
+	before, after := false, false
+	for node, counter := range c {
+		if counter > other[node] {
+			after = true
+		} else if counter < other[node] {
+			before = true
+		}
+	}
+	for node, counter := range other {
+		if _, ok := c[node]; !ok && counter > 0 {
+			before = true
+		}
+	}
+
+	switch {
+	case after && !before:
+		return 1
+	case before && !after:
+		return -1
+	default:
+		return 0
+	}
+}
+
+type FieldVersion struct {
+	Timestamp time.Time   `json:"timestamp"`
+	ClientId  string      `json:"clientId,omitempty"`
+	Clock     VectorClock `json:"clock,omitempty"`
+	// Removed marks a tag removal; it is only used for tag element versions.
+	Removed bool `json:"removed,omitempty"`
+}
+
+// Supersedes reports whether v wins over current under last-writer-wins.
+// Vector clocks decide when both versions carry one and they are ordered;
+// otherwise the later timestamp wins, with the client id as a tie-breaker.
+// concurrent is set when the clocks show that neither side saw the other.
+func (v FieldVersion) Supersedes(current FieldVersion) (wins bool, concurrent bool) {
+	if len(v.Clock) > 0 && len(current.Clock) > 0 {
+		switch v.Clock.Compare(current.Clock) {
+		case 1:
+			return true, false
+		case -1:
+			return false, false
+		}
+		concurrent = true
+	}
+
+	if !v.Timestamp.Equal(current.Timestamp) {
+		return v.Timestamp.After(current.Timestamp), concurrent
+	}
+	return v.ClientId > current.ClientId, concurrent
+}
+
+// FieldVersions holds the version of the last write of every task field.
+// Tag elements are versioned individually under TagVersionKey.
+type FieldVersions map[string]FieldVersion
+
+func TagVersionKey(tag string) string {
+	return FieldTags + ":" + tag
+}
+
+// Get returns the version of the field, defaulting to a version at
+// fallback for fields that have never been written through a tracked update.
+func (v FieldVersions) Get(field string, fallback time.Time) FieldVersion {
+	if version, ok := v[field]; ok {
+		return version
+	}
+	return FieldVersion{Timestamp: fallback}
+}
+
+func (v FieldVersions) Clone() FieldVersions {
+	if v == nil {
+		return make(FieldVersions)
+	}
+	return maps.Clone(v)
+}
+
+type FieldChange struct {
+	Field     string          `json:"field" validate:"required,oneof=title content status priority tags dueDate"`
+	Op        ChangeOp        `json:"op,omitempty" validate:"omitempty,oneof=set add remove"`
+	Value     json.RawMessage `json:"value"`
+	Timestamp time.Time       `json:"timestamp" validate:"required"`
+	Clock     VectorClock     `json:"clock,omitempty"`
+}
+
+// FieldChangeValue is a decoded FieldChange value; it carries the same
+// validation rules as Task.
+type FieldChangeValue struct {
+	Title    *string    `validate:"omitempty,gte=1,lte=200"`
+	Content  *string    `validate:"omitempty,lte=5000"`
+	Status   *Status    `validate:"omitempty,oneof=todo in_progress done"`
+	Priority *Priority  `validate:"omitempty,oneof=low normal high"`
+	Tags     []string   `validate:"omitempty,lte=10,dive,gte=1,lte=32"`
+	DueDate  *time.Time `validate:"-"`
+}
+
+func (c *FieldChange) DecodeValue() (*FieldChangeValue, error) {
+	value := &FieldChangeValue{}
+
+	if c.Op != "" && c.Op != OpSet && c.Field != FieldTags {
+		return nil, fmt.Errorf("operation %q is only supported for %s", c.Op, FieldTags)
+	}
+
+	var err error
+	switch c.Field {
+	case FieldTitle:
+		err = decodeRequired(c.Value, &value.Title)
+	case FieldContent:
+		err = decodeRequired(c.Value, &value.Content)
+	case FieldStatus:
+		err = decodeRequired(c.Value, &value.Status)
+	case FieldPriority:
+		err = decodeRequired(c.Value, &value.Priority)
+	case FieldDueDate:
+		err = json.Unmarshal(c.Value, &value.DueDate)
+	case FieldTags:
+		var tag string
+		if c.Op != OpSet && c.Op != "" && json.Unmarshal(c.Value, &tag) == nil {
+			value.Tags = []string{tag}
+		} else {
+			err = json.Unmarshal(c.Value, &value.Tags)
+		}
+	default:
+		err = fmt.Errorf("unknown field %q", c.Field)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for %s: %w", c.Field, err)
+	}
+
+	return value, nil
+}
+
+func decodeRequired[T any](raw json.RawMessage, dst **T) error {
+	if err := json.Unmarshal(raw, dst); err != nil {
+		return err
+	}
+	if *dst == nil {
+		return fmt.Errorf("value is required")
+	}
+	return nil
+}
+
+type MergeRequest struct {
+	ClientId string        `json:"clientId" validate:"required,lte=64"`
+	Changes  []FieldChange `json:"changes" validate:"required,gte=1,lte=100,dive"`
+}
+
+type MergeResolution = string
+
+const (
+	ResolutionClient = "client"
+	ResolutionServer = "server"
+)
+
+type MergeConflict struct {
+	Field       string          `json:"field"`
+	Tag         string          `json:"tag,omitempty"`
+	ServerValue any             `json:"serverValue"`
+	ClientValue any             `json:"clientValue"`
+	Resolution  MergeResolution `json:"resolution"`
+	Concurrent  bool            `json:"concurrent"`
+}
+
+type MergeResponse struct {
+	Task      Task            `json:"task"`
+	Conflicts []MergeConflict `json:"conflicts"`
+}
@@ -0,0 +1,96 @@
+package model
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+const (
+	SortFieldPriority  = "priority"
+	SortFieldDueDate   = "dueDate"
+	SortFieldCreatedAt = "createdAt"
+	SortFieldUpdatedAt = "updatedAt"
+	SortFieldTitle     = "title"
+	SortFieldStatus    = "status"
+
+	SortAsc = "asc"
+)
+
+var SortFields = []string{
+	SortFieldPriority,
+	SortFieldDueDate,
+	SortFieldCreatedAt,
+	SortFieldUpdatedAt,
+	SortFieldTitle,
+	SortFieldStatus,
+}
+
+type SortKey struct {
+	Field string
+	Desc  bool
+}
+
+// ParseSort parses a sort specification such as
+// "priority:desc,dueDate:asc,createdAt:desc". A bare "asc" or "desc" item
+// sets the direction of the preceding key, or of createdAt when it comes
+// first, which keeps the older "priority,desc" and "desc" forms working.
+func ParseSort(spec string) ([]SortKey, error) {
+	if spec == "" {
+		return nil, nil
+	}
+
+	keys := make([]SortKey, 0)
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		field, direction, hasDirection := strings.Cut(item, ":")
+
+		if !hasDirection && (field == SortAsc || field == SortDesc) {
+			if len(keys) == 0 {
+				keys = append(keys, SortKey{Field: SortFieldCreatedAt})
+			}
+			keys[len(keys)-1].Desc = field == SortDesc
+			continue
+		}
+
+		if !slices.Contains(SortFields, field) {
+			return nil, fmt.Errorf("unknown sort field %q", field)
+		}
+		if hasDirection && direction != SortAsc && direction != SortDesc {
+			return nil, fmt.Errorf("unknown sort direction %q", direction)
+		}
+		if slices.ContainsFunc(keys, func(key SortKey) bool { return key.Field == field }) {
+			return nil, fmt.Errorf("duplicate sort field %q", field)
+		}
+
+		keys = append(keys, SortKey{Field: field, Desc: direction == SortDesc})
+	}
+
+	return keys, nil
+}
+
+// PriorityRank orders priorities from low to high.
+func PriorityRank(priority Priority) int {
+	switch priority {
+	case PriorityLow:
+		return 1
+	case PriorityNormal:
+		return 2
+	case PriorityHigh:
+		return 3
+	}
+	return 0
+}
+
+// StatusRank orders statuses along the task workflow.
+func StatusRank(status Status) int {
+	switch status {
+	case StatusTodo:
+		return 1
+	case StatusInProgress:
+		return 2
+	case StatusDone:
+		return 3
+	}
+	return 0
+}
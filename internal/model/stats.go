@@ -0,0 +1,65 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	GroupByStatus   = "status"
+	GroupByPriority = "priority"
+	GroupByTag      = "tag"
+	GroupByDueWeek  = "dueWeek"
+)
+
+// NoDueWeek is the dueWeek group of tasks without a due date.
+const NoDueWeek = "none"
+
+// MaxStatsDays limits the range of the daily created/completed series.
+const MaxStatsDays = 366
+
+// StatsRequest configures GET /tasks/stats. From and To are inclusive
+// YYYY-MM-DD days in UTC; the last seven days are used when they are empty.
+type StatsRequest struct {
+	GroupBy []string `validate:"dive,oneof=status priority tag dueWeek"`
+	From    string   `validate:"omitempty,datetime=2006-01-02"`
+	To      string   `validate:"omitempty,datetime=2006-01-02"`
+}
+
+// Range resolves the requested days. The returned end is exclusive.
+func (r *StatsRequest) Range(now time.Time) (time.Time, time.Time) {
+	now = now.UTC()
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if r.To != "" {
+		to, _ = time.Parse(time.DateOnly, r.To)
+	}
+	from := to.AddDate(0, 0, -6)
+	if r.From != "" {
+		from, _ = time.Parse(time.DateOnly, r.From)
+	}
+	return from, to.AddDate(0, 0, 1)
+}
+
+type DailyStats struct {
+	Date      string `json:"date"`
+	Created   int    `json:"created"`
+	Completed int    `json:"completed"`
+}
+
+type TaskStats struct {
+	Total   int `json:"total"`
+	Overdue int `json:"overdue"`
+	// Groups maps every requested groupBy dimension to the task count of
+	// each of its values.
+	Groups map[string]map[string]int `json:"groups,omitempty"`
+	Daily  []DailyStats              `json:"daily"`
+}
+
+// DueWeek returns the ISO week of the due date, such as "2025-W40".
+func DueWeek(dueDate *time.Time) string {
+	if dueDate == nil {
+		return NoDueWeek
+	}
+	year, week := dueDate.UTC().ISOWeek()
+	return fmt.Sprintf("%d-W%02d", year, week)
+}
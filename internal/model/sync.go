@@ -0,0 +1,37 @@
+package model
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type Tombstone struct {
+	Id        uuid.UUID `json:"id"`
+	DeletedAt time.Time `json:"deletedAt"`
+}
+
+// ChangeSet lists the tasks modified and deleted after a change sequence
+// number, in sequence order.
+type ChangeSet struct {
+	Tasks      []Task
+	Tombstones []Tombstone
+	// Seq is the sequence number the change set is complete up to.
+	Seq     uint64
+	HasMore bool
+	// Expired is set when the requested sequence number is older than the
+	// retained tombstones, so deletions may be missing.
+	Expired bool
+}
+
+type SyncRequest struct {
+	Since string
+	Limit *int `validate:"omitempty,gte=1,lte=1000"`
+}
+
+type SyncResponse struct {
+	Tasks      []Task      `json:"tasks"`
+	Deleted    []Tombstone `json:"deleted"`
+	NextToken  string      `json:"nextToken"`
+	HasMore    bool        `json:"hasMore"`
+	FullResync bool        `json:"fullResync"`
+}
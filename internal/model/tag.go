@@ -0,0 +1,55 @@
+package model
+
+import (
+	"slices"
+	"strings"
+)
+
+// Tag is an entry of the tag catalog: the tag name with its optional
+// metadata and the number of tasks using it.
+type Tag struct {
+	Name        string `json:"name"`
+	Color       string `json:"color,omitempty"`
+	Description string `json:"description,omitempty"`
+	Count       int    `json:"count"`
+}
+
+type UpdateTagRequest struct {
+	Color       *string `json:"color,omitempty" validate:"omitempty,hexcolor"`
+	Description *string `json:"description,omitempty" validate:"omitempty,lte=200"`
+}
+
+type RenameTagRequest struct {
+	Name string `json:"name" validate:"required,gte=1,lte=32"`
+}
+
+type MergeTagRequest struct {
+	Into string `json:"into" validate:"required,gte=1,lte=32"`
+}
+
+type TagChangeResponse struct {
+	Tag          Tag `json:"tag"`
+	UpdatedTasks int `json:"updatedTasks"`
+}
+
+// NormalizeTag trims and lowercases a tag name.
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+// NormalizeTags normalizes every tag, dropping empty tags and duplicates
+// while keeping the original order.
+func NormalizeTags(tags []string) []string {
+	if tags == nil {
+		return nil
+	}
+
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = NormalizeTag(tag)
+		if tag != "" && !slices.Contains(normalized, tag) {
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized
+}
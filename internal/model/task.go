@@ -2,6 +2,10 @@ package model
 
 import (
 	"github.com/google/uuid"
+	"simple-tasks/internal/filter"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -9,7 +13,7 @@ type Status = string
 
 const (
 	StatusTodo       = "todo"
-	StatusInProgress = "in_proress"
+	StatusInProgress = "in_progress"
 	StatusDone       = "done"
 )
 
@@ -31,6 +35,13 @@ type Task struct {
 	DueDate   *time.Time `json:"dueDate,omitempty"`
 	CreatedAt time.Time  `json:"createdAt"`
 	UpdatedAt time.Time  `json:"updatedAt"`
+	// CompletedAt is set by the server when the task moves to done.
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+	// ExternalId identifies the task in the system it was imported from.
+	ExternalId string `json:"externalId,omitempty" validate:"lte=100"`
+
+	// Versions tracks per-field writes for offline merges.
+	Versions FieldVersions `json:"-"`
 }
 
 func (t *Task) SetDefaults() {
@@ -42,6 +53,26 @@ func (t *Task) SetDefaults() {
 	}
 }
 
+// ExportId identifies the task in exported files: its external id when it
+// was imported, so the source system recognizes it, and its own id
+// otherwise. Importing the file again matches it back to the task.
+func (t *Task) ExportId() string {
+	if t.ExternalId != "" {
+		return t.ExternalId
+	}
+	return t.Id.String()
+}
+
+// UpdateCompletedAt records when the task was completed: it is set when the
+// task becomes done and cleared when it is reopened.
+func (t *Task) UpdateCompletedAt(now time.Time) {
+	if t.Status != StatusDone {
+		t.CompletedAt = nil
+	} else if t.CompletedAt == nil {
+		t.CompletedAt = &now
+	}
+}
+
 type Sort = string
 
 const (
@@ -49,13 +80,152 @@ const (
 	SortDesc     = "desc"
 )
 
+type TagMode = string
+
+const (
+	TagModeAny  = "any"
+	TagModeAll  = "all"
+	TagModeNone = "none"
+)
+
+// GetTasksRequest holds the task listing parameters. Dates are kept as given
+// in the query, either RFC 3339 or YYYY-MM-DD, and parsed with ParseDate;
+// "before" bounds are exclusive and "after" bounds inclusive.
 type GetTasksRequest struct {
-	Status   string
-	Tags     []string
-	Q        string
-	Sort     Sort `validate:"omitempty,oneof=priority desc"`
-	Page     *int `validate:"omitempty,gte=0"`
-	PageSize *int `validate:"omitempty,gte=1,lte=100"`
+	Status        []string `validate:"dive,oneof=todo in_progress done"`
+	Priority      []string `validate:"dive,oneof=low normal high"`
+	Tags          []string
+	TagMode       TagMode `validate:"omitempty,oneof=any all none"`
+	Q             string
+	DueBefore     string `validate:"omitempty,date"`
+	DueAfter      string `validate:"omitempty,date"`
+	CreatedBefore string `validate:"omitempty,date"`
+	CreatedAfter  string `validate:"omitempty,date"`
+	UpdatedSince  string `validate:"omitempty,date"`
+	Overdue       string `validate:"omitempty,boolean"`
+	HasDueDate    string `validate:"omitempty,boolean"`
+	Sort          Sort   `validate:"omitempty,sort"`
+	Page          *int   `validate:"omitempty,gte=1"`
+	PageSize      *int   `validate:"omitempty,gte=1,lte=100"`
+	Cursor        string
+	// Filter is the parsed filter query parameter, nil when not given.
+	Filter filter.Expr
+}
+
+// ParseDate parses an RFC 3339 timestamp or a YYYY-MM-DD date, which stands
+// for the start of that day in UTC.
+func ParseDate(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, value)
+}
+
+// Matches reports whether the task passes the filters of the request except
+// the filter expression. Pagination and sorting are not taken into account.
+func (r *GetTasksRequest) Matches(task *Task) bool {
+	return r.Matcher(time.Now())(task)
+}
+
+// Matcher returns Matches for matching many tasks: the dates of the request,
+// which have been validated, are parsed once, and overdue tasks are judged by
+// now.
+func (r *GetTasksRequest) Matcher(now time.Time) func(task *Task) bool {
+	dueBefore, dueAfter := parseBound(r.DueBefore), parseBound(r.DueAfter)
+	createdBefore, createdAfter := parseBound(r.CreatedBefore), parseBound(r.CreatedAfter)
+	updatedSince := parseBound(r.UpdatedSince)
+	hasDueDate, _ := strconv.ParseBool(r.HasDueDate)
+	overdue, _ := strconv.ParseBool(r.Overdue)
+
+	return func(task *Task) bool {
+		if len(r.Status) > 0 && !slices.Contains(r.Status, task.Status) {
+			return false
+		}
+
+		if len(r.Priority) > 0 && !slices.Contains(r.Priority, task.Priority) {
+			return false
+		}
+
+		if len(r.Tags) > 0 {
+			hasTag := func(requestTag string) bool {
+				return slices.Contains(task.Tags, requestTag)
+			}
+
+			switch r.TagMode {
+			case TagModeAll:
+				if slices.ContainsFunc(r.Tags, func(requestTag string) bool { return !hasTag(requestTag) }) {
+					return false
+				}
+			case TagModeNone:
+				if slices.ContainsFunc(r.Tags, hasTag) {
+					return false
+				}
+			default:
+				if !slices.ContainsFunc(r.Tags, hasTag) {
+					return false
+				}
+			}
+		}
+
+		if r.Q != "" {
+			containsQ := strings.Contains(task.Title, r.Q) ||
+				strings.Contains(task.Content, r.Q)
+			if !containsQ {
+				return false
+			}
+		}
+
+		if r.DueBefore != "" || r.DueAfter != "" {
+			if task.DueDate == nil || !inRange(*task.DueDate, dueAfter, dueBefore) {
+				return false
+			}
+		}
+
+		if !inRange(task.CreatedAt, createdAfter, createdBefore) {
+			return false
+		}
+
+		if !inRange(task.UpdatedAt, updatedSince, nil) {
+			return false
+		}
+
+		if r.HasDueDate != "" && hasDueDate != (task.DueDate != nil) {
+			return false
+		}
+
+		if r.Overdue != "" && overdue != task.IsOverdue(now) {
+			return false
+		}
+
+		return true
+	}
+}
+
+// IsOverdue reports whether the task is past its due date and not done yet.
+func (t *Task) IsOverdue(now time.Time) bool {
+	return t.DueDate != nil && t.DueDate.Before(now) && t.Status != StatusDone
+}
+
+// parseBound parses a date filter; it is nil when the filter is not given.
+func parseBound(value string) *time.Time {
+	if value == "" {
+		return nil
+	}
+	bound, err := ParseDate(value)
+	if err != nil {
+		return nil
+	}
+	return &bound
+}
+
+func inRange(t time.Time, after *time.Time, before *time.Time) bool {
+	if after != nil && t.Before(*after) {
+		return false
+	}
+	if before != nil && !t.Before(*before) {
+		return false
+	}
+	return true
 }
 
 type GetTasksResponse struct {
@@ -64,6 +234,8 @@ type GetTasksResponse struct {
 	PageSize   *int   `json:"pageSize,omitempty"`
 	Total      int    `json:"total"`
 	TotalPages *int   `json:"totalPages,omitempty"`
+	NextCursor string `json:"nextCursor,omitempty"`
+	PrevCursor string `json:"prevCursor,omitempty"`
 }
 
 type UpdateTaskRequest struct {
@@ -0,0 +1,27 @@
+package model
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// View is a saved task query. Query is a GET /tasks query string, for
+// example "status=todo&tag=work&sort=dueDate".
+type View struct {
+	Id        uuid.UUID `json:"id"`
+	Name      string    `json:"name" validate:"required,gte=1,lte=100"`
+	Query     string    `json:"query" validate:"lte=2000"`
+	Owner     string    `json:"owner"`
+	Shared    bool      `json:"shared"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	// Count is the number of tasks the view matches right now.
+	Count *int `json:"count,omitempty"`
+}
+
+type UpdateViewRequest struct {
+	Name   string  `json:"name,omitempty" validate:"omitempty,gte=1,lte=100"`
+	Query  *string `json:"query,omitempty" validate:"omitempty,lte=2000"`
+	Shared *bool   `json:"shared,omitempty"`
+}
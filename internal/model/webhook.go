@@ -0,0 +1,47 @@
+package model
+
+import (
+	"github.com/google/uuid"
+	"slices"
+	"time"
+)
+
+type Webhook struct {
+	Id           uuid.UUID   `json:"id"`
+	Url          string      `json:"url" validate:"required,url,lte=2048"`
+	Description  string      `json:"description,omitempty" validate:"lte=500"`
+	Events       []EventType `json:"events" validate:"lte=10,dive,oneof=task.created task.updated task.completed task.deleted"`
+	Secret       string      `json:"secret,omitempty" validate:"omitempty,gte=16,lte=128"`
+	Active       bool        `json:"active"`
+	FailureCount int         `json:"failureCount"`
+	DisabledAt   *time.Time  `json:"disabledAt,omitempty"`
+	CreatedAt    time.Time   `json:"createdAt"`
+	UpdatedAt    time.Time   `json:"updatedAt"`
+}
+
+// Accepts reports whether the webhook is subscribed to the event type.
+// An empty event list subscribes to every event.
+func (w *Webhook) Accepts(eventType EventType) bool {
+	return len(w.Events) == 0 || slices.Contains(w.Events, eventType)
+}
+
+type UpdateWebhookRequest struct {
+	Url         string      `json:"url,omitempty" validate:"omitempty,url,lte=2048"`
+	Description *string     `json:"description,omitempty" validate:"omitempty,lte=500"`
+	Events      []EventType `json:"events,omitempty" validate:"omitempty,lte=10,dive,oneof=task.created task.updated task.completed task.deleted"`
+	Secret      string      `json:"secret,omitempty" validate:"omitempty,gte=16,lte=128"`
+	Active      *bool       `json:"active,omitempty"`
+}
+
+type WebhookDelivery struct {
+	Id          uuid.UUID `json:"id"`
+	WebhookId   uuid.UUID `json:"webhookId"`
+	EventId     uuid.UUID `json:"eventId"`
+	EventType   EventType `json:"eventType"`
+	Attempt     int       `json:"attempt"`
+	StatusCode  int       `json:"statusCode,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	Success     bool      `json:"success"`
+	DurationMs  int64     `json:"durationMs"`
+	AttemptedAt time.Time `json:"attemptedAt"`
+}
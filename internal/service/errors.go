@@ -0,0 +1,43 @@
+package service
+
+import "errors"
+
+// ErrorKind classifies the errors services return, so that handlers can
+// report them without knowing every error.
+type ErrorKind int
+
+const (
+	KindInternal ErrorKind = iota
+	KindNotFound
+	KindConflict
+	KindPreconditionFailed
+	KindForbidden
+	// KindInvalid is a malformed request, such as a cursor or sync token the
+	// service didn't issue.
+	KindInvalid
+	// KindValidation is a well-formed request the service refuses, such as a
+	// change that would leave a task with too many tags.
+	KindValidation
+	KindUnavailable
+)
+
+// Error is an error of a given kind. Its message is meant for clients.
+type Error struct {
+	Kind ErrorKind
+	Msg  string
+}
+
+func newError(kind ErrorKind, msg string) *Error {
+	return &Error{Kind: kind, Msg: msg}
+}
+
+func (e *Error) Error() string { return e.Msg }
+
+// KindOf returns the kind of a service error; any other error is internal.
+func KindOf(err error) ErrorKind {
+	var serviceErr *Error
+	if errors.As(err, &serviceErr) {
+		return serviceErr.Kind
+	}
+	return KindInternal
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"simple-tasks/internal/model"
+	"sync"
+)
+
+var BrokerClosedError = newError(KindUnavailable, "event broker closed")
+
+const subscriptionBufferSize = 64
+
+type StreamEvent struct {
+	Seq uint64
+	model.TaskEvent
+}
+
+type Subscription struct {
+	C <-chan StreamEvent
+
+	ch chan StreamEvent
+}
+
+// EventBroker fans task events out to stream subscribers and keeps the most
+// recent events in a bounded buffer so that reconnecting clients can resume.
+type EventBroker struct {
+	mu          sync.Mutex
+	seq         uint64
+	buffer      []StreamEvent
+	size        int
+	subscribers map[*Subscription]struct{}
+	closed      bool
+}
+
+func NewEventBroker(size int) *EventBroker {
+	return &EventBroker{
+		mu:          sync.Mutex{},
+		buffer:      make([]StreamEvent, 0, size),
+		size:        size,
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
+
+// Publish is a TaskService listener.
+func (b *EventBroker) Publish(ctx context.Context, event model.TaskEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+
+	b.seq++
+	streamEvent := StreamEvent{Seq: b.seq, TaskEvent: event}
+	if len(b.buffer) == b.size {
+		copy(b.buffer, b.buffer[1:])
+		b.buffer = b.buffer[:b.size-1]
+	}
+	b.buffer = append(b.buffer, streamEvent)
+
+	for sub := range b.subscribers {
+		select {
+		case sub.ch <- streamEvent:
+		default:
+			// The subscriber can't keep up; drop it so it reconnects and
+			// resumes from the buffer instead of blocking everyone else.
+			delete(b.subscribers, sub)
+			close(sub.ch)
+		}
+	}
+}
+
+// Subscribe registers a new subscriber. When lastSeq is not zero, the buffered
+// events published after it are returned for replay; complete is false when
+// some of those events have already been evicted from the buffer.
+func (b *EventBroker) Subscribe(lastSeq uint64) (sub *Subscription, replay []StreamEvent, complete bool, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil, nil, false, BrokerClosedError
+	}
+
+	complete = true
+	if lastSeq != 0 {
+		if lastSeq > b.seq {
+			complete = false
+		} else if len(b.buffer) > 0 && lastSeq+1 < b.buffer[0].Seq {
+			complete = false
+		}
+		for _, event := range b.buffer {
+			if event.Seq > lastSeq {
+				replay = append(replay, event)
+			}
+		}
+	}
+
+	ch := make(chan StreamEvent, subscriptionBufferSize)
+	sub = &Subscription{C: ch, ch: ch}
+	b.subscribers[sub] = struct{}{}
+
+	return sub, replay, complete, nil
+}
+
+func (b *EventBroker) Unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subscribers[sub]; ok {
+		delete(b.subscribers, sub)
+		close(sub.ch)
+	}
+}
+
+// Close disconnects all subscribers and rejects new ones.
+func (b *EventBroker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+
+	b.closed = true
+	for sub := range b.subscribers {
+		delete(b.subscribers, sub)
+		close(sub.ch)
+	}
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"simple-tasks/internal/model"
+	"simple-tasks/internal/store"
+	"slices"
+	"time"
+)
+
+// ImportTask stores an imported task. A task with the external id of an
+// existing task, or whose external id is the id of a task exported from
+// here, updates it or is skipped when nothing would change; anything else
+// is created, keeping the timestamps it had in the source system. With
+// dryRun nothing is stored, but the result reports what would have happened.
+func (s *TaskService) ImportTask(ctx context.Context, t *model.Task, dryRun bool) (*model.Task, model.ImportStatus, error) {
+	return s.importTask(ctx, t, dryRun, nil, nil)
+}
+
+// ImportTaskFields imports a task that carries only some fields, such as a
+// CSV row with a few of the columns, like ImportTask. Updates only set those
+// fields and keep the others of the existing task; nil fields are all of
+// them.
+func (s *TaskService) ImportTaskFields(ctx context.Context, t *model.Task, fields []string, dryRun bool) (*model.Task, model.ImportStatus, error) {
+	return s.importTask(ctx, t, dryRun, fields, nil)
+}
+
+// ImportTaskIf stores an imported task like ImportTask if precondition
+// accepts the task it would update, or nil when it would create one. The
+// check and the write happen under the lock of writes, so two writers can't
+// both pass it with the same version of the task.
+func (s *TaskService) ImportTaskIf(ctx context.Context, t *model.Task, precondition Precondition) (*model.Task, model.ImportStatus, error) {
+	return s.importTask(ctx, t, false, nil, precondition)
+}
+
+func (s *TaskService) importTask(ctx context.Context, t *model.Task, dryRun bool, fields []string, precondition Precondition) (*model.Task, model.ImportStatus, error) {
+	s.mergeMu.Lock()
+	defer s.mergeMu.Unlock()
+
+	t.Tags = model.NormalizeTags(t.Tags)
+	t.SetDefaults()
+
+	if t.ExternalId != "" {
+		existing, err := s.findImported(t.ExternalId)
+		if err == nil {
+			if precondition != nil && !precondition(&existing) {
+				return nil, model.ImportFailed, PreconditionFailedError
+			}
+			return s.importUpdate(ctx, &existing, t, fields, dryRun)
+		} else if !errors.Is(err, store.NotFoundError) {
+			return nil, model.ImportFailed, InternalError
+		}
+	}
+	if precondition != nil && !precondition(nil) {
+		return nil, model.ImportFailed, PreconditionFailedError
+	}
+
+	setImportedTimestamps(t, time.Now())
+	if dryRun {
+		return t, model.ImportCreated, nil
+	}
+
+	t.Versions = nil
+	return s.insertTask(ctx, t), model.ImportCreated, nil
+}
+
+// setImportedTimestamps keeps the creation, modification and completion
+// times of an imported task as long as they are consistent; missing ones
+// default to now.
+func setImportedTimestamps(t *model.Task, now time.Time) {
+	if t.CreatedAt.IsZero() || t.CreatedAt.After(now) {
+		t.CreatedAt = now
+	}
+	if t.UpdatedAt.Before(t.CreatedAt) || t.UpdatedAt.After(now) {
+		t.UpdatedAt = t.CreatedAt
+	}
+	if t.CompletedAt != nil && (t.CompletedAt.Before(t.CreatedAt) || t.CompletedAt.After(now)) {
+		t.CompletedAt = nil
+	}
+	t.UpdateCompletedAt(t.UpdatedAt)
+}
+
+// GetTaskByExternalId finds the task an import with the external id would
+// update.
+func (s *TaskService) GetTaskByExternalId(ctx context.Context, externalId string) (*model.Task, error) {
+	task, err := s.findImported(externalId)
+	if errors.Is(err, store.NotFoundError) {
+		return nil, NotFoundError
+	} else if err != nil {
+		return nil, InternalError
+	}
+
+	return &task, nil
+}
+
+// findImported looks a task up by its external id, falling back to the id of
+// a task without one, which is how such tasks are exported.
+func (s *TaskService) findImported(externalId string) (model.Task, error) {
+	task, err := s.repo.GetTaskByExternalId(externalId)
+	if !errors.Is(err, store.NotFoundError) {
+		return task, err
+	}
+
+	id, parseErr := uuid.Parse(externalId)
+	if parseErr != nil {
+		return task, err
+	}
+	task, err = s.repo.GetTaskById(id)
+	if err == nil && task.ExternalId != "" {
+		return model.Task{}, store.NotFoundError
+	}
+	return task, err
+}
+
+func (s *TaskService) importUpdate(ctx context.Context, task *model.Task, imported *model.Task, fields []string, dryRun bool) (*model.Task, model.ImportStatus, error) {
+	now := time.Now()
+	version := model.FieldVersion{Timestamp: now}
+	versions := task.Versions.Clone()
+	changed := false
+	wasDone := task.Status == model.StatusDone
+
+	carries := func(field string) bool {
+		return fields == nil || slices.Contains(fields, field)
+	}
+	set := func(field string, current *string, value string) {
+		if carries(field) && *current != value {
+			*current = value
+			versions[field] = version
+			changed = true
+		}
+	}
+	set(model.FieldTitle, &task.Title, imported.Title)
+	set(model.FieldContent, &task.Content, imported.Content)
+	set(model.FieldStatus, &task.Status, imported.Status)
+	set(model.FieldPriority, &task.Priority, imported.Priority)
+
+	if carries(model.FieldTags) && !slices.Equal(task.Tags, imported.Tags) {
+		setTagVersions(versions, task.Tags, imported.Tags, version)
+		task.Tags = imported.Tags
+		changed = true
+	}
+	if carries(model.FieldDueDate) && !equalTimes(task.DueDate, imported.DueDate) {
+		task.DueDate = imported.DueDate
+		versions[model.FieldDueDate] = version
+		changed = true
+	}
+
+	if !changed {
+		return task, model.ImportSkipped, nil
+	}
+	if dryRun {
+		return task, model.ImportUpdated, nil
+	}
+
+	task.Versions = versions
+	task.UpdatedAt = now
+	task.UpdateCompletedAt(now)
+
+	err := s.repo.UpdateTask(task)
+	if errors.Is(err, store.NotFoundError) {
+		return nil, model.ImportFailed, NotFoundError
+	} else if err != nil {
+		return nil, model.ImportFailed, InternalError
+	}
+
+	s.emit(ctx, model.EventTaskUpdated, task)
+	if !wasDone && task.Status == model.StatusDone {
+		s.emit(ctx, model.EventTaskCompleted, task)
+	}
+
+	return task, model.ImportUpdated, nil
+}
+
+func equalTimes(a *time.Time, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"log/slog"
+	"simple-tasks/internal/importer"
+	"simple-tasks/internal/model"
+	"simple-tasks/internal/store"
+	"sync"
+	"time"
+)
+
+var (
+	ImportJobNotFoundError  = newError(KindNotFound, "import job not found")
+	ImportJobQueueFullError = newError(KindUnavailable, "too many import jobs are queued")
+)
+
+const (
+	// importJobProgressEvery is the number of tasks after which the progress
+	// of a running job is stored.
+	importJobProgressEvery = 50
+	// maxQueuedImportJobs is the number of jobs that can wait for the
+	// running one; further jobs are rejected until the queue drains.
+	maxQueuedImportJobs = 10
+)
+
+// ImportJobService runs imports in the background, one job at a time in the
+// order they were started.
+type ImportJobService struct {
+	log   *slog.Logger
+	repo  store.ImportJobRepository
+	tasks *TaskService
+
+	// queue holds the jobs waiting for the worker.
+	queue    chan queuedImportJob
+	stopping chan struct{}
+	// done is closed once the worker has stopped.
+	done chan struct{}
+	// startMu serializes queueing jobs and closing, so that no job is queued
+	// after Close and a job is only stored once the queue has room for it.
+	startMu sync.Mutex
+}
+
+type queuedImportJob struct {
+	job   *model.ImportJob
+	items []importer.Item
+}
+
+func NewImportJobService(log *slog.Logger, repo store.ImportJobRepository, tasks *TaskService) *ImportJobService {
+	s := &ImportJobService{
+		log:      log,
+		repo:     repo,
+		tasks:    tasks,
+		queue:    make(chan queuedImportJob, maxQueuedImportJobs),
+		stopping: make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go s.work()
+
+	return s
+}
+
+// StartImportJob queues the import of the items. The job carries the totals
+// of the export, including items that were rejected before it started, which
+// are already counted as processed.
+func (s *ImportJobService) StartImportJob(ctx context.Context, job *model.ImportJob, items []importer.Item) (*model.ImportJob, error) {
+	s.startMu.Lock()
+	defer s.startMu.Unlock()
+
+	select {
+	case <-s.stopping:
+		s.log.ErrorContext(ctx, "import job rejected during shutdown")
+		return nil, InternalError
+	default:
+	}
+	// Only StartImportJob sends to the queue, so it has room until the send
+	// below.
+	if len(s.queue) == cap(s.queue) {
+		return nil, ImportJobQueueFullError
+	}
+
+	job.Id = uuid.New()
+	job.Status = model.ImportJobQueued
+	job.CreatedAt = time.Now()
+	job.StartedAt = nil
+	job.FinishedAt = nil
+	if job.Errors == nil {
+		job.Errors = make([]model.ImportJobError, 0)
+	}
+	if job.Unmapped == nil {
+		job.Unmapped = make(map[string]int)
+	}
+	s.repo.SaveImportJob(job)
+
+	queued := *job
+	queued.Errors = append(make([]model.ImportJobError, 0, len(job.Errors)), job.Errors...)
+	s.queue <- queuedImportJob{job: &queued, items: items}
+
+	return job, nil
+}
+
+func (s *ImportJobService) GetImportJobs(ctx context.Context) []model.ImportJob {
+	return s.repo.GetImportJobs()
+}
+
+func (s *ImportJobService) GetImportJobById(ctx context.Context, id uuid.UUID) (*model.ImportJob, error) {
+	job, err := s.repo.GetImportJobById(id)
+	if errors.Is(err, store.ImportJobNotFoundError) {
+		return nil, ImportJobNotFoundError
+	} else if err != nil {
+		return nil, InternalError
+	}
+
+	return &job, nil
+}
+
+// Close stops running and queued jobs, which are marked as failed, and waits
+// for them until ctx is done.
+func (s *ImportJobService) Close(ctx context.Context) {
+	s.startMu.Lock()
+	close(s.stopping)
+	s.startMu.Unlock()
+
+	select {
+	case <-s.done:
+	case <-ctx.Done():
+	}
+}
+
+// work runs the queued jobs one after another until Close, then fails the
+// jobs left in the queue.
+func (s *ImportJobService) work() {
+	defer close(s.done)
+
+	for {
+		select {
+		case queued := <-s.queue:
+			s.run(queued.job, queued.items)
+		case <-s.stopping:
+			for {
+				select {
+				case queued := <-s.queue:
+					queued.job.Error = "the server was shut down before the import started"
+					s.finish(queued.job, model.ImportJobFailed)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (s *ImportJobService) run(job *model.ImportJob, items []importer.Item) {
+	ctx := context.Background()
+	started := time.Now()
+	job.Status = model.ImportJobRunning
+	job.StartedAt = &started
+	s.update(job)
+
+	for i := range items {
+		select {
+		case <-s.stopping:
+			job.Error = "the server was shut down before the import completed"
+			s.finish(job, model.ImportJobFailed)
+			return
+		default:
+		}
+
+		item := &items[i]
+		_, status, err := s.tasks.ImportTask(ctx, &item.Task, false)
+		switch {
+		case err != nil:
+			job.Failed++
+			job.Errors = append(job.Errors, model.ImportJobError{Item: item.Ref, Message: err.Error()})
+		case status == model.ImportCreated:
+			job.Created++
+		case status == model.ImportUpdated:
+			job.Updated++
+		case status == model.ImportSkipped:
+			job.Skipped++
+		}
+
+		job.Processed++
+		if job.Processed%importJobProgressEvery == 0 {
+			s.update(job)
+		}
+	}
+
+	s.finish(job, model.ImportJobCompleted)
+}
+
+func (s *ImportJobService) finish(job *model.ImportJob, status model.ImportJobStatus) {
+	finished := time.Now()
+	job.Status = status
+	job.FinishedAt = &finished
+	s.update(job)
+
+	s.log.Info("import job finished",
+		slog.String("jobId", job.Id.String()),
+		slog.String("source", job.Source),
+		slog.String("status", job.Status),
+		slog.Int("created", job.Created),
+		slog.Int("updated", job.Updated),
+		slog.Int("skipped", job.Skipped),
+		slog.Int("failed", job.Failed))
+}
+
+func (s *ImportJobService) update(job *model.ImportJob) {
+	if err := s.repo.UpdateImportJob(job); err != nil {
+		// Unfinished jobs are never evicted, so the repository lost it; the
+		// job keeps running unobserved.
+		s.log.Warn("failed to store import job progress",
+			slog.String("jobId", job.Id.String()),
+			slog.String("error", err.Error()))
+	}
+}
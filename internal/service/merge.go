@@ -0,0 +1,251 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"simple-tasks/internal/model"
+	"simple-tasks/internal/store"
+	"slices"
+	"time"
+)
+
+var TooManyTagsError = newError(KindValidation, "task can have at most 10 tags")
+
+const maxTags = 10
+
+// MergeChanges applies a batch of field-level changes made by an offline
+// client. Every field, and every tag of the tag set, is merged independently
+// with last-writer-wins; changes that lose or that were made concurrently
+// with the server value are reported as conflicts.
+func (s *TaskService) MergeChanges(ctx context.Context, id uuid.UUID, request *model.MergeRequest) (*model.MergeResponse, error) {
+	s.mergeMu.Lock()
+	defer s.mergeMu.Unlock()
+
+	task, err := s.GetTaskById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := task.Versions.Clone()
+	conflicts := make([]model.MergeConflict, 0)
+	wasDone := task.Status == model.StatusDone
+	changed := false
+
+	for _, change := range request.Changes {
+		value, err := change.DecodeValue()
+		if err != nil {
+			return nil, err
+		}
+
+		incoming := model.FieldVersion{
+			Timestamp: change.Timestamp,
+			ClientId:  request.ClientId,
+			Clock:     change.Clock,
+		}
+
+		if change.Field == model.FieldTags {
+			tags := model.NormalizeTags(value.Tags)
+			tagsChanged, tagConflicts := mergeTags(task, versions, change.Op, tags, incoming)
+			changed = changed || tagsChanged
+			conflicts = append(conflicts, tagConflicts...)
+			continue
+		}
+
+		current := versions.Get(change.Field, task.CreatedAt)
+		serverValue := fieldValue(task, change.Field)
+		clientValue := changeValue(value, change.Field)
+		same := serverValue == clientValue
+
+		wins, concurrent := incoming.Supersedes(current)
+		if wins {
+			versions[change.Field] = incoming
+			if !same {
+				applyValue(task, value, change.Field)
+				changed = true
+			}
+		}
+
+		if !same && (!wins || concurrent) {
+			conflicts = append(conflicts, newConflict(change.Field, "", serverValue, clientValue, wins, concurrent))
+		}
+	}
+
+	if len(task.Tags) > maxTags {
+		return nil, TooManyTagsError
+	}
+
+	task.Versions = versions
+	if changed {
+		task.UpdatedAt = time.Now()
+		task.UpdateCompletedAt(task.UpdatedAt)
+	}
+
+	err = s.repo.UpdateTask(task)
+	if errors.Is(err, store.NotFoundError) {
+		return nil, NotFoundError
+	} else if err != nil {
+		return nil, InternalError
+	}
+
+	if changed {
+		s.emit(ctx, model.EventTaskUpdated, task)
+		if !wasDone && task.Status == model.StatusDone {
+			s.emit(ctx, model.EventTaskCompleted, task)
+		}
+	}
+
+	return &model.MergeResponse{
+		Task:      *task,
+		Conflicts: conflicts,
+	}, nil
+}
+
+// mergeTags merges tag changes element by element, treating the tags as a
+// last-writer-wins element set: "add" and "remove" touch only the given
+// tags while "set" adds the given tags and removes every other one.
+func mergeTags(task *model.Task, versions model.FieldVersions, op model.ChangeOp, tags []string, incoming model.FieldVersion) (bool, []model.MergeConflict) {
+	wanted := make(map[string]bool)
+	switch op {
+	case model.OpAdd:
+		for _, tag := range tags {
+			wanted[tag] = true
+		}
+	case model.OpRemove:
+		for _, tag := range tags {
+			wanted[tag] = false
+		}
+	default:
+		for _, tag := range task.Tags {
+			wanted[tag] = false
+		}
+		for _, tag := range tags {
+			wanted[tag] = true
+		}
+	}
+
+	changed := false
+	conflicts := make([]model.MergeConflict, 0)
+	result := slices.Clone(task.Tags)
+
+	keys := make([]string, 0, len(wanted))
+	for tag := range wanted {
+		keys = append(keys, tag)
+	}
+	slices.Sort(keys)
+
+	for _, tag := range keys {
+		present := slices.Contains(result, tag)
+		add := wanted[tag]
+
+		current := versions.Get(model.TagVersionKey(tag), task.CreatedAt)
+		version := incoming
+		version.Removed = !add
+
+		wins, concurrent := version.Supersedes(current)
+		if wins {
+			versions[model.TagVersionKey(tag)] = version
+			if add && !present {
+				result = append(result, tag)
+				changed = true
+			} else if !add && present {
+				result = slices.DeleteFunc(result, func(t string) bool { return t == tag })
+				changed = true
+			}
+		}
+
+		if present != add && (!wins || concurrent) {
+			conflicts = append(conflicts, newConflict(model.FieldTags, tag, present, add, wins, concurrent))
+		}
+	}
+
+	task.Tags = result
+
+	return changed, conflicts
+}
+
+// setTagVersions records element versions for the tags added and removed by
+// a whole-set tag update.
+func setTagVersions(versions model.FieldVersions, oldTags []string, newTags []string, version model.FieldVersion) {
+	for _, tag := range newTags {
+		if !slices.Contains(oldTags, tag) {
+			versions[model.TagVersionKey(tag)] = version
+		}
+	}
+	for _, tag := range oldTags {
+		if !slices.Contains(newTags, tag) {
+			removed := version
+			removed.Removed = true
+			versions[model.TagVersionKey(tag)] = removed
+		}
+	}
+}
+
+func newConflict(field string, tag string, serverValue any, clientValue any, clientWins bool, concurrent bool) model.MergeConflict {
+	resolution := model.ResolutionServer
+	if clientWins {
+		resolution = model.ResolutionClient
+	}
+
+	return model.MergeConflict{
+		Field:       field,
+		Tag:         tag,
+		ServerValue: serverValue,
+		ClientValue: clientValue,
+		Resolution:  resolution,
+		Concurrent:  concurrent,
+	}
+}
+
+func fieldValue(task *model.Task, field string) any {
+	switch field {
+	case model.FieldTitle:
+		return task.Title
+	case model.FieldContent:
+		return task.Content
+	case model.FieldStatus:
+		return task.Status
+	case model.FieldPriority:
+		return task.Priority
+	case model.FieldDueDate:
+		if task.DueDate == nil {
+			return nil
+		}
+		return task.DueDate.UTC().Format(time.RFC3339Nano)
+	}
+	return nil
+}
+
+func changeValue(value *model.FieldChangeValue, field string) any {
+	switch field {
+	case model.FieldTitle:
+		return *value.Title
+	case model.FieldContent:
+		return *value.Content
+	case model.FieldStatus:
+		return *value.Status
+	case model.FieldPriority:
+		return *value.Priority
+	case model.FieldDueDate:
+		if value.DueDate == nil {
+			return nil
+		}
+		return value.DueDate.UTC().Format(time.RFC3339Nano)
+	}
+	return nil
+}
+
+func applyValue(task *model.Task, value *model.FieldChangeValue, field string) {
+	switch field {
+	case model.FieldTitle:
+		task.Title = *value.Title
+	case model.FieldContent:
+		task.Content = *value.Content
+	case model.FieldStatus:
+		task.Status = *value.Status
+	case model.FieldPriority:
+		task.Priority = *value.Priority
+	case model.FieldDueDate:
+		task.DueDate = value.DueDate
+	}
+}
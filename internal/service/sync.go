@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"simple-tasks/internal/model"
+	"strconv"
+	"strings"
+)
+
+var InvalidSyncTokenError = newError(KindInvalid, "invalid sync token")
+
+const defaultSyncLimit = 500
+
+// Sync returns the changes made after the position encoded in the request's
+// change token. Tokens carry the service epoch, so tokens issued before a
+// restart of the in-memory store lead to a full resync instead of silently
+// missing changes.
+func (s *TaskService) Sync(ctx context.Context, request *model.SyncRequest) (*model.SyncResponse, error) {
+	var since uint64
+	fullResync := false
+	if request.Since != "" {
+		epoch, seq, err := decodeSyncToken(request.Since)
+		if err != nil {
+			return nil, err
+		}
+		if epoch == s.epoch {
+			since = seq
+		} else {
+			fullResync = true
+		}
+	}
+
+	limit := defaultSyncLimit
+	if request.Limit != nil {
+		limit = *request.Limit
+	}
+
+	changes := s.repo.GetChanges(since, limit)
+
+	return &model.SyncResponse{
+		Tasks:      changes.Tasks,
+		Deleted:    changes.Tombstones,
+		NextToken:  encodeSyncToken(s.epoch, changes.Seq),
+		HasMore:    changes.HasMore,
+		FullResync: fullResync || changes.Expired,
+	}, nil
+}
+
+// SyncToken returns the change token of the current state of the tasks. It
+// changes whenever a task is created, updated or deleted.
+func (s *TaskService) SyncToken(ctx context.Context) string {
+	return encodeSyncToken(s.epoch, s.repo.Seq())
+}
+
+func encodeSyncToken(epoch string, seq uint64) string {
+	return base64.RawURLEncoding.EncodeToString(fmt.Appendf(nil, "%s.%d", epoch, seq))
+}
+
+func decodeSyncToken(token string) (string, uint64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return "", 0, InvalidSyncTokenError
+	}
+
+	epoch, seqStr, ok := strings.Cut(string(raw), ".")
+	if !ok {
+		return "", 0, InvalidSyncTokenError
+	}
+	seq, err := strconv.ParseUint(seqStr, 10, 64)
+	if err != nil {
+		return "", 0, InvalidSyncTokenError
+	}
+
+	return epoch, seq, nil
+}
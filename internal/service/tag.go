@@ -0,0 +1,189 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"log/slog"
+	"simple-tasks/internal/model"
+	"simple-tasks/internal/store"
+	"slices"
+	"strings"
+	"sync"
+)
+
+var (
+	TagNotFoundError = newError(KindNotFound, "tag not found")
+	TagExistsError   = newError(KindConflict, "tag already exists")
+)
+
+// TagService manages the tag catalog: usage counts computed from the tasks,
+// metadata kept in its own repository, and renames and merges that rewrite
+// every affected task.
+type TagService struct {
+	repo  store.TagRepository
+	tasks *TaskService
+	log   *slog.Logger
+
+	// mu serializes catalog changes so a rename can't interleave with
+	// another rename or a metadata update.
+	mu sync.Mutex
+}
+
+func NewTagService(log *slog.Logger, repo store.TagRepository, tasks *TaskService) *TagService {
+	return &TagService{
+		log:   log,
+		repo:  repo,
+		tasks: tasks,
+	}
+}
+
+// GetTags returns the tags starting with prefix, most used first. A limit of
+// zero returns all of them.
+func (s *TagService) GetTags(ctx context.Context, prefix string, limit int) []model.Tag {
+	prefix = model.NormalizeTag(prefix)
+	counts := s.tasks.TagCounts(ctx)
+
+	byName := make(map[string]model.Tag)
+	for _, tag := range s.repo.GetTags() {
+		byName[tag.Name] = tag
+	}
+	for name, count := range counts {
+		tag := byName[name]
+		tag.Name = name
+		tag.Count = count
+		byName[name] = tag
+	}
+
+	tags := make([]model.Tag, 0, len(byName))
+	for _, tag := range byName {
+		if strings.HasPrefix(tag.Name, prefix) {
+			tags = append(tags, tag)
+		}
+	}
+	slices.SortFunc(tags, func(a, b model.Tag) int {
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	if limit > 0 && len(tags) > limit {
+		tags = tags[:limit]
+	}
+
+	return tags
+}
+
+func (s *TagService) GetTag(ctx context.Context, name string) (*model.Tag, error) {
+	name = model.NormalizeTag(name)
+
+	tag, err := s.repo.GetTag(name)
+	if err != nil && !errors.Is(err, store.TagNotFoundError) {
+		return nil, InternalError
+	}
+
+	tag.Name = name
+	tag.Count = s.tasks.TagCounts(ctx)[name]
+	if err != nil && tag.Count == 0 {
+		return nil, TagNotFoundError
+	}
+
+	return &tag, nil
+}
+
+// UpdateTag sets the metadata of a tag. Metadata can be set before the tag is
+// used by any task.
+func (s *TagService) UpdateTag(ctx context.Context, name string, request *model.UpdateTagRequest) (*model.Tag, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	name = model.NormalizeTag(name)
+	tag, err := s.repo.GetTag(name)
+	if err != nil && !errors.Is(err, store.TagNotFoundError) {
+		return nil, InternalError
+	}
+
+	tag.Name = name
+	if request.Color != nil {
+		tag.Color = *request.Color
+	}
+	if request.Description != nil {
+		tag.Description = *request.Description
+	}
+	s.repo.SaveTag(&tag)
+
+	return s.GetTag(ctx, name)
+}
+
+// RenameTag renames a tag on every task and moves its metadata. The new name
+// must not be in use; merging into an existing tag is MergeTag.
+func (s *TagService) RenameTag(ctx context.Context, name string, request *model.RenameTagRequest) (*model.TagChangeResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	from := model.NormalizeTag(name)
+	to := model.NormalizeTag(request.Name)
+
+	source, err := s.GetTag(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+	if from == to {
+		return &model.TagChangeResponse{Tag: *source}, nil
+	}
+	if _, err := s.GetTag(ctx, to); err == nil {
+		return nil, TagExistsError
+	}
+
+	updated := s.tasks.ReplaceTag(ctx, from, to)
+
+	s.repo.DeleteTag(from)
+	target := *source
+	target.Name = to
+	if target.Color != "" || target.Description != "" {
+		s.repo.SaveTag(&target)
+	}
+
+	tag, err := s.GetTag(ctx, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.TagChangeResponse{Tag: *tag, UpdatedTasks: updated}, nil
+}
+
+// MergeTag replaces a tag with another, existing or not, on every task. The
+// target keeps its own metadata and inherits the source's only when it has
+// none.
+func (s *TagService) MergeTag(ctx context.Context, name string, request *model.MergeTagRequest) (*model.TagChangeResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	from := model.NormalizeTag(name)
+	to := model.NormalizeTag(request.Into)
+
+	source, err := s.GetTag(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+	if from == to {
+		return &model.TagChangeResponse{Tag: *source}, nil
+	}
+
+	updated := s.tasks.ReplaceTag(ctx, from, to)
+
+	s.repo.DeleteTag(from)
+	if _, err := s.repo.GetTag(to); errors.Is(err, store.TagNotFoundError) && (source.Color != "" || source.Description != "") {
+		target := *source
+		target.Name = to
+		s.repo.SaveTag(&target)
+	}
+
+	tag, err := s.GetTag(ctx, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.TagChangeResponse{Tag: *tag, UpdatedTasks: updated}, nil
+}
@@ -4,42 +4,106 @@ import (
 	"context"
 	"errors"
 	"github.com/google/uuid"
+	"iter"
 	"log/slog"
 	"simple-tasks/internal/model"
 	"simple-tasks/internal/store"
+	"slices"
+	"sync"
 	"time"
 )
 
 var (
-	NotFoundError = errors.New("task not found")
-	InternalError = errors.New("internal error")
+	NotFoundError = newError(KindNotFound, "task not found")
+	InternalError = newError(KindInternal, "internal error")
+
+	InvalidCursorError = newError(KindInvalid, "invalid cursor")
+
+	PreconditionFailedError = newError(KindPreconditionFailed, "precondition failed")
 )
 
+// Precondition decides whether a conditional write goes ahead, given the
+// current task, which is nil when there is none.
+type Precondition func(current *model.Task) bool
+
+type EventListener func(ctx context.Context, event model.TaskEvent)
+
 type TaskService struct {
 	repo store.TaskRepository
 	log  *slog.Logger
+	// epoch identifies this service instance in sync tokens.
+	epoch string
+
+	listenersMu sync.RWMutex
+	listeners   []EventListener
+
+	// mergeMu serializes the read-modify-write of tasks, so that updates,
+	// merges, imports and deletes don't overwrite each other's fields and
+	// versions.
+	mergeMu sync.Mutex
 }
 
 func NewTaskService(log *slog.Logger, repo store.TaskRepository) *TaskService {
 	return &TaskService{
-		log:  log,
-		repo: repo,
+		log:   log,
+		repo:  repo,
+		epoch: uuid.New().String()[:8],
 	}
 }
 
 func (s *TaskService) CreateTask(ctx context.Context, t *model.Task) *model.Task {
-	t.Id = uuid.New()
 	t.CreatedAt = time.Now()
 	t.UpdatedAt = t.CreatedAt
+	t.CompletedAt = nil
+
+	return s.insertTask(ctx, t)
+}
+
+// insertTask stores a new task whose timestamps are already set.
+func (s *TaskService) insertTask(ctx context.Context, t *model.Task) *model.Task {
+	t.Id = uuid.New()
+	t.Tags = model.NormalizeTags(t.Tags)
 	t.SetDefaults()
+	t.UpdateCompletedAt(t.UpdatedAt)
 
 	s.repo.SaveTask(t)
+	s.emit(ctx, model.EventTaskCreated, t)
 
 	return t
 }
 
-func (s *TaskService) GetTasks(ctx context.Context, request *model.GetTasksRequest) *model.GetTasksResponse {
-	return s.repo.GetTasks(request)
+func (s *TaskService) GetTasks(ctx context.Context, request *model.GetTasksRequest) (*model.GetTasksResponse, error) {
+	response, err := s.repo.GetTasks(request)
+	if errors.Is(err, store.InvalidCursorError) {
+		return nil, InvalidCursorError
+	} else if err != nil {
+		return nil, InternalError
+	}
+
+	return response, nil
+}
+
+func (s *TaskService) IterTasks(ctx context.Context, request *model.GetTasksRequest) (iter.Seq[model.Task], error) {
+	tasks, err := s.repo.IterTasks(request)
+	if err != nil {
+		return nil, InternalError
+	}
+
+	return tasks, nil
+}
+
+func (s *TaskService) GetStats(ctx context.Context, request *model.GetTasksRequest, statsRequest *model.StatsRequest) (*model.TaskStats, error) {
+	stats, err := s.repo.GetStats(request, statsRequest)
+	if err != nil {
+		return nil, InternalError
+	}
+
+	return stats, nil
+}
+
+// TagCounts returns the number of tasks with each tag.
+func (s *TaskService) TagCounts(ctx context.Context) map[string]int {
+	return s.repo.GetTagCounts()
 }
 
 func (s *TaskService) GetTaskById(ctx context.Context, uuid uuid.UUID) (*model.Task, error) {
@@ -54,31 +118,49 @@ func (s *TaskService) GetTaskById(ctx context.Context, uuid uuid.UUID) (*model.T
 }
 
 func (s *TaskService) UpdateTask(ctx context.Context, id uuid.UUID, request *model.UpdateTaskRequest) (*model.Task, error) {
+	s.mergeMu.Lock()
+	defer s.mergeMu.Unlock()
+
 	task, err := s.GetTaskById(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
+	now := time.Now()
+	version := model.FieldVersion{Timestamp: now}
+	versions := task.Versions.Clone()
+
 	if request.Title != "" {
 		task.Title = request.Title
+		versions[model.FieldTitle] = version
 	}
 	if request.Content != "" {
 		task.Content = request.Content
+		versions[model.FieldContent] = version
 	}
+	completed := false
 	if request.Status != "" {
+		completed = task.Status != model.StatusDone && request.Status == model.StatusDone
 		task.Status = request.Status
+		versions[model.FieldStatus] = version
 	}
 	if request.Priority != "" {
 		task.Priority = request.Priority
+		versions[model.FieldPriority] = version
 	}
 	if len(request.Tags) != 0 {
-		task.Tags = request.Tags
+		tags := model.NormalizeTags(request.Tags)
+		setTagVersions(versions, task.Tags, tags, version)
+		task.Tags = tags
 	}
 	if request.DueDate != nil {
 		task.DueDate = request.DueDate
+		versions[model.FieldDueDate] = version
 	}
 
-	task.UpdatedAt = time.Now()
+	task.Versions = versions
+	task.UpdatedAt = now
+	task.UpdateCompletedAt(now)
 
 	err = s.repo.UpdateTask(task)
 	if errors.Is(err, store.NotFoundError) {
@@ -87,16 +169,108 @@ func (s *TaskService) UpdateTask(ctx context.Context, id uuid.UUID, request *mod
 		return nil, InternalError
 	}
 
+	s.emit(ctx, model.EventTaskUpdated, task)
+	if completed {
+		s.emit(ctx, model.EventTaskCompleted, task)
+	}
+
 	return task, nil
 }
 
 func (s *TaskService) DeleteTask(ctx context.Context, uuid uuid.UUID) error {
-	err := s.repo.DeleteTask(uuid)
+	return s.DeleteTaskIf(ctx, uuid, nil)
+}
+
+// DeleteTaskIf deletes a task if precondition accepts it. The check and the
+// delete happen under the lock of writes, so no write can come in between.
+func (s *TaskService) DeleteTaskIf(ctx context.Context, uuid uuid.UUID, precondition Precondition) error {
+	s.mergeMu.Lock()
+	defer s.mergeMu.Unlock()
+
+	task, err := s.GetTaskById(ctx, uuid)
+	if err != nil {
+		return err
+	}
+	if precondition != nil && !precondition(task) {
+		return PreconditionFailedError
+	}
+
+	err = s.repo.DeleteTask(uuid)
 	if errors.Is(err, store.NotFoundError) {
 		return NotFoundError
 	} else if err != nil {
 		return InternalError
 	}
 
+	s.emit(ctx, model.EventTaskDeleted, task)
+
 	return nil
 }
+
+// ReplaceTag replaces a tag with another on every task in one repository
+// update, keeping tag versions current for offline merges. It returns the
+// number of updated tasks.
+func (s *TaskService) ReplaceTag(ctx context.Context, from string, to string) int {
+	s.mergeMu.Lock()
+	defer s.mergeMu.Unlock()
+
+	now := time.Now()
+	version := model.FieldVersion{Timestamp: now}
+
+	changed := s.repo.UpdateTasks(func(task *model.Task) bool {
+		if !slices.Contains(task.Tags, from) {
+			return false
+		}
+
+		tags := make([]string, 0, len(task.Tags))
+		for _, tag := range task.Tags {
+			if tag == from {
+				tag = to
+			}
+			if !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+
+		versions := task.Versions.Clone()
+		setTagVersions(versions, task.Tags, tags, version)
+		task.Tags = tags
+		task.Versions = versions
+		task.UpdatedAt = now
+		return true
+	})
+
+	for i := range changed {
+		s.emit(ctx, model.EventTaskUpdated, &changed[i])
+	}
+
+	return len(changed)
+}
+
+// AddListener registers a listener that is called synchronously after every
+// successful task mutation. Listeners must not block.
+func (s *TaskService) AddListener(listener EventListener) {
+	s.listenersMu.Lock()
+	s.listeners = append(s.listeners, listener)
+	s.listenersMu.Unlock()
+}
+
+func (s *TaskService) emit(ctx context.Context, eventType model.EventType, task *model.Task) {
+	s.listenersMu.RLock()
+	listeners := s.listeners
+	s.listenersMu.RUnlock()
+
+	if len(listeners) == 0 {
+		return
+	}
+
+	event := model.TaskEvent{
+		Id:         uuid.New(),
+		Type:       eventType,
+		OccurredAt: time.Now(),
+		Task:       *task,
+	}
+	for _, listener := range listeners {
+		listener(ctx, event)
+	}
+}
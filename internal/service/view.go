@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"log/slog"
+	"simple-tasks/internal/model"
+	"simple-tasks/internal/store"
+	"time"
+)
+
+var (
+	ViewNotFoundError  = newError(KindNotFound, "view not found")
+	ViewForbiddenError = newError(KindForbidden, "view belongs to another user")
+)
+
+// ViewService manages saved views. A view is visible to its owner and, when
+// shared, to every other user; only the owner can change or delete it.
+type ViewService struct {
+	repo store.ViewRepository
+	log  *slog.Logger
+}
+
+func NewViewService(log *slog.Logger, repo store.ViewRepository) *ViewService {
+	return &ViewService{
+		log:  log,
+		repo: repo,
+	}
+}
+
+func (s *ViewService) CreateView(ctx context.Context, user string, v *model.View) *model.View {
+	v.Id = uuid.New()
+	v.Owner = user
+	v.Count = nil
+	v.CreatedAt = time.Now()
+	v.UpdatedAt = v.CreatedAt
+
+	s.repo.SaveView(v)
+
+	return v
+}
+
+func (s *ViewService) GetViews(ctx context.Context, user string) []model.View {
+	views := s.repo.GetViews()
+
+	visible := make([]model.View, 0, len(views))
+	for _, view := range views {
+		if view.Owner == user || view.Shared {
+			visible = append(visible, view)
+		}
+	}
+
+	return visible
+}
+
+func (s *ViewService) GetViewById(ctx context.Context, user string, id uuid.UUID) (*model.View, error) {
+	view, err := s.repo.GetViewById(id)
+	if errors.Is(err, store.ViewNotFoundError) {
+		return nil, ViewNotFoundError
+	} else if err != nil {
+		return nil, InternalError
+	}
+
+	// Private views of other users are reported as missing rather than
+	// forbidden, so their ids don't leak.
+	if view.Owner != user && !view.Shared {
+		return nil, ViewNotFoundError
+	}
+
+	return &view, nil
+}
+
+func (s *ViewService) UpdateView(ctx context.Context, user string, id uuid.UUID, request *model.UpdateViewRequest) (*model.View, error) {
+	view, err := s.GetViewById(ctx, user, id)
+	if err != nil {
+		return nil, err
+	}
+	if view.Owner != user {
+		return nil, ViewForbiddenError
+	}
+
+	if request.Name != "" {
+		view.Name = request.Name
+	}
+	if request.Query != nil {
+		view.Query = *request.Query
+	}
+	if request.Shared != nil {
+		view.Shared = *request.Shared
+	}
+
+	view.UpdatedAt = time.Now()
+
+	err = s.repo.UpdateView(view)
+	if errors.Is(err, store.ViewNotFoundError) {
+		return nil, ViewNotFoundError
+	} else if err != nil {
+		return nil, InternalError
+	}
+
+	return view, nil
+}
+
+func (s *ViewService) DeleteView(ctx context.Context, user string, id uuid.UUID) error {
+	view, err := s.GetViewById(ctx, user, id)
+	if err != nil {
+		return err
+	}
+	if view.Owner != user {
+		return ViewForbiddenError
+	}
+
+	err = s.repo.DeleteView(id)
+	if errors.Is(err, store.ViewNotFoundError) {
+		return ViewNotFoundError
+	} else if err != nil {
+		return InternalError
+	}
+
+	return nil
+}
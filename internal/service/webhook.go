@@ -0,0 +1,360 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"io"
+	"log/slog"
+	"net/http"
+	"simple-tasks/internal/model"
+	"simple-tasks/internal/store"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var WebhookNotFoundError = newError(KindNotFound, "webhook not found")
+
+const (
+	HeaderWebhookEvent     = "X-Webhook-Event"
+	HeaderWebhookDelivery  = "X-Webhook-Delivery"
+	HeaderWebhookTimestamp = "X-Webhook-Timestamp"
+	HeaderWebhookSignature = "X-Webhook-Signature"
+)
+
+type WebhookOptions struct {
+	// MaxAttempts is the number of times a single event is sent before the
+	// delivery is considered failed.
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// DisableAfter is the number of consecutive failed deliveries after which
+	// the webhook is deactivated.
+	DisableAfter int
+	Timeout      time.Duration
+}
+
+func DefaultWebhookOptions() WebhookOptions {
+	return WebhookOptions{
+		MaxAttempts:    5,
+		InitialBackoff: time.Second,
+		MaxBackoff:     time.Minute,
+		DisableAfter:   5,
+		Timeout:        10 * time.Second,
+	}
+}
+
+type WebhookService struct {
+	repo    store.WebhookRepository
+	log     *slog.Logger
+	client  *http.Client
+	options WebhookOptions
+
+	// stopping is closed by Close to abort pending retries, while ctx is
+	// cancelled only when in-flight requests must be abandoned.
+	stopping chan struct{}
+	closeMu  sync.RWMutex
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+	// failuresMu serializes failure accounting so concurrent deliveries to
+	// the same webhook do not lose counter updates.
+	failuresMu sync.Mutex
+}
+
+func NewWebhookService(log *slog.Logger, repo store.WebhookRepository, options WebhookOptions) *WebhookService {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &WebhookService{
+		repo:     repo,
+		log:      log,
+		client:   &http.Client{Timeout: options.Timeout},
+		options:  options,
+		stopping: make(chan struct{}),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+func (s *WebhookService) CreateWebhook(ctx context.Context, w *model.Webhook) (*model.Webhook, error) {
+	if w.Secret == "" {
+		secret, err := newWebhookSecret()
+		if err != nil {
+			s.log.ErrorContext(ctx, "failed to generate webhook secret", slog.String("error", err.Error()))
+			return nil, InternalError
+		}
+		w.Secret = secret
+	}
+
+	w.Id = uuid.New()
+	w.Active = true
+	w.FailureCount = 0
+	w.DisabledAt = nil
+	w.CreatedAt = time.Now()
+	w.UpdatedAt = w.CreatedAt
+
+	s.repo.SaveWebhook(w)
+
+	return w, nil
+}
+
+func (s *WebhookService) GetWebhooks(ctx context.Context) []model.Webhook {
+	return s.repo.GetWebhooks()
+}
+
+func (s *WebhookService) GetWebhookById(ctx context.Context, id uuid.UUID) (*model.Webhook, error) {
+	webhook, err := s.repo.GetWebhookById(id)
+	if errors.Is(err, store.WebhookNotFoundError) {
+		return nil, WebhookNotFoundError
+	} else if err != nil {
+		return nil, InternalError
+	}
+
+	return &webhook, nil
+}
+
+func (s *WebhookService) UpdateWebhook(ctx context.Context, id uuid.UUID, request *model.UpdateWebhookRequest) (*model.Webhook, error) {
+	webhook, err := s.GetWebhookById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.Url != "" {
+		webhook.Url = request.Url
+	}
+	if request.Description != nil {
+		webhook.Description = *request.Description
+	}
+	if request.Events != nil {
+		webhook.Events = request.Events
+	}
+	if request.Secret != "" {
+		webhook.Secret = request.Secret
+	}
+	if request.Active != nil {
+		webhook.Active = *request.Active
+		if webhook.Active {
+			webhook.FailureCount = 0
+			webhook.DisabledAt = nil
+		}
+	}
+
+	webhook.UpdatedAt = time.Now()
+
+	err = s.repo.UpdateWebhook(webhook)
+	if errors.Is(err, store.WebhookNotFoundError) {
+		return nil, WebhookNotFoundError
+	} else if err != nil {
+		return nil, InternalError
+	}
+
+	return webhook, nil
+}
+
+func (s *WebhookService) DeleteWebhook(ctx context.Context, id uuid.UUID) error {
+	err := s.repo.DeleteWebhook(id)
+	if errors.Is(err, store.WebhookNotFoundError) {
+		return WebhookNotFoundError
+	} else if err != nil {
+		return InternalError
+	}
+
+	return nil
+}
+
+func (s *WebhookService) GetDeliveries(ctx context.Context, id uuid.UUID) ([]model.WebhookDelivery, error) {
+	deliveries, err := s.repo.GetDeliveries(id)
+	if errors.Is(err, store.WebhookNotFoundError) {
+		return nil, WebhookNotFoundError
+	} else if err != nil {
+		return nil, InternalError
+	}
+
+	return deliveries, nil
+}
+
+// HandleEvent schedules delivery of the event to every active webhook
+// subscribed to it. It is meant to be registered with TaskService.AddListener
+// and returns without waiting for the deliveries.
+func (s *WebhookService) HandleEvent(ctx context.Context, event model.TaskEvent) {
+	s.closeMu.RLock()
+	defer s.closeMu.RUnlock()
+
+	select {
+	case <-s.stopping:
+		return
+	default:
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		s.log.ErrorContext(ctx, "failed to encode webhook payload", slog.String("error", err.Error()))
+		return
+	}
+
+	for _, webhook := range s.repo.GetWebhooks() {
+		if !webhook.Active || !webhook.Accepts(event.Type) {
+			continue
+		}
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.deliver(webhook, event, payload)
+		}()
+	}
+}
+
+// Close stops scheduling new deliveries and retries, then waits for requests
+// in flight until ctx is done, after which they are cancelled.
+func (s *WebhookService) Close(ctx context.Context) {
+	s.closeMu.Lock()
+	close(s.stopping)
+	s.closeMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		s.cancel()
+		<-done
+	}
+	s.cancel()
+}
+
+func (s *WebhookService) deliver(webhook model.Webhook, event model.TaskEvent, payload []byte) {
+	backoff := s.options.InitialBackoff
+	for attempt := 1; attempt <= s.options.MaxAttempts; attempt++ {
+		delivery := s.send(webhook, event, payload, attempt)
+		s.repo.AddDelivery(delivery)
+		if delivery.Success {
+			s.recordResult(webhook.Id, true)
+			return
+		}
+
+		s.log.Warn("webhook delivery failed",
+			slog.String("webhookId", webhook.Id.String()),
+			slog.String("eventId", event.Id.String()),
+			slog.Int("attempt", attempt),
+			slog.Int("statusCode", delivery.StatusCode),
+			slog.String("error", delivery.Error))
+
+		if attempt == s.options.MaxAttempts {
+			break
+		}
+
+		select {
+		case <-s.stopping:
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > s.options.MaxBackoff {
+			backoff = s.options.MaxBackoff
+		}
+	}
+
+	s.recordResult(webhook.Id, false)
+}
+
+func (s *WebhookService) send(webhook model.Webhook, event model.TaskEvent, payload []byte, attempt int) *model.WebhookDelivery {
+	delivery := &model.WebhookDelivery{
+		Id:          uuid.New(),
+		WebhookId:   webhook.Id,
+		EventId:     event.Id,
+		EventType:   event.Type,
+		Attempt:     attempt,
+		AttemptedAt: time.Now(),
+	}
+
+	req, err := http.NewRequestWithContext(s.ctx, http.MethodPost, webhook.Url, bytes.NewReader(payload))
+	if err != nil {
+		delivery.Error = err.Error()
+		return delivery
+	}
+
+	timestamp := strconv.FormatInt(delivery.AttemptedAt.Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderWebhookEvent, event.Type)
+	req.Header.Set(HeaderWebhookDelivery, delivery.Id.String())
+	req.Header.Set(HeaderWebhookTimestamp, timestamp)
+	req.Header.Set(HeaderWebhookSignature, "sha256="+SignWebhookPayload(webhook.Secret, timestamp, payload))
+
+	resp, err := s.client.Do(req)
+	delivery.DurationMs = time.Since(delivery.AttemptedAt).Milliseconds()
+	if err != nil {
+		delivery.Error = err.Error()
+		return delivery
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	_ = resp.Body.Close()
+
+	delivery.StatusCode = resp.StatusCode
+	delivery.Success = resp.StatusCode >= 200 && resp.StatusCode < 300
+	if !delivery.Success {
+		delivery.Error = fmt.Sprintf("unexpected status code %d", resp.StatusCode)
+	}
+
+	return delivery
+}
+
+func (s *WebhookService) recordResult(id uuid.UUID, success bool) {
+	s.failuresMu.Lock()
+	defer s.failuresMu.Unlock()
+
+	webhook, err := s.repo.GetWebhookById(id)
+	if err != nil {
+		return
+	}
+
+	if success {
+		if webhook.FailureCount == 0 {
+			return
+		}
+		webhook.FailureCount = 0
+	} else {
+		webhook.FailureCount++
+		if webhook.Active && webhook.FailureCount >= s.options.DisableAfter {
+			now := time.Now()
+			webhook.Active = false
+			webhook.DisabledAt = &now
+			s.log.Warn("webhook disabled after repeated failures",
+				slog.String("webhookId", webhook.Id.String()),
+				slog.Int("failureCount", webhook.FailureCount))
+		}
+	}
+
+	_ = s.repo.UpdateWebhook(&webhook)
+}
+
+// SignWebhookPayload returns the hex encoded HMAC-SHA256 of
+// "<timestamp>.<payload>" keyed with the webhook secret.
+func SignWebhookPayload(secret string, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func newWebhookSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(secret), nil
+}
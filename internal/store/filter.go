@@ -0,0 +1,172 @@
+package store
+
+import (
+	"cmp"
+	"simple-tasks/internal/filter"
+	"simple-tasks/internal/model"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// evalFilter reports whether the task matches a filter expression. Relative
+// dates such as today() are resolved against now.
+func evalFilter(expr filter.Expr, task *model.Task, now time.Time) bool {
+	switch e := expr.(type) {
+	case *filter.And:
+		return evalFilter(e.Left, task, now) && evalFilter(e.Right, task, now)
+	case *filter.Or:
+		return evalFilter(e.Left, task, now) || evalFilter(e.Right, task, now)
+	case *filter.Not:
+		return !evalFilter(e.X, task, now)
+	case *filter.Has:
+		switch e.Field {
+		case filter.FieldDueDate:
+			return task.DueDate != nil
+		case filter.FieldTags:
+			return len(task.Tags) > 0
+		case filter.FieldCreatedAt, filter.FieldUpdatedAt:
+			return true
+		default:
+			return textField(task, e.Field) != ""
+		}
+	case *filter.Comparison:
+		return evalComparison(e, task, now)
+	}
+	return false
+}
+
+func evalComparison(c *filter.Comparison, task *model.Task, now time.Time) bool {
+	switch c.Type() {
+	case filter.TypeNumber:
+		n := len(task.Tags)
+		if c.Field != filter.FieldTags {
+			n = utf8.RuneCountInString(textField(task, c.Field))
+		}
+		return compareResult(c.Op, cmp.Compare(n, c.Values[0].Num))
+
+	case filter.TypeTime:
+		var t time.Time
+		switch c.Field {
+		case filter.FieldDueDate:
+			if task.DueDate == nil {
+				return false
+			}
+			t = *task.DueDate
+		case filter.FieldCreatedAt:
+			t = task.CreatedAt
+		case filter.FieldUpdatedAt:
+			t = task.UpdatedAt
+		}
+		return compareTime(c.Op, t, c.Values[0], now)
+
+	case filter.TypeSet:
+		return matchAny(c, func(value string) bool {
+			if c.Op == filter.OpContains {
+				return slices.ContainsFunc(task.Tags, func(tag string) bool {
+					return containsFold(tag, value)
+				})
+			}
+			return slices.Contains(task.Tags, value)
+		})
+
+	case filter.TypeEnum:
+		value := textField(task, c.Field)
+		rank := model.StatusRank
+		if c.Field == filter.FieldPriority {
+			rank = model.PriorityRank
+		}
+		if c.Op == filter.OpIn || c.Op == filter.OpNotIn {
+			return matchAny(c, func(v string) bool { return v == value })
+		}
+		return compareResult(c.Op, cmp.Compare(rank(value), rank(c.Values[0].Str)))
+
+	default:
+		value := textField(task, c.Field)
+		if c.Func == filter.FuncLower {
+			value = strings.ToLower(value)
+		}
+		return matchAny(c, func(v string) bool {
+			if c.Op == filter.OpContains {
+				return containsFold(value, v)
+			}
+			return value == v
+		})
+	}
+}
+
+// matchAny applies the equality-like operators: =, ~ and in match when any
+// value matches, != and not in when none does.
+func matchAny(c *filter.Comparison, match func(string) bool) bool {
+	matched := slices.ContainsFunc(c.Values, func(v filter.Value) bool {
+		return match(v.Str)
+	})
+	if c.Op == filter.OpNe || c.Op == filter.OpNotIn {
+		return !matched
+	}
+	return matched
+}
+
+func compareResult(op filter.Operator, c int) bool {
+	switch op {
+	case filter.OpEq:
+		return c == 0
+	case filter.OpNe:
+		return c != 0
+	case filter.OpLt:
+		return c < 0
+	case filter.OpLe:
+		return c <= 0
+	case filter.OpGt:
+		return c > 0
+	case filter.OpGe:
+		return c >= 0
+	}
+	return false
+}
+
+// compareTime compares an instant with a date value. Against a whole day,
+// "=" means any time on that day, "<" before it starts and "<=" before it
+// ends.
+func compareTime(op filter.Operator, t time.Time, value filter.Value, now time.Time) bool {
+	start, wholeDay := value.Resolve(now)
+	if !wholeDay {
+		return compareResult(op, t.Compare(start))
+	}
+
+	end := start.AddDate(0, 0, 1)
+	switch op {
+	case filter.OpEq:
+		return !t.Before(start) && t.Before(end)
+	case filter.OpNe:
+		return t.Before(start) || !t.Before(end)
+	case filter.OpLt:
+		return t.Before(start)
+	case filter.OpLe:
+		return t.Before(end)
+	case filter.OpGt:
+		return !t.Before(end)
+	case filter.OpGe:
+		return !t.Before(start)
+	}
+	return false
+}
+
+func textField(task *model.Task, field string) string {
+	switch field {
+	case filter.FieldTitle:
+		return task.Title
+	case filter.FieldContent:
+		return task.Content
+	case filter.FieldStatus:
+		return task.Status
+	case filter.FieldPriority:
+		return task.Priority
+	}
+	return ""
+}
+
+func containsFold(s string, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
@@ -0,0 +1,96 @@
+package store
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"simple-tasks/internal/model"
+	"slices"
+	"sort"
+	"sync"
+)
+
+var ImportJobNotFoundError = errors.New("import job not found")
+
+// maxImportJobs is the number of jobs kept; the oldest finished ones are
+// forgotten first, while queued and running jobs are always kept.
+const maxImportJobs = 100
+
+type ImportJobRepository interface {
+	SaveImportJob(*model.ImportJob)
+	GetImportJobs() []model.ImportJob
+	GetImportJobById(uuid.UUID) (model.ImportJob, error)
+	UpdateImportJob(*model.ImportJob) error
+}
+
+type InMemoryImportJobRepository struct {
+	mu   sync.RWMutex
+	jobs map[uuid.UUID]model.ImportJob
+	// order holds the job ids from the oldest to the newest.
+	order []uuid.UUID
+}
+
+func NewInMemoryImportJobRepository() *InMemoryImportJobRepository {
+	return &InMemoryImportJobRepository{
+		mu:   sync.RWMutex{},
+		jobs: make(map[uuid.UUID]model.ImportJob),
+	}
+}
+
+// SaveImportJob stores a new job. The errors are copied, so the caller can
+// keep appending to its own.
+func (r *InMemoryImportJobRepository) SaveImportJob(job *model.ImportJob) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := *job
+	stored.Errors = slices.Clone(job.Errors)
+	r.jobs[job.Id] = stored
+	r.order = append(r.order, job.Id)
+	if len(r.order) > maxImportJobs {
+		i := slices.IndexFunc(r.order, func(id uuid.UUID) bool { return r.jobs[id].FinishedAt != nil })
+		if i >= 0 {
+			delete(r.jobs, r.order[i])
+			r.order = slices.Delete(r.order, i, i+1)
+		}
+	}
+}
+
+func (r *InMemoryImportJobRepository) GetImportJobs() []model.ImportJob {
+	r.mu.RLock()
+	jobs := make([]model.ImportJob, 0, len(r.jobs))
+	for _, job := range r.jobs {
+		jobs = append(jobs, job)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].CreatedAt.After(jobs[j].CreatedAt)
+	})
+
+	return jobs
+}
+
+func (r *InMemoryImportJobRepository) GetImportJobById(id uuid.UUID) (model.ImportJob, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if job, ok := r.jobs[id]; ok {
+		return job, nil
+	}
+
+	return model.ImportJob{}, ImportJobNotFoundError
+}
+
+func (r *InMemoryImportJobRepository) UpdateImportJob(job *model.ImportJob) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.jobs[job.Id]; !ok {
+		return ImportJobNotFoundError
+	}
+
+	stored := *job
+	stored.Errors = slices.Clone(job.Errors)
+	r.jobs[job.Id] = stored
+
+	return nil
+}
@@ -0,0 +1,394 @@
+package store
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"simple-tasks/internal/model"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const frontMatterDelimiter = "---"
+
+// validate checks hand-edited tasks against the rules the API enforces.
+var validate = validator.New()
+
+// MarkdownTaskRepository stores every task as a Markdown file in a
+// directory, with the fields in YAML front matter and the content as the
+// body, so tasks can be kept under version control. Queries are answered
+// from an in-memory index, which a polling watcher keeps up to date with
+// files edited, added or removed outside of the server. Such external
+// changes reach GetChanges, but not the service's event listeners. Files
+// that are not valid tasks by the rules of the API are logged and skipped.
+type MarkdownTaskRepository struct {
+	*InMemoryTaskRepository
+
+	log *slog.Logger
+	dir string
+
+	// fileMu serializes writes and scans, so a scan never reads a file
+	// while it is being written.
+	fileMu sync.Mutex
+	// files maps the name of every known file to its task and the state it
+	// was last read or written in; paths maps tasks back to their files.
+	files map[string]markdownFile
+	paths map[uuid.UUID]string
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+type markdownFile struct {
+	id      uuid.UUID
+	modTime time.Time
+	size    int64
+}
+
+type frontMatter struct {
+	Id          string     `yaml:"id"`
+	Title       string     `yaml:"title"`
+	Status      string     `yaml:"status"`
+	Priority    string     `yaml:"priority"`
+	Tags        []string   `yaml:"tags,flow,omitempty"`
+	DueDate     *time.Time `yaml:"dueDate,omitempty"`
+	CreatedAt   time.Time  `yaml:"createdAt"`
+	UpdatedAt   time.Time  `yaml:"updatedAt"`
+	CompletedAt *time.Time `yaml:"completedAt,omitempty"`
+	ExternalId  string     `yaml:"externalId,omitempty"`
+}
+
+// NewMarkdownTaskRepository loads the tasks of dir, creating it if needed,
+// and watches it for changes every pollInterval until Close.
+func NewMarkdownTaskRepository(log *slog.Logger, dir string, pollInterval time.Duration) (*MarkdownTaskRepository, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	r := &MarkdownTaskRepository{
+		InMemoryTaskRepository: NewInMemoryTaskRepository(),
+		log:                    log,
+		dir:                    dir,
+		files:                  make(map[string]markdownFile),
+		paths:                  make(map[uuid.UUID]string),
+		stop:                   make(chan struct{}),
+		done:                   make(chan struct{}),
+	}
+	if err := r.Scan(); err != nil {
+		return nil, err
+	}
+
+	go r.watch(pollInterval)
+
+	return r, nil
+}
+
+// Close stops watching the directory.
+func (r *MarkdownTaskRepository) Close() {
+	close(r.stop)
+	<-r.done
+}
+
+func (r *MarkdownTaskRepository) SaveTask(task *model.Task) {
+	r.fileMu.Lock()
+	defer r.fileMu.Unlock()
+
+	r.InMemoryTaskRepository.SaveTask(task)
+	r.writeTask(task)
+}
+
+func (r *MarkdownTaskRepository) UpdateTask(task *model.Task) error {
+	r.fileMu.Lock()
+	defer r.fileMu.Unlock()
+
+	if err := r.InMemoryTaskRepository.UpdateTask(task); err != nil {
+		return err
+	}
+	r.writeTask(task)
+	return nil
+}
+
+func (r *MarkdownTaskRepository) UpdateTasks(update func(*model.Task) bool) []model.Task {
+	r.fileMu.Lock()
+	defer r.fileMu.Unlock()
+
+	changed := r.InMemoryTaskRepository.UpdateTasks(update)
+	for i := range changed {
+		r.writeTask(&changed[i])
+	}
+	return changed
+}
+
+func (r *MarkdownTaskRepository) DeleteTask(id uuid.UUID) error {
+	r.fileMu.Lock()
+	defer r.fileMu.Unlock()
+
+	if err := r.InMemoryTaskRepository.DeleteTask(id); err != nil {
+		return err
+	}
+
+	if name, ok := r.paths[id]; ok {
+		if err := os.Remove(filepath.Join(r.dir, name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			r.log.Error("failed to remove task file", slog.String("file", name), slog.String("error", err.Error()))
+		}
+		delete(r.files, name)
+		delete(r.paths, id)
+	}
+	return nil
+}
+
+// Scan brings the index up to date with the directory: new and modified
+// files are read, and the tasks of removed files are deleted. Files are
+// matched by name, size and modification time.
+func (r *MarkdownTaskRepository) Scan() error {
+	r.fileMu.Lock()
+	defer r.fileMu.Unlock()
+
+	entries, err := os.ReadDir(r.dir)
+	if err != nil {
+		return err
+	}
+
+	present := make(map[string]os.FileInfo, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") || filepath.Ext(name) != ".md" {
+			continue
+		}
+		if info, err := entry.Info(); err == nil {
+			present[name] = info
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(present)) {
+		info := present[name]
+		known, ok := r.files[name]
+		if ok && known.modTime.Equal(info.ModTime()) && known.size == info.Size() {
+			continue
+		}
+		r.readTask(name, info, present)
+	}
+
+	for name, file := range r.files {
+		if _, ok := present[name]; ok {
+			continue
+		}
+		delete(r.files, name)
+		if r.paths[file.id] == name {
+			delete(r.paths, file.id)
+			_ = r.InMemoryTaskRepository.DeleteTask(file.id)
+		}
+	}
+
+	return nil
+}
+
+func (r *MarkdownTaskRepository) watch(pollInterval time.Duration) {
+	defer close(r.done)
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			if err := r.Scan(); err != nil {
+				r.log.Error("failed to scan task directory", slog.String("dir", r.dir), slog.String("error", err.Error()))
+			}
+		}
+	}
+}
+
+// readTask indexes a new or modified file. A file that takes over the id of
+// a file that is gone, such as a renamed one, replaces it. A known file that
+// turns invalid keeps its task as last read until it is fixed or removed.
+// The caller must hold fileMu.
+func (r *MarkdownTaskRepository) readTask(name string, info os.FileInfo, present map[string]os.FileInfo) {
+	data, err := os.ReadFile(filepath.Join(r.dir, name))
+	if err != nil {
+		r.log.Error("failed to read task file", slog.String("file", name), slog.String("error", err.Error()))
+		return
+	}
+
+	task, err := parseMarkdownTask(data)
+	if err != nil {
+		// The file is remembered, so it is only reported again once it
+		// changes.
+		r.files[name] = markdownFile{id: r.files[name].id, modTime: info.ModTime(), size: info.Size()}
+		r.log.Error("invalid task file", slog.String("file", name), slog.String("error", err.Error()))
+		return
+	}
+	if task.Id == uuid.Nil {
+		// Files created by hand get an id derived from their name.
+		task.Id = uuid.NewSHA1(uuid.NameSpaceURL, []byte(name))
+	}
+	if other, ok := r.paths[task.Id]; ok && other != name {
+		if _, exists := present[other]; exists {
+			r.files[name] = markdownFile{id: r.files[name].id, modTime: info.ModTime(), size: info.Size()}
+			r.log.Error("duplicate task id", slog.String("file", name), slog.String("other", other))
+			return
+		}
+		delete(r.files, other)
+	}
+
+	if task.CreatedAt.IsZero() {
+		task.CreatedAt = info.ModTime()
+	}
+	existing, err := r.InMemoryTaskRepository.GetTaskById(task.Id)
+	if err == nil {
+		// Hand edits rarely touch updatedAt, but clients rely on it moving.
+		if !task.UpdatedAt.After(existing.UpdatedAt) {
+			task.UpdatedAt = info.ModTime()
+		}
+		task.Versions = existing.Versions
+		task.UpdateCompletedAt(task.UpdatedAt)
+		_ = r.InMemoryTaskRepository.UpdateTask(&task)
+	} else {
+		if task.UpdatedAt.IsZero() {
+			task.UpdatedAt = task.CreatedAt
+		}
+		task.UpdateCompletedAt(task.UpdatedAt)
+		r.InMemoryTaskRepository.SaveTask(&task)
+	}
+
+	r.files[name] = markdownFile{id: task.Id, modTime: info.ModTime(), size: info.Size()}
+	r.paths[task.Id] = name
+}
+
+// writeTask writes the file of a task, keeping the name of an existing file.
+// The caller must hold fileMu.
+func (r *MarkdownTaskRepository) writeTask(task *model.Task) {
+	name, ok := r.paths[task.Id]
+	if !ok {
+		name = task.Id.String() + ".md"
+	}
+
+	info, err := writeFileAtomic(filepath.Join(r.dir, name), formatMarkdownTask(task))
+	if err != nil {
+		r.log.Error("failed to write task file", slog.String("file", name), slog.String("error", err.Error()))
+		return
+	}
+
+	r.files[name] = markdownFile{id: task.Id, modTime: info.ModTime(), size: info.Size()}
+	r.paths[task.Id] = name
+}
+
+func formatMarkdownTask(task *model.Task) []byte {
+	matter := frontMatter{
+		Id:          task.Id.String(),
+		Title:       task.Title,
+		Status:      task.Status,
+		Priority:    task.Priority,
+		Tags:        task.Tags,
+		DueDate:     utcTime(task.DueDate),
+		CreatedAt:   task.CreatedAt.UTC(),
+		UpdatedAt:   task.UpdatedAt.UTC(),
+		CompletedAt: utcTime(task.CompletedAt),
+		ExternalId:  task.ExternalId,
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(frontMatterDelimiter + "\n")
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	_ = encoder.Encode(matter)
+	_ = encoder.Close()
+	buf.WriteString(frontMatterDelimiter + "\n")
+	if task.Content != "" {
+		buf.WriteString("\n" + task.Content)
+		if !strings.HasSuffix(task.Content, "\n") {
+			buf.WriteString("\n")
+		}
+	}
+	return buf.Bytes()
+}
+
+func parseMarkdownTask(data []byte) (model.Task, error) {
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	text = strings.TrimPrefix(text, "\ufeff")
+
+	rest, ok := strings.CutPrefix(text, frontMatterDelimiter+"\n")
+	if !ok {
+		return model.Task{}, errors.New("missing front matter")
+	}
+	header, body, ok := strings.Cut(rest, "\n"+frontMatterDelimiter+"\n")
+	if !ok {
+		header, ok = strings.CutSuffix(rest, "\n"+frontMatterDelimiter)
+		if !ok {
+			return model.Task{}, errors.New("unterminated front matter")
+		}
+	}
+
+	var matter frontMatter
+	if err := yaml.Unmarshal([]byte(header), &matter); err != nil {
+		return model.Task{}, err
+	}
+
+	task := model.Task{
+		Title:       matter.Title,
+		Content:     strings.TrimSuffix(strings.TrimPrefix(body, "\n"), "\n"),
+		Status:      matter.Status,
+		Priority:    matter.Priority,
+		Tags:        model.NormalizeTags(matter.Tags),
+		DueDate:     matter.DueDate,
+		CreatedAt:   matter.CreatedAt,
+		UpdatedAt:   matter.UpdatedAt,
+		CompletedAt: matter.CompletedAt,
+		ExternalId:  matter.ExternalId,
+	}
+	if matter.Id != "" {
+		id, err := uuid.Parse(matter.Id)
+		if err != nil {
+			return model.Task{}, fmt.Errorf("invalid id %q", matter.Id)
+		}
+		task.Id = id
+	}
+	if task.Title == "" {
+		return model.Task{}, errors.New("missing title")
+	}
+	task.SetDefaults()
+	if err := validate.Struct(task); err != nil {
+		return model.Task{}, err
+	}
+
+	return task, nil
+}
+
+func writeFileAtomic(path string, data []byte) (os.FileInfo, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return nil, err
+	}
+	return os.Stat(path)
+}
+
+func utcTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	utc := t.UTC()
+	return &utc
+}
@@ -3,113 +3,122 @@ package store
 import (
 	"errors"
 	"github.com/google/uuid"
-	"math"
+	"iter"
 	"simple-tasks/internal/model"
 	"slices"
 	"sort"
-	"strings"
 	"sync"
+	"time"
 )
 
 var NotFoundError = errors.New("task not found")
 
 type TaskRepository interface {
 	SaveTask(*model.Task)
-	GetTasks(*model.GetTasksRequest) *model.GetTasksResponse
+	GetTasks(*model.GetTasksRequest) (*model.GetTasksResponse, error)
+	IterTasks(*model.GetTasksRequest) (iter.Seq[model.Task], error)
 	GetTaskById(uuid.UUID) (model.Task, error)
+	GetTaskByExternalId(string) (model.Task, error)
 	UpdateTask(*model.Task) error
 	DeleteTask(uuid.UUID) error
+	GetChanges(since uint64, limit int) *model.ChangeSet
+	// Seq returns the sequence number of the latest change.
+	Seq() uint64
+	GetStats(*model.GetTasksRequest, *model.StatsRequest) (*model.TaskStats, error)
+	GetTagCounts() map[string]int
+	// UpdateTasks calls update for every task under a single lock and stores
+	// the tasks it reports as changed, which are returned.
+	UpdateTasks(update func(*model.Task) bool) []model.Task
+}
+
+const maxTombstones = 10000
+
+type tombstone struct {
+	model.Tombstone
+	seq uint64
 }
 
 type InMemoryTaskRepository struct {
 	mu    sync.RWMutex
 	tasks map[uuid.UUID]model.Task
+	// externalIds indexes the tasks that have an external id.
+	externalIds map[string]uuid.UUID
+
+	// seq is incremented on every change; seqs holds the sequence number of
+	// the last change of every task.
+	seq        uint64
+	seqs       map[uuid.UUID]uint64
+	tombstones []tombstone
+	// minSeq is the oldest sequence number changes can be computed from;
+	// it moves forward as tombstones are evicted.
+	minSeq uint64
 }
 
 func NewInMemoryTaskRepository() *InMemoryTaskRepository {
 	return &InMemoryTaskRepository{
-		mu:    sync.RWMutex{},
-		tasks: make(map[uuid.UUID]model.Task),
+		mu:          sync.RWMutex{},
+		tasks:       make(map[uuid.UUID]model.Task),
+		externalIds: make(map[string]uuid.UUID),
+		seqs:        make(map[uuid.UUID]uint64),
 	}
 }
 
 func (r *InMemoryTaskRepository) SaveTask(task *model.Task) {
 	r.mu.Lock()
 	r.tasks[task.Id] = *task
+	r.indexExternalId(nil, task)
+	r.seq++
+	r.seqs[task.Id] = r.seq
 	r.mu.Unlock()
 }
 
-func (r *InMemoryTaskRepository) GetTasks(request *model.GetTasksRequest) *model.GetTasksResponse {
-	slices.Sort(request.Tags)
-	tasks := make([]model.Task, 0)
+func (r *InMemoryTaskRepository) GetTasks(request *model.GetTasksRequest) (*model.GetTasksResponse, error) {
+	order, err := newTaskOrder(request)
+	if err != nil {
+		return nil, err
+	}
 
-	r.mu.RLock()
-	for _, task := range r.tasks {
-		if request.Status != "" && task.Status != request.Status {
-			continue
-		}
+	tasks := r.matching(request)
+	order.sort(tasks)
 
-		if len(request.Tags) > 0 {
-			tagsCopy := make([]string, len(task.Tags))
-			copy(tagsCopy, task.Tags)
-			slices.Sort(tagsCopy)
-			containsTag := slices.ContainsFunc(request.Tags, func(requestTag string) bool {
-				return slices.Contains(tagsCopy, requestTag)
-			})
-			if !containsTag {
-				continue
-			}
-		}
-
-		if request.Q != "" {
-			containsQ := strings.Contains(task.Title, request.Q) ||
-				strings.Contains(task.Content, request.Q)
-			if !containsQ {
-				continue
-			}
-		}
+	return paginate(request, order, tasks)
+}
 
-		tasks = append(tasks, task)
+// IterTasks returns every task matching the request in sort order, ignoring
+// pagination. The matching tasks are copied up front, so the caller can take
+// its time consuming the sequence without holding the repository lock.
+func (r *InMemoryTaskRepository) IterTasks(request *model.GetTasksRequest) (iter.Seq[model.Task], error) {
+	order, err := newTaskOrder(request)
+	if err != nil {
+		return nil, err
 	}
-	r.mu.RUnlock()
 
-	total := len(tasks)
-	var totalPages *int
+	tasks := r.matching(request)
+	order.sort(tasks)
 
-	if request.PageSize != nil && request.Page != nil {
-		pageSize := *request.PageSize
-		page := *request.Page
-		if pageSize != 0 {
-			totalPagesVal := int(math.Ceil(float64(total) / float64(pageSize)))
-			totalPages = &totalPagesVal
-		}
+	return slices.Values(tasks), nil
+}
 
-		start := (page - 1) * pageSize
-		if start > total {
-			start = total
+func (r *InMemoryTaskRepository) matching(request *model.GetTasksRequest) []model.Task {
+	tasks := make([]model.Task, 0)
+	now := time.Now()
+	matches := request.Matcher(now)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, task := range r.tasks {
+		if !matches(&task) {
+			continue
 		}
-		end := start + pageSize
-		if end > total {
-			end = total
+		if request.Filter != nil && !evalFilter(request.Filter, &task, now) {
+			continue
 		}
 
-		tasks = tasks[start:end]
-	}
-
-	switch request.Sort {
-	case model.SortPriority:
-		sort.Slice(tasks, func(i, j int) bool {
-			return tasks[i].Priority < tasks[j].Priority
-		})
+		tasks = append(tasks, task)
 	}
 
-	return &model.GetTasksResponse{
-		Tasks:      tasks,
-		Page:       request.Page,
-		PageSize:   request.PageSize,
-		Total:      total,
-		TotalPages: totalPages,
-	}
+	return tasks
 }
 
 func (r *InMemoryTaskRepository) GetTaskById(id uuid.UUID) (model.Task, error) {
@@ -122,27 +131,162 @@ func (r *InMemoryTaskRepository) GetTaskById(id uuid.UUID) (model.Task, error) {
 	return model.Task{}, NotFoundError
 }
 
+func (r *InMemoryTaskRepository) GetTaskByExternalId(externalId string) (model.Task, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if id, ok := r.externalIds[externalId]; ok {
+		return r.tasks[id], nil
+	}
+
+	return model.Task{}, NotFoundError
+}
+
+// indexExternalId moves the external id index entry from the old version of
+// a task to the new one; either may be nil. The caller must hold the lock.
+func (r *InMemoryTaskRepository) indexExternalId(oldTask *model.Task, newTask *model.Task) {
+	if oldTask != nil && oldTask.ExternalId != "" && r.externalIds[oldTask.ExternalId] == oldTask.Id {
+		delete(r.externalIds, oldTask.ExternalId)
+	}
+	if newTask != nil && newTask.ExternalId != "" {
+		r.externalIds[newTask.ExternalId] = newTask.Id
+	}
+}
+
 func (r *InMemoryTaskRepository) UpdateTask(newTask *model.Task) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if _, ok := r.tasks[newTask.Id]; !ok {
+	oldTask, ok := r.tasks[newTask.Id]
+	if !ok {
 		return NotFoundError
 	}
 
 	r.tasks[newTask.Id] = *newTask
+	r.indexExternalId(&oldTask, newTask)
+	r.seq++
+	r.seqs[newTask.Id] = r.seq
 
 	return nil
 }
 
+func (r *InMemoryTaskRepository) UpdateTasks(update func(*model.Task) bool) []model.Task {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	changed := make([]model.Task, 0)
+	for id, task := range r.tasks {
+		oldTask := task
+		if !update(&task) {
+			continue
+		}
+
+		r.tasks[id] = task
+		r.indexExternalId(&oldTask, &task)
+		r.seq++
+		r.seqs[id] = r.seq
+		changed = append(changed, task)
+	}
+
+	return changed
+}
+
+func (r *InMemoryTaskRepository) GetTagCounts() map[string]int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	counts := make(map[string]int)
+	for _, task := range r.tasks {
+		for _, tag := range task.Tags {
+			counts[tag]++
+		}
+	}
+
+	return counts
+}
+
 func (r *InMemoryTaskRepository) DeleteTask(id uuid.UUID) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	if _, ok := r.tasks[id]; !ok {
+	task, ok := r.tasks[id]
+	if !ok {
 		return NotFoundError
 	}
 
 	delete(r.tasks, id)
+	r.indexExternalId(&task, nil)
+	delete(r.seqs, id)
+	r.seq++
+	r.tombstones = append(r.tombstones, tombstone{
+		Tombstone: model.Tombstone{Id: id, DeletedAt: time.Now()},
+		seq:       r.seq,
+	})
+	if len(r.tombstones) > maxTombstones {
+		r.minSeq = r.tombstones[0].seq
+		r.tombstones = r.tombstones[1:]
+	}
 
 	return nil
 }
+
+func (r *InMemoryTaskRepository) Seq() uint64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.seq
+}
+
+func (r *InMemoryTaskRepository) GetChanges(since uint64, limit int) *model.ChangeSet {
+	type change struct {
+		seq       uint64
+		task      *model.Task
+		tombstone *model.Tombstone
+	}
+
+	changeSet := &model.ChangeSet{
+		Tasks:      make([]model.Task, 0),
+		Tombstones: make([]model.Tombstone, 0),
+	}
+
+	r.mu.RLock()
+	if since < r.minSeq || since > r.seq {
+		changeSet.Expired = true
+		since = 0
+	}
+
+	changes := make([]change, 0)
+	for id, seq := range r.seqs {
+		if seq > since {
+			task := r.tasks[id]
+			changes = append(changes, change{seq: seq, task: &task})
+		}
+	}
+	if since > 0 {
+		for i := range r.tombstones {
+			if r.tombstones[i].seq > since {
+				changes = append(changes, change{seq: r.tombstones[i].seq, tombstone: &r.tombstones[i].Tombstone})
+			}
+		}
+	}
+	changeSet.Seq = r.seq
+	r.mu.RUnlock()
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].seq < changes[j].seq
+	})
+
+	if limit > 0 && len(changes) > limit {
+		changes = changes[:limit]
+		changeSet.Seq = changes[limit-1].seq
+		changeSet.HasMore = true
+	}
+
+	for _, c := range changes {
+		if c.task != nil {
+			changeSet.Tasks = append(changeSet.Tasks, *c.task)
+		} else {
+			changeSet.Tombstones = append(changeSet.Tombstones, *c.tombstone)
+		}
+	}
+
+	return changeSet
+}
@@ -0,0 +1,226 @@
+package store
+
+import (
+	"cmp"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+	"math"
+	"simple-tasks/internal/model"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+var InvalidCursorError = errors.New("invalid cursor")
+
+const defaultCursorPageSize = 20
+
+// cursor marks a position in the ordered task list. It holds the sort key
+// values of the boundary task rather than an offset, so pages stay stable
+// while tasks are added or removed.
+type cursor struct {
+	Backward bool `json:"b,omitempty"`
+	// Sort is the sort the cursor was issued for; a cursor can't be reused
+	// with a different order.
+	Sort      string     `json:"s,omitempty"`
+	Id        uuid.UUID  `json:"id"`
+	CreatedAt time.Time  `json:"c"`
+	UpdatedAt time.Time  `json:"u"`
+	DueDate   *time.Time `json:"d,omitempty"`
+	Priority  string     `json:"p,omitempty"`
+	Status    string     `json:"st,omitempty"`
+	Title     string     `json:"t,omitempty"`
+}
+
+func encodeCursor(request *model.GetTasksRequest, order *taskOrder, task *model.Task, backward bool) string {
+	c := cursor{
+		Backward:  backward,
+		Sort:      request.Sort,
+		Id:        task.Id,
+		CreatedAt: task.CreatedAt,
+		UpdatedAt: task.UpdatedAt,
+		DueDate:   task.DueDate,
+		Priority:  task.Priority,
+		Status:    task.Status,
+	}
+	if order.has(model.SortFieldTitle) {
+		c.Title = task.Title
+	}
+
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(request *model.GetTasksRequest) (*cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(request.Cursor)
+	if err != nil {
+		return nil, InvalidCursorError
+	}
+
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, InvalidCursorError
+	}
+	if c.Sort != request.Sort {
+		return nil, InvalidCursorError
+	}
+
+	return &c, nil
+}
+
+func (c *cursor) boundary() *model.Task {
+	return &model.Task{
+		Id:        c.Id,
+		CreatedAt: c.CreatedAt,
+		UpdatedAt: c.UpdatedAt,
+		DueDate:   c.DueDate,
+		Priority:  c.Priority,
+		Status:    c.Status,
+		Title:     c.Title,
+	}
+}
+
+// taskOrder is the total order of a task list: the requested sort keys, then
+// creation time and id as tie-breakers.
+type taskOrder struct {
+	keys     []model.SortKey
+	collator *collate.Collator
+}
+
+func newTaskOrder(request *model.GetTasksRequest) (*taskOrder, error) {
+	keys, err := model.ParseSort(request.Sort)
+	if err != nil {
+		return nil, err
+	}
+
+	order := &taskOrder{keys: keys}
+	if order.has(model.SortFieldTitle) {
+		// The Russian tailoring orders Latin before Cyrillic and handles
+		// both alphabets, which covers our English and Russian titles.
+		order.collator = collate.New(language.Russian)
+	}
+
+	return order, nil
+}
+
+func (o *taskOrder) has(field string) bool {
+	return slices.ContainsFunc(o.keys, func(key model.SortKey) bool {
+		return key.Field == field
+	})
+}
+
+func (o *taskOrder) compare(a *model.Task, b *model.Task) int {
+	for _, key := range o.keys {
+		var c int
+		switch key.Field {
+		case model.SortFieldPriority:
+			c = cmp.Compare(model.PriorityRank(a.Priority), model.PriorityRank(b.Priority))
+		case model.SortFieldStatus:
+			c = cmp.Compare(model.StatusRank(a.Status), model.StatusRank(b.Status))
+		case model.SortFieldCreatedAt:
+			c = a.CreatedAt.Compare(b.CreatedAt)
+		case model.SortFieldUpdatedAt:
+			c = a.UpdatedAt.Compare(b.UpdatedAt)
+		case model.SortFieldTitle:
+			c = o.collator.CompareString(a.Title, b.Title)
+		case model.SortFieldDueDate:
+			// Tasks without a due date go last in both directions.
+			switch {
+			case a.DueDate == nil && b.DueDate == nil:
+				c = 0
+			case a.DueDate == nil:
+				return 1
+			case b.DueDate == nil:
+				return -1
+			default:
+				c = a.DueDate.Compare(*b.DueDate)
+			}
+		}
+
+		if key.Desc {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+
+	if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+		return c
+	}
+	return strings.Compare(a.Id.String(), b.Id.String())
+}
+
+func (o *taskOrder) sort(tasks []model.Task) {
+	slices.SortStableFunc(tasks, func(a, b model.Task) int {
+		return o.compare(&a, &b)
+	})
+}
+
+// paginate cuts the requested page out of the ordered task list. Page mode is
+// used when both page and pageSize are set; cursor mode when a cursor is set
+// or only pageSize is given.
+func paginate(request *model.GetTasksRequest, order *taskOrder, tasks []model.Task) (*model.GetTasksResponse, error) {
+	response := &model.GetTasksResponse{
+		Tasks:    tasks,
+		Page:     request.Page,
+		PageSize: request.PageSize,
+		Total:    len(tasks),
+	}
+
+	switch {
+	case request.Cursor == "" && request.Page != nil && request.PageSize != nil:
+		pageSize := *request.PageSize
+		page := *request.Page
+		totalPages := int(math.Ceil(float64(len(tasks)) / float64(pageSize)))
+		response.TotalPages = &totalPages
+
+		start := min((page-1)*pageSize, len(tasks))
+		end := min(start+pageSize, len(tasks))
+		response.Tasks = tasks[start:end]
+
+	case request.Cursor != "" || request.PageSize != nil:
+		pageSize := defaultCursorPageSize
+		if request.PageSize != nil {
+			pageSize = *request.PageSize
+		}
+		response.Page = nil
+		response.PageSize = &pageSize
+
+		start, end := 0, min(pageSize, len(tasks))
+		if request.Cursor != "" {
+			c, err := decodeCursor(request)
+			if err != nil {
+				return nil, err
+			}
+
+			boundary := c.boundary()
+			if c.Backward {
+				end = sort.Search(len(tasks), func(i int) bool {
+					return order.compare(&tasks[i], boundary) >= 0
+				})
+				start = max(end-pageSize, 0)
+			} else {
+				start = sort.Search(len(tasks), func(i int) bool {
+					return order.compare(&tasks[i], boundary) > 0
+				})
+				end = min(start+pageSize, len(tasks))
+			}
+		}
+
+		response.Tasks = tasks[start:end]
+		if end < len(tasks) && end > start {
+			response.NextCursor = encodeCursor(request, order, &tasks[end-1], false)
+		}
+		if start > 0 && end > start {
+			response.PrevCursor = encodeCursor(request, order, &tasks[start], true)
+		}
+	}
+
+	return response, nil
+}
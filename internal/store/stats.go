@@ -0,0 +1,78 @@
+package store
+
+import (
+	"simple-tasks/internal/model"
+	"time"
+)
+
+// GetStats aggregates the tasks matching the request in a single pass,
+// without copying or sorting them.
+func (r *InMemoryTaskRepository) GetStats(request *model.GetTasksRequest, statsRequest *model.StatsRequest) (*model.TaskStats, error) {
+	now := time.Now()
+	from, to := statsRequest.Range(now)
+	matches := request.Matcher(now)
+
+	days := int(to.Sub(from).Hours() / 24)
+	stats := &model.TaskStats{
+		Daily: make([]model.DailyStats, days),
+	}
+	for i := range stats.Daily {
+		stats.Daily[i].Date = from.AddDate(0, 0, i).Format(time.DateOnly)
+	}
+	if len(statsRequest.GroupBy) > 0 {
+		stats.Groups = make(map[string]map[string]int, len(statsRequest.GroupBy))
+		for _, groupBy := range statsRequest.GroupBy {
+			stats.Groups[groupBy] = make(map[string]int)
+		}
+	}
+
+	day := func(t time.Time) (int, bool) {
+		if t.Before(from) || !t.Before(to) {
+			return 0, false
+		}
+		return int(t.Sub(from).Hours() / 24), true
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, task := range r.tasks {
+		if !matches(&task) {
+			continue
+		}
+		if request.Filter != nil && !evalFilter(request.Filter, &task, now) {
+			continue
+		}
+
+		stats.Total++
+		if task.IsOverdue(now) {
+			stats.Overdue++
+		}
+
+		for groupBy, counts := range stats.Groups {
+			switch groupBy {
+			case model.GroupByStatus:
+				counts[task.Status]++
+			case model.GroupByPriority:
+				counts[task.Priority]++
+			case model.GroupByTag:
+				for _, tag := range task.Tags {
+					counts[tag]++
+				}
+			case model.GroupByDueWeek:
+				counts[model.DueWeek(task.DueDate)]++
+			}
+		}
+
+		if i, ok := day(task.CreatedAt); ok {
+			stats.Daily[i].Created++
+		}
+		if task.CompletedAt != nil {
+			if i, ok := day(*task.CompletedAt); ok {
+				stats.Daily[i].Completed++
+			}
+		}
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,69 @@
+package store
+
+import (
+	"errors"
+	"simple-tasks/internal/model"
+	"sort"
+	"sync"
+)
+
+var TagNotFoundError = errors.New("tag not found")
+
+// TagRepository stores tag metadata. Tag usage lives in the tasks themselves.
+type TagRepository interface {
+	SaveTag(*model.Tag)
+	GetTags() []model.Tag
+	GetTag(string) (model.Tag, error)
+	DeleteTag(string)
+}
+
+type InMemoryTagRepository struct {
+	mu   sync.RWMutex
+	tags map[string]model.Tag
+}
+
+func NewInMemoryTagRepository() *InMemoryTagRepository {
+	return &InMemoryTagRepository{
+		mu:   sync.RWMutex{},
+		tags: make(map[string]model.Tag),
+	}
+}
+
+func (r *InMemoryTagRepository) SaveTag(tag *model.Tag) {
+	r.mu.Lock()
+	saved := *tag
+	saved.Count = 0
+	r.tags[tag.Name] = saved
+	r.mu.Unlock()
+}
+
+func (r *InMemoryTagRepository) GetTags() []model.Tag {
+	r.mu.RLock()
+	tags := make([]model.Tag, 0, len(r.tags))
+	for _, tag := range r.tags {
+		tags = append(tags, tag)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(tags, func(i, j int) bool {
+		return tags[i].Name < tags[j].Name
+	})
+
+	return tags
+}
+
+func (r *InMemoryTagRepository) GetTag(name string) (model.Tag, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if tag, ok := r.tags[name]; ok {
+		return tag, nil
+	}
+
+	return model.Tag{}, TagNotFoundError
+}
+
+func (r *InMemoryTagRepository) DeleteTag(name string) {
+	r.mu.Lock()
+	delete(r.tags, name)
+	r.mu.Unlock()
+}
@@ -0,0 +1,87 @@
+package store
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"simple-tasks/internal/model"
+	"sort"
+	"sync"
+)
+
+var ViewNotFoundError = errors.New("view not found")
+
+type ViewRepository interface {
+	SaveView(*model.View)
+	GetViews() []model.View
+	GetViewById(uuid.UUID) (model.View, error)
+	UpdateView(*model.View) error
+	DeleteView(uuid.UUID) error
+}
+
+type InMemoryViewRepository struct {
+	mu    sync.RWMutex
+	views map[uuid.UUID]model.View
+}
+
+func NewInMemoryViewRepository() *InMemoryViewRepository {
+	return &InMemoryViewRepository{
+		mu:    sync.RWMutex{},
+		views: make(map[uuid.UUID]model.View),
+	}
+}
+
+func (r *InMemoryViewRepository) SaveView(view *model.View) {
+	r.mu.Lock()
+	r.views[view.Id] = *view
+	r.mu.Unlock()
+}
+
+func (r *InMemoryViewRepository) GetViews() []model.View {
+	r.mu.RLock()
+	views := make([]model.View, 0, len(r.views))
+	for _, view := range r.views {
+		views = append(views, view)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(views, func(i, j int) bool {
+		return views[i].CreatedAt.Before(views[j].CreatedAt)
+	})
+
+	return views
+}
+
+func (r *InMemoryViewRepository) GetViewById(id uuid.UUID) (model.View, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if view, ok := r.views[id]; ok {
+		return view, nil
+	}
+
+	return model.View{}, ViewNotFoundError
+}
+
+func (r *InMemoryViewRepository) UpdateView(view *model.View) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.views[view.Id]; !ok {
+		return ViewNotFoundError
+	}
+
+	r.views[view.Id] = *view
+
+	return nil
+}
+
+func (r *InMemoryViewRepository) DeleteView(id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.views[id]; !ok {
+		return ViewNotFoundError
+	}
+
+	delete(r.views, id)
+
+	return nil
+}
@@ -0,0 +1,121 @@
+package store
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"simple-tasks/internal/model"
+	"sort"
+	"sync"
+)
+
+var WebhookNotFoundError = errors.New("webhook not found")
+
+const maxDeliveriesPerWebhook = 100
+
+type WebhookRepository interface {
+	SaveWebhook(*model.Webhook)
+	GetWebhooks() []model.Webhook
+	GetWebhookById(uuid.UUID) (model.Webhook, error)
+	UpdateWebhook(*model.Webhook) error
+	DeleteWebhook(uuid.UUID) error
+	AddDelivery(*model.WebhookDelivery)
+	GetDeliveries(uuid.UUID) ([]model.WebhookDelivery, error)
+}
+
+type InMemoryWebhookRepository struct {
+	mu         sync.RWMutex
+	webhooks   map[uuid.UUID]model.Webhook
+	deliveries map[uuid.UUID][]model.WebhookDelivery
+}
+
+func NewInMemoryWebhookRepository() *InMemoryWebhookRepository {
+	return &InMemoryWebhookRepository{
+		mu:         sync.RWMutex{},
+		webhooks:   make(map[uuid.UUID]model.Webhook),
+		deliveries: make(map[uuid.UUID][]model.WebhookDelivery),
+	}
+}
+
+func (r *InMemoryWebhookRepository) SaveWebhook(webhook *model.Webhook) {
+	r.mu.Lock()
+	r.webhooks[webhook.Id] = *webhook
+	r.mu.Unlock()
+}
+
+func (r *InMemoryWebhookRepository) GetWebhooks() []model.Webhook {
+	r.mu.RLock()
+	webhooks := make([]model.Webhook, 0, len(r.webhooks))
+	for _, webhook := range r.webhooks {
+		webhooks = append(webhooks, webhook)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(webhooks, func(i, j int) bool {
+		return webhooks[i].CreatedAt.Before(webhooks[j].CreatedAt)
+	})
+
+	return webhooks
+}
+
+func (r *InMemoryWebhookRepository) GetWebhookById(id uuid.UUID) (model.Webhook, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if webhook, ok := r.webhooks[id]; ok {
+		return webhook, nil
+	}
+
+	return model.Webhook{}, WebhookNotFoundError
+}
+
+func (r *InMemoryWebhookRepository) UpdateWebhook(webhook *model.Webhook) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.webhooks[webhook.Id]; !ok {
+		return WebhookNotFoundError
+	}
+
+	r.webhooks[webhook.Id] = *webhook
+
+	return nil
+}
+
+func (r *InMemoryWebhookRepository) DeleteWebhook(id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.webhooks[id]; !ok {
+		return WebhookNotFoundError
+	}
+
+	delete(r.webhooks, id)
+	delete(r.deliveries, id)
+
+	return nil
+}
+
+func (r *InMemoryWebhookRepository) AddDelivery(delivery *model.WebhookDelivery) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.webhooks[delivery.WebhookId]; !ok {
+		return
+	}
+
+	deliveries := append(r.deliveries[delivery.WebhookId], *delivery)
+	if len(deliveries) > maxDeliveriesPerWebhook {
+		deliveries = deliveries[len(deliveries)-maxDeliveriesPerWebhook:]
+	}
+	r.deliveries[delivery.WebhookId] = deliveries
+}
+
+func (r *InMemoryWebhookRepository) GetDeliveries(webhookId uuid.UUID) ([]model.WebhookDelivery, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if _, ok := r.webhooks[webhookId]; !ok {
+		return nil, WebhookNotFoundError
+	}
+
+	deliveries := make([]model.WebhookDelivery, len(r.deliveries[webhookId]))
+	copy(deliveries, r.deliveries[webhookId])
+
+	return deliveries, nil
+}
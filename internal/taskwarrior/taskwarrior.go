@@ -0,0 +1,262 @@
+// Package taskwarrior converts tasks to and from the JSON objects of
+// Taskwarrior's `task export` and `task import`
+// (https://taskwarrior.org/docs/design/task/).
+//
+// Descriptions are titles and annotations the lines of the content. Pending
+// tasks are todo, or in progress once started; completed tasks are done.
+// Priorities H and M are high and normal; L and no priority are low, which
+// is exported without one. The project becomes a tag starting with
+// "project:", which is turned back into the project on export; like every
+// tag it is lowercased.
+//
+// Tasks have no place for some attributes, so a round trip loses them: the
+// start time, the time of annotations, the line breaks within annotations,
+// the difference between L and no priority, the case of the project and
+// tags, and projects too long for a tag. Task.Losses lists them for a task.
+package taskwarrior
+
+import (
+	json2 "encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"simple-tasks/internal/model"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	StatusPending   = "pending"
+	StatusCompleted = "completed"
+	StatusDeleted   = "deleted"
+	StatusWaiting   = "waiting"
+	StatusRecurring = "recurring"
+
+	// ProjectTagPrefix marks the tag holding the project of a task.
+	ProjectTagPrefix = "project:"
+
+	timeLayout = "20060102T150405Z"
+
+	// maxProjectLength is the longest project that fits a tag, which is
+	// limited to 32 characters.
+	maxProjectLength = 32 - len(ProjectTagPrefix)
+)
+
+var priorities = map[string]string{
+	model.PriorityHigh:   "H",
+	model.PriorityNormal: "M",
+}
+
+// Time is a timestamp in Taskwarrior's compact ISO 8601 form.
+type Time struct {
+	time.Time
+}
+
+func NewTime(t time.Time) *Time {
+	return &Time{Time: t.UTC().Truncate(time.Second)}
+}
+
+func (t Time) MarshalJSON() ([]byte, error) {
+	return json2.Marshal(t.UTC().Format(timeLayout))
+}
+
+// UnmarshalJSON also accepts RFC 3339, which older versions exported.
+func (t *Time) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json2.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	parsed, err := time.Parse(timeLayout, value)
+	if err != nil {
+		if parsed, err = time.Parse(time.RFC3339, value); err != nil {
+			return fmt.Errorf("invalid date %q, expected YYYYMMDDTHHMMSSZ", value)
+		}
+	}
+	t.Time = parsed.UTC()
+	return nil
+}
+
+type Annotation struct {
+	Entry       *Time  `json:"entry,omitempty"`
+	Description string `json:"description"`
+}
+
+// Task is a Taskwarrior task. Attributes without a task field, such as
+// urgency or user defined attributes, are ignored.
+type Task struct {
+	Uuid        string       `json:"uuid"`
+	Description string       `json:"description"`
+	Status      string       `json:"status"`
+	Priority    string       `json:"priority,omitempty"`
+	Project     string       `json:"project,omitempty"`
+	Tags        []string     `json:"tags,omitempty"`
+	Annotations []Annotation `json:"annotations,omitempty"`
+	Due         *Time        `json:"due,omitempty"`
+	Entry       *Time        `json:"entry,omitempty"`
+	Modified    *Time        `json:"modified,omitempty"`
+	Start       *Time        `json:"start,omitempty"`
+	End         *Time        `json:"end,omitempty"`
+}
+
+// AttributeError is an attribute that can't be mapped to a task field.
+type AttributeError struct {
+	Attribute string
+	Msg       string
+}
+
+func (e *AttributeError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Attribute, e.Msg)
+}
+
+// Loss is an attribute that a task keeps only in part, or not at all.
+type Loss struct {
+	Attribute string
+	Msg       string
+}
+
+// Losses lists the attributes that converting the task with ToTask loses, so
+// that imports can report them. Times equal to the modification time are not
+// lost, since exports use it for them.
+func (tw *Task) Losses() []Loss {
+	var losses []Loss
+	if tw.Start != nil && !tw.Start.Equal(tw.modified()) {
+		losses = append(losses, Loss{Attribute: "start", Msg: "the start time is not kept, exports use the modification time"})
+	}
+	if tw.Priority == "L" {
+		losses = append(losses, Loss{Attribute: "priority", Msg: "priority L is imported as low, which is exported without a priority"})
+	}
+	if utf8.RuneCountInString(tw.Project) > maxProjectLength {
+		losses = append(losses, Loss{Attribute: "project", Msg: fmt.Sprintf("projects over %d characters are not kept", maxProjectLength)})
+	} else if tw.Project != model.NormalizeTag(tw.Project) {
+		losses = append(losses, Loss{Attribute: "project", Msg: "the project is lowercased"})
+	}
+	if slices.ContainsFunc(tw.Tags, func(tag string) bool { return tag != model.NormalizeTag(tag) }) {
+		losses = append(losses, Loss{Attribute: "tags", Msg: "tags are lowercased"})
+	}
+	if slices.ContainsFunc(tw.Annotations, func(a Annotation) bool { return a.Entry != nil && !a.Entry.Equal(tw.modified()) }) {
+		losses = append(losses, Loss{Attribute: "annotations", Msg: "annotation times are not kept, exports use the modification time"})
+	}
+	if slices.ContainsFunc(tw.Annotations, func(a Annotation) bool { return strings.Contains(strings.TrimSpace(a.Description), "\n") }) {
+		losses = append(losses, Loss{Attribute: "annotations", Msg: "annotations with several lines are exported as an annotation per line"})
+	}
+	return losses
+}
+
+func (tw *Task) modified() time.Time {
+	if tw.Modified == nil {
+		return time.Time{}
+	}
+	return tw.Modified.Time
+}
+
+// FromTask converts a task for export. Its uuid is the export id when that
+// is a UUID, so tasks imported from Taskwarrior keep theirs, and the task id
+// otherwise.
+func FromTask(task *model.Task) Task {
+	id := task.ExportId()
+	if _, err := uuid.Parse(id); err != nil {
+		id = task.Id.String()
+	}
+
+	tw := Task{
+		Uuid:        id,
+		Description: task.Title,
+		Status:      StatusPending,
+		Priority:    priorities[task.Priority],
+		Entry:       NewTime(task.CreatedAt),
+		Modified:    NewTime(task.UpdatedAt),
+	}
+
+	switch task.Status {
+	case model.StatusInProgress:
+		tw.Start = NewTime(task.UpdatedAt)
+	case model.StatusDone:
+		tw.Status = StatusCompleted
+		if task.CompletedAt != nil {
+			tw.End = NewTime(*task.CompletedAt)
+		}
+	}
+
+	for _, tag := range task.Tags {
+		if project, ok := strings.CutPrefix(tag, ProjectTagPrefix); ok && tw.Project == "" {
+			tw.Project = project
+		} else {
+			tw.Tags = append(tw.Tags, tag)
+		}
+	}
+
+	if task.Content != "" {
+		for _, line := range strings.Split(task.Content, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				tw.Annotations = append(tw.Annotations, Annotation{Entry: tw.Modified, Description: line})
+			}
+		}
+	}
+
+	if task.DueDate != nil {
+		tw.Due = NewTime(*task.DueDate)
+	}
+
+	return tw
+}
+
+// ToTask converts an imported Taskwarrior task. The uuid becomes the external
+// id, so importing an export again updates the same tasks. Deleted tasks are
+// converted like pending ones; callers are expected to skip them.
+func (tw *Task) ToTask() (model.Task, error) {
+	task := model.Task{
+		ExternalId: tw.Uuid,
+		Title:      tw.Description,
+	}
+
+	switch tw.Status {
+	case StatusPending, StatusWaiting, StatusRecurring, StatusDeleted, "":
+		task.Status = model.StatusTodo
+		if tw.Start != nil {
+			task.Status = model.StatusInProgress
+		}
+	case StatusCompleted:
+		task.Status = model.StatusDone
+	default:
+		return task, &AttributeError{Attribute: "status", Msg: fmt.Sprintf("unknown status %q", tw.Status)}
+	}
+
+	switch tw.Priority {
+	case "H":
+		task.Priority = model.PriorityHigh
+	case "M":
+		task.Priority = model.PriorityNormal
+	case "L", "":
+		task.Priority = model.PriorityLow
+	default:
+		return task, &AttributeError{Attribute: "priority", Msg: fmt.Sprintf("invalid priority %q, expected H, M or L", tw.Priority)}
+	}
+
+	task.Tags = append(task.Tags, tw.Tags...)
+	if tw.Project != "" && utf8.RuneCountInString(tw.Project) <= maxProjectLength {
+		task.Tags = append(task.Tags, ProjectTagPrefix+tw.Project)
+	}
+
+	lines := make([]string, 0, len(tw.Annotations))
+	for _, annotation := range tw.Annotations {
+		lines = append(lines, annotation.Description)
+	}
+	task.Content = strings.Join(lines, "\n")
+
+	if tw.Due != nil {
+		task.DueDate = &tw.Due.Time
+	}
+	if tw.Entry != nil {
+		task.CreatedAt = tw.Entry.Time
+	}
+	if tw.Modified != nil {
+		task.UpdatedAt = tw.Modified.Time
+	}
+	if tw.End != nil && task.Status == model.StatusDone {
+		task.CompletedAt = &tw.End.Time
+	}
+
+	return task, nil
+}
@@ -0,0 +1,85 @@
+package todotxt
+
+import (
+	"maps"
+	"slices"
+)
+
+// Entry is a line of the reconciled file.
+type Entry struct {
+	Line string
+	// Push marks lines that are new or changed locally and have to be sent
+	// to the server; they are replaced by the server's version afterwards.
+	Push bool
+}
+
+type Plan struct {
+	Entries []Entry
+	// Deletes lists the ids of server tasks that were removed locally.
+	Deletes []string
+	// Conflicts lists the ids of tasks changed on both sides; the local
+	// version wins.
+	Conflicts []string
+}
+
+// Reconcile merges a local todo.txt file with the server's tasks, using the
+// lines of the last sync as the common base. All maps are keyed by id.
+//
+// A line changed locally is pushed; otherwise the server's version is taken.
+// Deleting a line deletes the task unless it changed on the server since,
+// and a task deleted on the server is removed from the file unless the line
+// changed locally. New tasks on either side are added to the other.
+func Reconcile(local []string, base map[string]string, remote map[string]string) *Plan {
+	plan := &Plan{
+		Entries:   make([]Entry, 0, len(local)),
+		Deletes:   make([]string, 0),
+		Conflicts: make([]string, 0),
+	}
+	seen := make(map[string]bool)
+
+	for _, line := range local {
+		id := Id(line)
+		if id == "" || seen[id] {
+			plan.Entries = append(plan.Entries, Entry{Line: line, Push: true})
+			continue
+		}
+		seen[id] = true
+
+		baseLine, synced := base[id]
+		remoteLine, exists := remote[id]
+		changed := !synced || line != baseLine
+
+		switch {
+		case changed:
+			if exists && synced && remoteLine != baseLine {
+				plan.Conflicts = append(plan.Conflicts, id)
+			}
+			plan.Entries = append(plan.Entries, Entry{Line: line, Push: true})
+		case exists:
+			plan.Entries = append(plan.Entries, Entry{Line: remoteLine})
+		}
+	}
+
+	for _, id := range slices.Sorted(maps.Keys(base)) {
+		baseLine := base[id]
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if remoteLine, exists := remote[id]; exists {
+			if remoteLine == baseLine {
+				plan.Deletes = append(plan.Deletes, id)
+			} else {
+				plan.Entries = append(plan.Entries, Entry{Line: remoteLine})
+			}
+		}
+	}
+
+	for _, id := range slices.Sorted(maps.Keys(remote)) {
+		if !seen[id] {
+			plan.Entries = append(plan.Entries, Entry{Line: remote[id]})
+		}
+	}
+
+	return plan
+}
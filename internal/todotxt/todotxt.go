@@ -0,0 +1,237 @@
+// Package todotxt converts tasks to and from todo.txt lines
+// (http://todotxt.org), for example
+//
+//	x 2025-10-02 2025-10-01 Call mom +family @phone due:2025-10-03 id:3f2c...
+//
+// Priorities A and B are high and normal, anything else is low. Projects
+// become tags and contexts tags starting with "@". Besides due:, the keys
+// id: (the task's export id), pri: (the priority of a completed task) and
+// status: (for tasks in progress) are understood; other key:value pairs stay
+// in the title.
+//
+// Title words that would read as tags, keys or the leading completion mark,
+// priority and dates are escaped with a backslash, as in "Reply to \+1
+// votes", and whitespace and "%" in tags are percent-encoded, as in
+// "+my%20tag", so that lines parse back to the same task.
+package todotxt
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"net/url"
+	"simple-tasks/internal/model"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	keyDue      = "due"
+	keyId       = "id"
+	keyPriority = "pri"
+	keyStatus   = "status"
+)
+
+var priorities = map[string]string{
+	model.PriorityHigh:   "A",
+	model.PriorityNormal: "B",
+}
+
+// TokenError is a token of a line that can't be mapped to a task field.
+type TokenError struct {
+	Key string
+	Msg string
+}
+
+func (e *TokenError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Key, e.Msg)
+}
+
+// Format renders a task as a single todo.txt line.
+func Format(task *model.Task) string {
+	words := make([]string, 0, 8)
+
+	priority := priorities[task.Priority]
+	if task.Status == model.StatusDone {
+		words = append(words, "x")
+		if task.CompletedAt != nil {
+			words = append(words, task.CompletedAt.UTC().Format(time.DateOnly))
+		}
+	} else if priority != "" {
+		words = append(words, "("+priority+")")
+	}
+	if !task.CreatedAt.IsZero() {
+		words = append(words, task.CreatedAt.UTC().Format(time.DateOnly))
+	}
+
+	// The line is the only separator between tasks.
+	for i, word := range strings.Fields(task.Title) {
+		words = append(words, escapeWord(word, i == 0))
+	}
+
+	for _, tag := range task.Tags {
+		if strings.HasPrefix(tag, "@") {
+			words = append(words, encodeTag(tag))
+		} else {
+			words = append(words, "+"+encodeTag(tag))
+		}
+	}
+
+	if task.DueDate != nil {
+		words = append(words, keyDue+":"+task.DueDate.UTC().Format(time.DateOnly))
+	}
+	if task.Status == model.StatusDone && priority != "" {
+		words = append(words, keyPriority+":"+priority)
+	}
+	if task.Status == model.StatusInProgress {
+		words = append(words, keyStatus+":"+task.Status)
+	}
+	if task.Id != uuid.Nil || task.ExternalId != "" {
+		words = append(words, keyId+":"+task.ExportId())
+	}
+
+	return strings.Join(words, " ")
+}
+
+// Parse reads a todo.txt line. The id: key becomes the external id, so
+// importing an exported file updates the same tasks. Completion and
+// creation dates are managed by the server and ignored.
+func Parse(line string) (model.Task, error) {
+	task := model.Task{Status: model.StatusTodo}
+	words := strings.Fields(line)
+
+	if len(words) > 0 && words[0] == "x" {
+		task.Status = model.StatusDone
+		words = words[1:]
+		// Completion date, then creation date.
+		for range 2 {
+			if len(words) > 0 && isDate(words[0]) {
+				words = words[1:]
+			}
+		}
+	} else {
+		if len(words) > 0 && isPriority(words[0]) {
+			task.Priority = priority(words[0][1:2])
+			words = words[1:]
+		}
+		if len(words) > 0 && isDate(words[0]) {
+			words = words[1:]
+		}
+	}
+
+	title := make([]string, 0, len(words))
+	for _, word := range words {
+		switch {
+		case word[0] == '\\':
+			title = append(title, word[1:])
+		case len(word) > 1 && word[0] == '+':
+			task.Tags = append(task.Tags, decodeTag(word[1:]))
+		case len(word) > 1 && word[0] == '@':
+			task.Tags = append(task.Tags, decodeTag(word))
+		default:
+			key, value, ok := strings.Cut(word, ":")
+			if !ok || value == "" {
+				title = append(title, word)
+				continue
+			}
+
+			switch key {
+			case keyDue:
+				due, err := model.ParseDate(value)
+				if err != nil {
+					return task, &TokenError{Key: key, Msg: fmt.Sprintf("invalid date %q, expected YYYY-MM-DD", value)}
+				}
+				task.DueDate = &due
+			case keyId:
+				task.ExternalId = value
+			case keyPriority:
+				if len(value) != 1 || value[0] < 'A' || value[0] > 'Z' {
+					return task, &TokenError{Key: key, Msg: fmt.Sprintf("invalid priority %q, expected A-Z", value)}
+				}
+				task.Priority = priority(value)
+			case keyStatus:
+				if task.Status != model.StatusDone {
+					task.Status = value
+				}
+			default:
+				title = append(title, word)
+			}
+		}
+	}
+	task.Title = strings.Join(title, " ")
+
+	return task, nil
+}
+
+// Id returns the value of the id: key of a line, or "".
+func Id(line string) string {
+	for _, word := range strings.Fields(line) {
+		if value, ok := strings.CutPrefix(word, keyId+":"); ok {
+			return value
+		}
+	}
+	return ""
+}
+
+// escapeWord escapes a title word that Parse would not read as title: tags,
+// known keys, words escaped themselves and, as the first word, anything that
+// reads as the completion mark, a priority or a date.
+func escapeWord(word string, first bool) string {
+	key, value, isKey := strings.Cut(word, ":")
+	switch {
+	case word[0] == '\\',
+		len(word) > 1 && (word[0] == '+' || word[0] == '@'),
+		isKey && value != "" && (key == keyDue || key == keyId || key == keyPriority || key == keyStatus),
+		first && (word == "x" || isPriority(word) || isDate(word)):
+		return "\\" + word
+	}
+	return word
+}
+
+// encodeTag percent-encodes the whitespace of a tag, which would split it,
+// and "%" itself.
+func encodeTag(tag string) string {
+	var b strings.Builder
+	for _, r := range tag {
+		if r == '%' || unicode.IsSpace(r) {
+			for _, c := range []byte(string(r)) {
+				fmt.Fprintf(&b, "%%%02X", c)
+			}
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// decodeTag reverses encodeTag. Tags of other tools that aren't valid
+// percent-encoding, such as "+50%", are kept as they are.
+func decodeTag(tag string) string {
+	if !strings.Contains(tag, "%") {
+		return tag
+	}
+	decoded, err := url.PathUnescape(tag)
+	if err != nil {
+		return tag
+	}
+	return decoded
+}
+
+func priority(letter string) string {
+	switch letter {
+	case "A":
+		return model.PriorityHigh
+	case "B":
+		return model.PriorityNormal
+	}
+	return model.PriorityLow
+}
+
+func isPriority(word string) bool {
+	return len(word) == 3 && word[0] == '(' && word[1] >= 'A' && word[1] <= 'Z' && word[2] == ')'
+}
+
+func isDate(word string) bool {
+	_, err := time.Parse(time.DateOnly, word)
+	return err == nil
+}
@@ -0,0 +1,321 @@
+// Package xlsx writes minimal Office Open XML workbooks (.xlsx): sheets of
+// text, number and date cells with a styled, filterable header row. Strings
+// are stored inline, so there is no shared string table.
+package xlsx
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	nsMain          = "http://schemas.openxmlformats.org/spreadsheetml/2006/main"
+	nsRelationships = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+
+	styleDefault = 0
+	styleHeader  = 1
+	styleDate    = 2
+)
+
+// excelEpoch is day zero of the 1900 date system. Starting at Dec 30 rather
+// than Jan 1 accounts for Excel treating 1900 as a leap year.
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+type cellKind int
+
+const (
+	kindEmpty cellKind = iota
+	kindString
+	kindNumber
+	kindDate
+)
+
+type Cell struct {
+	kind   cellKind
+	text   string
+	number float64
+}
+
+func String(s string) Cell {
+	return Cell{kind: kindString, text: s}
+}
+
+func Number(n float64) Cell {
+	return Cell{kind: kindNumber, number: n}
+}
+
+// Date is a date cell showing the UTC time, or an empty cell for nil.
+func Date(t *time.Time) Cell {
+	if t == nil {
+		return Cell{}
+	}
+	utc := t.UTC()
+	days := float64(utc.Unix()-excelEpoch.Unix()) / 86400
+	days += float64(utc.Nanosecond()) / float64(24*time.Hour)
+	return Cell{kind: kindDate, number: days}
+}
+
+type Column struct {
+	Name string
+	// Width is in characters; zero leaves the default width.
+	Width float64
+}
+
+type Sheet struct {
+	name    string
+	columns []Column
+	rows    [][]Cell
+}
+
+// AddRow appends a row below the header.
+func (s *Sheet) AddRow(cells ...Cell) {
+	s.rows = append(s.rows, cells)
+}
+
+type Workbook struct {
+	sheets []*Sheet
+}
+
+func NewWorkbook() *Workbook {
+	return &Workbook{}
+}
+
+// AddSheet adds a sheet whose first row holds the column names. Names are
+// at most 31 characters and can't contain any of []:*?/\.
+func (w *Workbook) AddSheet(name string, columns []Column) *Sheet {
+	sheet := &Sheet{name: name, columns: columns}
+	w.sheets = append(w.sheets, sheet)
+	return sheet
+}
+
+// Write writes the workbook as a zip archive.
+func (w *Workbook) Write(out io.Writer) error {
+	type part struct {
+		name  string
+		write func(io.Writer) error
+	}
+
+	zw := zip.NewWriter(out)
+
+	parts := []part{
+		{"[Content_Types].xml", w.writeContentTypes},
+		{"_rels/.rels", writeString(rootRels)},
+		{"xl/workbook.xml", w.writeWorkbook},
+		{"xl/_rels/workbook.xml.rels", w.writeWorkbookRels},
+		{"xl/styles.xml", writeString(styles)},
+	}
+	for i, sheet := range w.sheets {
+		parts = append(parts, part{fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1), sheet.write})
+	}
+
+	for _, part := range parts {
+		pw, err := zw.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if err := part.write(pw); err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+const xmlHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n"
+
+const rootRels = xmlHeader +
+	`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+// styles defines the cell formats referenced by index: the default, the
+// bold, shaded and underlined header, and dates.
+const styles = xmlHeader +
+	`<styleSheet xmlns="` + nsMain + `">` +
+	`<numFmts count="1"><numFmt numFmtId="164" formatCode="yyyy-mm-dd hh:mm"/></numFmts>` +
+	`<fonts count="2">` +
+	`<font><sz val="11"/><name val="Calibri"/><family val="2"/></font>` +
+	`<font><b/><sz val="11"/><name val="Calibri"/><family val="2"/></font>` +
+	`</fonts>` +
+	`<fills count="3">` +
+	`<fill><patternFill patternType="none"/></fill>` +
+	`<fill><patternFill patternType="gray125"/></fill>` +
+	`<fill><patternFill patternType="solid"><fgColor rgb="FFD9E1F2"/><bgColor indexed="64"/></patternFill></fill>` +
+	`</fills>` +
+	`<borders count="2">` +
+	`<border><left/><right/><top/><bottom/><diagonal/></border>` +
+	`<border><left/><right/><top/><bottom style="thin"><color auto="1"/></bottom><diagonal/></border>` +
+	`</borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="3">` +
+	`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="2" borderId="1" xfId="0" applyFont="1" applyFill="1" applyBorder="1"/>` +
+	`<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`</cellXfs>` +
+	`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>` +
+	`</styleSheet>`
+
+func writeString(s string) func(io.Writer) error {
+	return func(w io.Writer) error {
+		_, err := io.WriteString(w, s)
+		return err
+	}
+}
+
+func (w *Workbook) writeContentTypes(out io.Writer) error {
+	var b strings.Builder
+	b.WriteString(xmlHeader)
+	b.WriteString(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
+	b.WriteString(`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`)
+	b.WriteString(`<Default Extension="xml" ContentType="application/xml"/>`)
+	b.WriteString(`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+	b.WriteString(`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+	for i := range w.sheets {
+		fmt.Fprintf(&b, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i+1)
+	}
+	b.WriteString(`</Types>`)
+
+	_, err := io.WriteString(out, b.String())
+	return err
+}
+
+func (w *Workbook) writeWorkbook(out io.Writer) error {
+	var b strings.Builder
+	b.WriteString(xmlHeader)
+	b.WriteString(`<workbook xmlns="` + nsMain + `" xmlns:r="` + nsRelationships + `"><sheets>`)
+	for i, sheet := range w.sheets {
+		fmt.Fprintf(&b, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, escape(sheet.name), i+1, i+1)
+	}
+	b.WriteString(`</sheets><definedNames>`)
+	// Excel keeps the autofilter range of every sheet as a hidden name.
+	for i, sheet := range w.sheets {
+		fmt.Fprintf(&b, `<definedName name="_xlnm._FilterDatabase" localSheetId="%d" hidden="1">%s!%s</definedName>`,
+			i, escape(quoteSheetName(sheet.name)), sheet.absoluteRange())
+	}
+	b.WriteString(`</definedNames></workbook>`)
+
+	_, err := io.WriteString(out, b.String())
+	return err
+}
+
+func (w *Workbook) writeWorkbookRels(out io.Writer) error {
+	var b strings.Builder
+	b.WriteString(xmlHeader)
+	b.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i := range w.sheets {
+		fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i+1, i+1)
+	}
+	fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, len(w.sheets)+1)
+	b.WriteString(`</Relationships>`)
+
+	_, err := io.WriteString(out, b.String())
+	return err
+}
+
+// write writes the worksheet with the header row frozen and filterable.
+func (s *Sheet) write(out io.Writer) error {
+	var b strings.Builder
+	b.WriteString(xmlHeader)
+	b.WriteString(`<worksheet xmlns="` + nsMain + `" xmlns:r="` + nsRelationships + `">`)
+	fmt.Fprintf(&b, `<dimension ref="%s"/>`, s.ref())
+	b.WriteString(`<sheetViews><sheetView workbookViewId="0">`)
+	b.WriteString(`<pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/>`)
+	b.WriteString(`<selection pane="bottomLeft" activeCell="A2" sqref="A2"/>`)
+	b.WriteString(`</sheetView></sheetViews>`)
+
+	if len(s.columns) > 0 {
+		b.WriteString(`<cols>`)
+		for i, column := range s.columns {
+			if column.Width > 0 {
+				fmt.Fprintf(&b, `<col min="%d" max="%d" width="%s" customWidth="1"/>`, i+1, i+1, strconv.FormatFloat(column.Width, 'f', -1, 64))
+			}
+		}
+		b.WriteString(`</cols>`)
+	}
+
+	b.WriteString(`<sheetData>`)
+	header := make([]Cell, 0, len(s.columns))
+	for _, column := range s.columns {
+		header = append(header, String(column.Name))
+	}
+	writeRow(&b, 1, header, styleHeader)
+	for i, row := range s.rows {
+		writeRow(&b, i+2, row, styleDefault)
+		// Flush every so often so large sheets aren't held twice.
+		if b.Len() > 64<<10 {
+			if _, err := io.WriteString(out, b.String()); err != nil {
+				return err
+			}
+			b.Reset()
+		}
+	}
+	b.WriteString(`</sheetData>`)
+
+	if len(s.columns) > 0 {
+		fmt.Fprintf(&b, `<autoFilter ref="%s"/>`, s.ref())
+	}
+	b.WriteString(`</worksheet>`)
+
+	_, err := io.WriteString(out, b.String())
+	return err
+}
+
+func writeRow(b *strings.Builder, n int, cells []Cell, style int) {
+	fmt.Fprintf(b, `<row r="%d">`, n)
+	for i, cell := range cells {
+		ref := columnName(i) + strconv.Itoa(n)
+		switch cell.kind {
+		case kindString:
+			fmt.Fprintf(b, `<c r="%s"%s t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, styleAttr(style), escape(cell.text))
+		case kindNumber:
+			fmt.Fprintf(b, `<c r="%s"%s><v>%s</v></c>`, ref, styleAttr(style), strconv.FormatFloat(cell.number, 'f', -1, 64))
+		case kindDate:
+			fmt.Fprintf(b, `<c r="%s"%s><v>%s</v></c>`, ref, styleAttr(styleDate), strconv.FormatFloat(cell.number, 'f', -1, 64))
+		}
+	}
+	b.WriteString(`</row>`)
+}
+
+func styleAttr(style int) string {
+	if style == styleDefault {
+		return ""
+	}
+	return fmt.Sprintf(` s="%d"`, style)
+}
+
+// ref is the range of the header and every row, such as A1:K20.
+func (s *Sheet) ref() string {
+	columns := max(len(s.columns), 1)
+	return fmt.Sprintf("A1:%s%d", columnName(columns-1), len(s.rows)+1)
+}
+
+func (s *Sheet) absoluteRange() string {
+	columns := max(len(s.columns), 1)
+	return fmt.Sprintf("$A$1:$%s$%d", columnName(columns-1), len(s.rows)+1)
+}
+
+// columnName returns the letters of the 0-based column: A, B, ..., Z, AA.
+func columnName(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+func quoteSheetName(name string) string {
+	return "'" + strings.ReplaceAll(name, "'", "''") + "'"
+}
+
+// escape escapes text for XML content and attributes. Characters XML can't
+// represent, such as most control characters, are replaced.
+func escape(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}